@@ -0,0 +1,73 @@
+package exectest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// checkAnchored checks the lines of the want are the first lines of the
+// output with the [Prefix] option and the last ones with the [Suffix] one.
+func checkAnchored(name, want, got, spool string, diff DiffOption, opts []cmp.Option) (mismatch, bool) {
+	wantLines := toLines(want)
+	first, last, err := outputEnds(got, spool, len(wantLines))
+	if err != nil {
+		return mismatch{message: fmt.Sprintf("Failed to read spooled %s: %s", name, err)}, false
+	}
+	if diff&Prefix != 0 {
+		if m, ok := checkAnchoredLines(name, "prefix", wantLines, first, got, spool, opts); !ok {
+			return m, false
+		}
+	}
+	if diff&Suffix != 0 {
+		if m, ok := checkAnchoredLines(name, "suffix", wantLines, last, got, spool, opts); !ok {
+			return m, false
+		}
+	}
+	return mismatch{}, true
+}
+
+// checkAnchoredLines compares the want lines with the ones of the end of the
+// output.
+func checkAnchoredLines(name, end string, want, got []string, output, spool string, opts []cmp.Option) (mismatch, bool) {
+	if diff := cmp.Diff(want, got, opts...); diff != "" {
+		m := mismatch{message: fmt.Sprintf("Failed matching %s %s (-missing line, +extra line): \n%s", name, end, diff)}
+		if spool == "" {
+			m.output = fmt.Sprintf("%s:\n%s", name, output)
+		}
+		return m, false
+	}
+	return mismatch{}, true
+}
+
+// outputEnds returns the first and the last n lines of the output, the
+// spooled one is read line by line.
+func outputEnds(got, spool string, n int) ([]string, []string, error) {
+	var output io.Reader = strings.NewReader(got)
+	if spool != "" {
+		file, err := os.Open(spool)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+		output = file
+	}
+	var first, last []string
+	reader := bufio.NewReader(output)
+	for {
+		line, ok := readSpoolLine(reader)
+		if !ok {
+			return first, last, nil
+		}
+		if len(first) < n {
+			first = append(first, line)
+		}
+		if last = append(last, line); len(last) > n {
+			last = last[1:]
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const reportScript = `echo "Report v1"; echo "========="; date; echo "Total: 3"`
+
+func TestExecuteStdoutPrefixSuffix(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+reportScript+`; echo "BANNER" >&2; echo "$$" >&2
+--stdout-prefix
+Report v1
+=========
+--stderr: prefix, ignore-case
+banner
+`)
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+reportScript+`
+--stdout-suffix
+Total: 3
+`, exectest.WithOutputSpool(t.TempDir()))
+}
+
+func TestExecuteStdoutPrefixFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:`+reportScript+`
+--stdout-prefix
+Report v2
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching stdout prefix (-missing line, +extra line):", `"Report v2\n"`, `"Report v1\n"`)
+}
+
+func TestExecuteStdoutSuffixFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:echo short
+--stdout-suffix
+Total: 3
+short
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching stdout suffix (-missing line, +extra line):", `"Total: 3\n"`)
+}
+
+func TestExecuteForFileUpdateKeepsPrefix(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `--arg:-c
+--arg:echo header; echo body; exit 1
+--stdout-prefix
+header
+`)
+
+	exectest.ExecuteForFile(t, "sh", file)
+
+	assertFileContent(t, file, `--arg:-c
+--arg:echo header; echo body; exit 1
+--stdout-prefix
+header
+--return-code: 1
+`)
+}
@@ -0,0 +1,81 @@
+package exectest
+
+import "io"
+
+// WithStripANSI removes the ANSI escape sequences, e.g. colors and cursor
+// movements, from the output before the comparison, the same as the
+// `--strip-ansi` directive does.
+func WithStripANSI() Option {
+	return func(c *config) {
+		c.stripANSI = true
+	}
+}
+
+type ansiState int
+
+const (
+	ansiText ansiState = iota
+	// ansiEscape is right after the ESC.
+	ansiEscape
+	// ansiIntermediate is within the ESC sequence with intermediate bytes.
+	ansiIntermediate
+	// ansiCSI is within the control sequence, ESC [.
+	ansiCSI
+	// ansiOSC is within the operating system command, ESC ].
+	ansiOSC
+	// ansiOSCEscape is the ESC within the operating system command.
+	ansiOSCEscape
+)
+
+// ansiStripper writes the text without the ANSI escape sequences to the w,
+// the sequences might be split between writes.
+type ansiStripper struct {
+	w     io.Writer
+	state ansiState
+}
+
+func (s *ansiStripper) Write(p []byte) (int, error) {
+	text := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch s.state {
+		case ansiText:
+			if b == 0x1b {
+				s.state = ansiEscape
+			} else {
+				text = append(text, b)
+			}
+		case ansiEscape:
+			switch {
+			case b == '[':
+				s.state = ansiCSI
+			case b == ']':
+				s.state = ansiOSC
+			case b >= 0x20 && b <= 0x2f:
+				s.state = ansiIntermediate
+			default:
+				s.state = ansiText
+			}
+		case ansiIntermediate:
+			if b < 0x20 || b > 0x2f {
+				s.state = ansiText
+			}
+		case ansiCSI:
+			if b >= 0x40 && b <= 0x7e {
+				s.state = ansiText
+			}
+		case ansiOSC:
+			switch b {
+			case 0x07:
+				s.state = ansiText
+			case 0x1b:
+				s.state = ansiOSCEscape
+			}
+		case ansiOSCEscape:
+			s.state = ansiText
+		}
+	}
+	if _, err := s.w.Write(text); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
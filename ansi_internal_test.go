@@ -0,0 +1,20 @@
+package exectest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestANSIStripperSplitWrites(t *testing.T) {
+	var out strings.Builder
+	stripper := &ansiStripper{w: &out}
+	for _, part := range []string{"a\x1b", "[3", "1mb\x1b]0;ti", "tle\x1b", "\\c\x1b(", "Bd"} {
+		if _, err := stripper.Write([]byte(part)); err != nil {
+			t.Fatalf("Failed to write: %s", err)
+		}
+	}
+
+	if got := out.String(); got != "abcd" {
+		t.Errorf("Unexpected stripped text: %q", got)
+	}
+}
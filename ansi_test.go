@@ -0,0 +1,31 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const colorScript = `printf '\033[1;31merror\033[0m: \033]8;;https://example.com\007link\033]8;;\007\n'; printf '\033[2K\033[32mok\033[0m\n' >&2`
+
+func TestExecuteStripANSI(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+colorScript+`
+--strip-ansi
+--stdout
+error: link
+--stderr
+ok
+`)
+}
+
+func TestExecuteWithStripANSI(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+colorScript+`
+--output
+error: link
+ok
+`, exectest.WithStripANSI())
+}
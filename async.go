@@ -0,0 +1,81 @@
+package exectest
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// goroutineTB is the TB of a goroutine other than the test one: the Fatal
+// and the Skip calls stop the goroutine only, [runGoroutines] reports them on
+// the test goroutine once all the goroutines exit.
+type goroutineTB struct {
+	testing.TB
+
+	failed  bool
+	skipped bool
+	reason  string
+}
+
+func (g *goroutineTB) Fatal(args ...any) {
+	g.TB.Helper()
+	g.TB.Error(args...)
+	g.FailNow()
+}
+
+func (g *goroutineTB) Fatalf(format string, args ...any) {
+	g.TB.Helper()
+	g.TB.Errorf(format, args...)
+	g.FailNow()
+}
+
+func (g *goroutineTB) FailNow() {
+	g.TB.Fail()
+	g.failed = true
+	runtime.Goexit()
+}
+
+func (g *goroutineTB) Skip(args ...any) {
+	g.reason = fmt.Sprint(args...)
+	g.SkipNow()
+}
+
+func (g *goroutineTB) Skipf(format string, args ...any) {
+	g.reason = fmt.Sprintf(format, args...)
+	g.SkipNow()
+}
+
+func (g *goroutineTB) SkipNow() {
+	g.skipped = true
+	runtime.Goexit()
+}
+
+// runGoroutines calls the fn for the 0..n-1 indexes in their own goroutines
+// and waits for them. The t must not be stopped off the test goroutine, so
+// the fn gets the [goroutineTB] and its Fatal and Skip calls are repeated by
+// the t after the wait.
+func runGoroutines(t testing.TB, n int, fn func(t testing.TB, i int)) {
+	t.Helper()
+	tbs := make([]*goroutineTB, n)
+	var wg sync.WaitGroup
+	for i := range tbs {
+		tbs[i] = &goroutineTB{TB: t}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fn(tbs[i], i)
+		}(i)
+	}
+	wg.Wait()
+	for _, tb := range tbs {
+		if tb.failed {
+			t.FailNow()
+		}
+	}
+	for _, tb := range tbs {
+		if tb.skipped {
+			t.Skip(tb.reason)
+		}
+	}
+}
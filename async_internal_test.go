@@ -0,0 +1,102 @@
+package exectest
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stopTB is the [testing.TB] recording the errors and the calls stopping the
+// test with the goroutines finished by then.
+type stopTB struct {
+	testing.TB
+	finished *atomic.Int32
+
+	mu     sync.Mutex
+	errors []string
+	stops  []string
+}
+
+func (s *stopTB) Helper() {}
+
+func (s *stopTB) Fail() {}
+
+func (s *stopTB) Errorf(format string, args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, fmt.Sprintf(format, args...))
+}
+
+func (s *stopTB) stop(call string) {
+	s.mu.Lock()
+	s.stops = append(s.stops, fmt.Sprintf("%s after %d", call, s.finished.Load()))
+	s.mu.Unlock()
+	runtime.Goexit()
+}
+
+func (s *stopTB) FailNow() {
+	s.stop("FailNow")
+}
+
+func (s *stopTB) Skip(args ...any) {
+	s.stop("Skip: " + fmt.Sprint(args...))
+}
+
+// runStopped runs the goroutines with the fn of the instance 0, the other
+// instance finishes later.
+func runStopped(t *testing.T, fn func(t testing.TB)) *stopTB {
+	t.Helper()
+	tb := &stopTB{TB: t, finished: new(atomic.Int32)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runGoroutines(tb, 2, func(t testing.TB, i int) {
+			if i == 0 {
+				fn(t)
+			}
+			time.Sleep(10 * time.Millisecond)
+			tb.finished.Add(1)
+		})
+		tb.stop("return")
+	}()
+	<-done
+	return tb
+}
+
+func TestRunGoroutinesFatal(t *testing.T) {
+	tb := runStopped(t, func(t testing.TB) {
+		t.Fatalf("Failed %d", 1)
+	})
+
+	if want := []string{"Failed 1"}; !slices.Equal(tb.errors, want) {
+		t.Errorf("Expected errors %q, got %q", want, tb.errors)
+	}
+	if want := []string{"FailNow after 1"}; !slices.Equal(tb.stops, want) {
+		t.Errorf("Expected stops %q, got %q", want, tb.stops)
+	}
+}
+
+func TestRunGoroutinesSkip(t *testing.T) {
+	tb := runStopped(t, func(t testing.TB) {
+		t.Skipf("Skipped %d", 1)
+	})
+
+	if len(tb.errors) > 0 {
+		t.Errorf("Expected no errors, got %q", tb.errors)
+	}
+	if want := []string{"Skip: Skipped 1 after 1"}; !slices.Equal(tb.stops, want) {
+		t.Errorf("Expected stops %q, got %q", want, tb.stops)
+	}
+}
+
+func TestRunGoroutinesPass(t *testing.T) {
+	tb := runStopped(t, func(testing.TB) {})
+
+	if want := []string{"return after 2"}; !slices.Equal(tb.stops, want) {
+		t.Errorf("Expected stops %q, got %q", want, tb.stops)
+	}
+}
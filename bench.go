@@ -0,0 +1,38 @@
+package exectest
+
+import "testing"
+
+// ExecuteBench is the [Execute] for benchmarks.
+//
+// The scheme is prepared once, then the binary is run b.N times in the same
+// directory. Only the results of the first run are asserted and the
+// preparation and assertions are excluded from the measured time, so the
+// reported time per op is the wall time of a single invocation. The
+// resources of every run are released right after it, not piled up until the
+// end of the benchmark.
+func ExecuteBench(b *testing.B, binary, scheme string, opts ...Option) {
+	b.Helper()
+	cfg := newConfig(opts)
+	schemeResult := prepareScheme(b, scheme, cfg)
+
+	var releases []func()
+	collect := func(release func()) { releases = append(releases, release) }
+	release := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+		releases = releases[:0]
+	}
+	b.Cleanup(release)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		executionResult := runCommand(b, binary, schemeResult, cfg.cmdOpts, collect)
+		b.StopTimer()
+		if i == 0 {
+			assertResult(b, schemeResult, executionResult, cfg)
+		}
+		release()
+		b.StartTimer()
+	}
+}
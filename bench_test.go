@@ -0,0 +1,45 @@
+package exectest_test
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func BenchmarkExecuteBenchCat(b *testing.B) {
+	exectest.ExecuteBench(b, "cat", `
+--file:a.txt
+hello
+--arg:a.txt
+--stdout
+hello
+`)
+}
+
+func TestExecuteBenchReleasesRuns(t *testing.T) {
+	spool := t.TempDir()
+	var runs, piled atomic.Int32
+	result := testing.Benchmark(func(b *testing.B) {
+		exectest.ExecuteBench(b, "cat", `
+--file:a.txt
+hello
+--arg:a.txt
+--stdout
+hello
+`, exectest.WithOutputSpool(spool), exectest.WithAfterRun(func(_ testing.TB, _ exectest.Run) {
+			runs.Add(1)
+			if entries, _ := os.ReadDir(spool); len(entries) > 2 {
+				piled.Add(1)
+			}
+		}))
+	})
+
+	if result.N == 0 || runs.Load() < 2 {
+		t.Fatalf("Expected the benchmark to run several times, got %d runs", runs.Load())
+	}
+	if piled.Load() > 0 {
+		t.Errorf("Expected the spools of the previous runs to be removed, piled up in %d of %d runs", piled.Load(), runs.Load())
+	}
+}
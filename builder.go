@@ -0,0 +1,86 @@
+package exectest
+
+import "testing"
+
+// SchemeBuilder builds a [Scheme] programmatically, an alternative to the
+// scheme text for dynamic cases.
+//
+// Example:
+//
+//	exectest.NewScheme().
+//		File("a.txt", "content\n").
+//		Arg("-a").
+//		ExpectStdout(".\n..\na.txt\n").
+//		Run(t, "ls")
+//
+// Values are taken as is, without trimming, but placeholders like `{dir}`
+// are expanded the same way as in the scheme text.
+type SchemeBuilder struct {
+	scheme Scheme
+}
+
+// NewScheme creates an empty [SchemeBuilder].
+func NewScheme() *SchemeBuilder {
+	return &SchemeBuilder{}
+}
+
+// File adds a file with the content to the scheme directory.
+func (b *SchemeBuilder) File(path, content string) *SchemeBuilder {
+	b.scheme.Files = append(b.scheme.Files, File{Path: path, Content: content})
+	return b
+}
+
+// Arg adds arguments passed to the binary.
+func (b *SchemeBuilder) Arg(args ...string) *SchemeBuilder {
+	b.scheme.Args = append(b.scheme.Args, args...)
+	return b
+}
+
+// Env adds the environment variable to the binary environment.
+func (b *SchemeBuilder) Env(key, value string) *SchemeBuilder {
+	b.scheme.Env = append(b.scheme.Env, key+"="+value)
+	return b
+}
+
+// Stdin sets the stdin fed to the binary.
+func (b *SchemeBuilder) Stdin(stdin string) *SchemeBuilder {
+	b.scheme.Stdin = stdin
+	return b
+}
+
+// ExpectStdout sets the expected stdout.
+func (b *SchemeBuilder) ExpectStdout(stdout string) *SchemeBuilder {
+	b.scheme.ExpectedStdout = stdout
+	return b
+}
+
+// ExpectStderr sets the expected stderr.
+func (b *SchemeBuilder) ExpectStderr(stderr string) *SchemeBuilder {
+	b.scheme.ExpectedStderr = stderr
+	return b
+}
+
+// ExpectReturnCode sets the expected return code.
+func (b *SchemeBuilder) ExpectReturnCode(code int) *SchemeBuilder {
+	b.scheme.ExpectedReturnCode = code
+	return b
+}
+
+// Build returns a copy of the built scheme.
+func (b *SchemeBuilder) Build() *Scheme {
+	scheme := b.scheme
+	scheme.Files = append([]File(nil), b.scheme.Files...)
+	scheme.Args = append([]string(nil), b.scheme.Args...)
+	scheme.Env = append([]string(nil), b.scheme.Env...)
+	return &scheme
+}
+
+// Run executes the built scheme the same way as [Execute] does.
+func (b *SchemeBuilder) Run(t testing.TB, binary string, opts ...Option) {
+	t.Helper()
+	scheme := b.Build()
+	logSchemeOnFailure(t, *scheme)
+	cfg := newConfig(opts)
+	schemeResult, executionResult := run(t, binary, scheme, cfg)
+	assertResult(t, schemeResult, executionResult, cfg)
+}
@@ -0,0 +1,43 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestSchemeBuilderRun(t *testing.T) {
+	exectest.NewScheme().
+		File("a.txt", "hello\n").
+		File(".b.txt", "").
+		Arg("-a").
+		ExpectStdout(".\n..\n.b.txt\na.txt\n").
+		Run(t, "ls")
+}
+
+func TestSchemeBuilderKeepsValuesAsIs(t *testing.T) {
+	exectest.NewScheme().
+		Arg("-c", `printf "[%s] %s\n" "$1" "$VALUE"; exit 4`, "sh", "  --stdout  ").
+		Env("VALUE", "{dir}").
+		ExpectStdout("[  --stdout  ] {dir}\n").
+		ExpectReturnCode(4).
+		Run(t, "sh")
+}
+
+func TestSchemeBuilderStdinAndStderr(t *testing.T) {
+	exectest.NewScheme().
+		Arg("-c", "cat >&2").
+		Stdin("--arg:not a directive\n").
+		ExpectStderr("--arg:not a directive\n").
+		Run(t, "sh")
+}
+
+func TestSchemeBuilderBuildCopies(t *testing.T) {
+	builder := exectest.NewScheme().Arg("a")
+	scheme := builder.Build()
+	builder.Arg("b")
+
+	if len(scheme.Args) != 1 {
+		t.Errorf("Built scheme changed after the builder modification: %v", scheme.Args)
+	}
+}
@@ -0,0 +1,130 @@
+package exectest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cutBlockPrefix returns the prefix of the line out of the prefixes of the
+// block variants and the rest of the line.
+func cutBlockPrefix(line string, prefixes ...string) (string, string, bool) {
+	for _, prefix := range prefixes {
+		if rest, ok := strings.CutPrefix(line, prefix); ok {
+			return prefix, rest, true
+		}
+	}
+	return "", "", false
+}
+
+// decodeBlock decodes the block of the bytes or the hex header, the other
+// blocks are returned as is.
+func decodeBlock(header, block string) (string, error) {
+	switch header {
+	case stdoutBytesPrefix, stderrBytesPrefix:
+		return decodeBytes(block)
+	case stdoutHexPrefix, stderrHexPrefix:
+		return decodeHex(block)
+	}
+	return block, nil
+}
+
+// decodeBytes returns the bytes of the `--stdout-bytes` block: the final
+// newline of the block is dropped and the `\\`, `\n`, `\r`, `\t` and `\xHH`
+// escapes are decoded.
+func decodeBytes(block string) (string, error) {
+	block = strings.TrimSuffix(block, "\n")
+	var result strings.Builder
+	for i := 0; i < len(block); i++ {
+		if block[i] != '\\' {
+			result.WriteByte(block[i])
+			continue
+		}
+		if i+1 == len(block) {
+			return "", fmt.Errorf("escape at the end of the block")
+		}
+		i++
+		switch block[i] {
+		case '\\':
+			result.WriteByte('\\')
+		case 'n':
+			result.WriteByte('\n')
+		case 'r':
+			result.WriteByte('\r')
+		case 't':
+			result.WriteByte('\t')
+		case 'x':
+			if i+2 >= len(block) {
+				return "", fmt.Errorf("malformed escape %q", block[i-1:])
+			}
+			b, err := strconv.ParseUint(block[i+1:i+3], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("malformed escape %q", block[i-1:i+3])
+			}
+			result.WriteByte(byte(b))
+			i += 2
+		default:
+			return "", fmt.Errorf("unknown escape %q", block[i-1:i+1])
+		}
+	}
+	return result.String(), nil
+}
+
+// formatBytesBlock turns the output into the `--stdout-bytes` block content, the
+// reverse of [decodeBytes], with the scheme directory replaced back with the
+// `{dir}` placeholder.
+func formatBytesBlock(output, dir string) (string, error) {
+	if dir != "" {
+		output = strings.ReplaceAll(output, dir, "{dir}")
+	}
+	var block strings.Builder
+	for i := 0; i < len(output); i++ {
+		switch c := output[i]; {
+		case c == '\\':
+			block.WriteString(`\\`)
+		case c == '\r':
+			block.WriteString(`\r`)
+		case c == '\n' || c == '\t' || c >= ' ' && c != 0x7f:
+			block.WriteByte(c)
+		default:
+			fmt.Fprintf(&block, `\x%02x`, c)
+		}
+	}
+	block.WriteString("\n")
+	for _, line := range toLines(block.String()) {
+		if isDirective(line) {
+			return "", fmt.Errorf("output line %q can't be represented in a scheme", strings.TrimSuffix(line, "\n"))
+		}
+	}
+	return block.String(), nil
+}
+
+// checkBytes compares the output with the expected one byte by byte, the
+// spooled output is read from the file.
+func checkBytes(name, want, got, spool string) (mismatch, bool) {
+	if spool != "" {
+		content, err := os.ReadFile(spool)
+		if err != nil {
+			return mismatch{message: fmt.Sprintf("Failed to read spooled %s: %s", name, err)}, false
+		}
+		if bytes.Equal(content, []byte(want)) {
+			return mismatch{}, true
+		}
+		if isBinary(want) || isBinary(string(content)) {
+			return hexMismatch(name, want, string(content)), false
+		}
+		return mismatch{message: fmt.Sprintf("Failed to match %s bytes: want %q, got %d bytes of %s", name, want, len(content), spool)}, false
+	}
+	if got == want {
+		return mismatch{}, true
+	}
+	if isBinary(want) || isBinary(got) {
+		return hexMismatch(name, want, got), false
+	}
+	return mismatch{
+		message: fmt.Sprintf("Failed to match %s bytes: want %q, got %q", name, want, got),
+		output:  fmt.Sprintf("%s:\n%s", name, got),
+	}, false
+}
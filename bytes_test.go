@@ -0,0 +1,90 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteStdoutBytes(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:printf 'progress 10%%\r\033[Kdone\\'; printf 'no newline' >&2
+--stdout-bytes
+progress 10%\r\x1b[Kdone\\
+--stderr-bytes
+no newline
+`)
+}
+
+func TestExecuteStdoutBytesFinalNewline(t *testing.T) {
+	exectest.Execute(t, "printf", `
+--arg:a\nb\n
+--stdout-bytes
+a
+b
+
+`)
+}
+
+func TestExecuteStdoutBytesFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:printf 'a\r\n'; printf 'b' >&2
+--stdout-bytes
+a
+--stderr: bytes
+b
+`)
+	})
+
+	assertFailed(t, fake,
+		`Failed to match stdout bytes: want "a", got "a\r\n"`,
+		`Failed to match stderr bytes: want "b\n", got "b"`,
+	)
+}
+
+func TestExecuteWithExactBytes(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "printf", `
+--arg:done
+--stdout
+done
+`, exectest.WithDiffOptions(exectest.ExactBytes))
+	})
+
+	assertFailed(t, fake, `Failed to match stdout bytes: want "done\n", got "done"`)
+}
+
+func TestExecuteStdoutBytesSpooled(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "printf", `
+--arg:a\r\n
+--stdout-bytes
+a\r\n
+--stderr-bytes
+`, exectest.WithOutputSpool(t.TempDir()))
+	})
+
+	assertPassed(t, fake)
+}
+
+func TestExecuteForFileUpdateRewritesStdoutBytes(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `--arg:-c
+--arg:printf 'a\r\tb\\\001'
+--stdout-bytes
+stale
+`)
+
+	exectest.ExecuteForFile(t, "sh", file)
+
+	assertFileContent(t, file, `--arg:-c
+--arg:printf 'a\r\tb\\\001'
+--stdout-bytes
+a\r	b\\\x01
+`)
+}
@@ -0,0 +1,152 @@
+package exectest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// WithResultCache skips the execution of the schemes passed before with the
+// same inputs, the same as EXECTEST_CACHE=1 does. The passed results are
+// stored under `exectest/results` of the [os.UserCacheDir] keyed by the hash
+// of the content of the binary, the scheme and the fingerprint of the
+// environment: the variables and the working directory of the test process
+// and the options of the execution.
+//
+// The func(*exec.Cmd) options, the [WithCmd] and [WithAfterRun] functions,
+// the [WithVariable] placeholders, the [WithCmpOptions] and the files read
+// outside of the scheme directory, e.g. the ones of `--env-file:` and
+// `--golden-dir:`, are not the part of the key, so the cache is for the
+// schemes describing all the inputs of the binary.
+// The cache is not used with a [Runner] and in the update mode.
+func WithResultCache() Option {
+	return func(c *config) {
+		c.resultCache = true
+	}
+}
+
+// binaryHash is the memoized content hash of a binary file.
+type binaryHash struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// binaryHashes memoizes the hashes of the binaries by their paths.
+var binaryHashes sync.Map
+
+// cachedResult reports whether the scheme of the source has passed before,
+// otherwise the result is stored once the test passes.
+func cachedResult(t testing.TB, binary string, source schemeSource, cfg *config) bool {
+	t.Helper()
+	if !cfg.resultCache && os.Getenv("EXECTEST_CACHE") != "1" || cfg.runner != nil || updateMode() {
+		return false
+	}
+	key, err := cacheKey(binary, source, cfg)
+	if err != nil {
+		t.Logf("Failed to compute the cache key: %s", err)
+		return false
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		t.Logf("Failed to find the cache directory: %s", err)
+		return false
+	}
+	entry := filepath.Join(cacheDir, "exectest", "results", key)
+	if _, err := os.Stat(entry); err == nil {
+		t.Logf("Cached result %s", key)
+		return true
+	}
+	t.Cleanup(func() {
+		if t.Failed() || t.Skipped() {
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(entry), 0o755); err != nil {
+			t.Logf("Failed to cache the result: %s", err)
+			return
+		}
+		if err := os.WriteFile(entry, nil, 0o644); err != nil {
+			t.Logf("Failed to cache the result: %s", err)
+		}
+	})
+	return false
+}
+
+// cacheKey returns the hash of the inputs of the scheme execution.
+func cacheKey(binary string, source schemeSource, cfg *config) (string, error) {
+	binaryHash, err := hashBinary(binary)
+	if err != nil {
+		return "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	env := os.Environ()
+	sort.Strings(env)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "binary %s\n", binaryHash)
+	fmt.Fprintf(h, "scheme %q %q %q %q\n", source.file, source.caseName, source.head, source.text)
+	fmt.Fprintf(h, "platform %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(h, "wd %q\n", wd)
+	fmt.Fprintf(h, "env %q\n", env)
+	fmt.Fprintf(h, "options %s\n", cfg.fingerprint())
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fingerprint describes the options of the config affecting the result.
+func (c *config) fingerprint() string {
+	var pty TerminalSize
+	if c.pty != nil {
+		pty = *c.pty
+	}
+	var cassette cassette
+	if c.cassette != nil {
+		cassette = *c.cassette
+	}
+	return fmt.Sprintf("%v %v %v %v %v %v %v %v %v %v %v %v %q %v %q %v %v %v",
+		c.tagFilter, c.coverage, pty, c.diff, c.diffFormat, c.stripANSI, c.concurrent,
+		c.hermeticEnv, c.fakeHome, c.networkIsolation, c.strictScheme, c.processGroup,
+		c.path, c.hasPath, cassette.file, cassette.commands, c.seed, c.hasSeed)
+}
+
+// hashBinary returns the hash of the content of the binary resolved against
+// the PATH, the hashes are memoized until the file changes.
+func hashBinary(binary string) (string, error) {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return "", fmt.Errorf("failed to find binary %s: %w", binary, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat binary %s: %w", path, err)
+	}
+	if cached, ok := binaryHashes.Load(path); ok {
+		if cached := cached.(binaryHash); cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+			return cached.hash, nil
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open binary %s: %w", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash binary %s: %w", path, err)
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	binaryHashes.Store(path, binaryHash{size: info.Size(), modTime: info.ModTime(), hash: hash})
+	return hash, nil
+}
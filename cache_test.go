@@ -0,0 +1,63 @@
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+// countingBinary writes the script appending a line to the returned file on
+// every run and echoing its arguments.
+func countingBinary(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs")
+	binary := filepath.Join(dir, "count.sh")
+	script := "#!/bin/sh\necho run >> " + runs + "\necho \"$@\"\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write binary: %s", err)
+	}
+	return binary, runs
+}
+
+func assertRuns(t *testing.T, runs string, want int) {
+	t.Helper()
+	content, _ := os.ReadFile(runs)
+	if got := strings.Count(string(content), "run\n"); got != want {
+		t.Errorf("Expected %d runs, got %d", want, got)
+	}
+}
+
+func TestExecuteResultCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	binary, runs := countingBinary(t)
+
+	for i := 0; i < 2; i++ {
+		t.Run("run", func(t *testing.T) {
+			exectest.Execute(t, binary, "--arg:hello\n--stdout\nhello\n", exectest.WithResultCache())
+		})
+	}
+	assertRuns(t, runs, 1)
+
+	t.Run("changed", func(t *testing.T) {
+		exectest.Execute(t, binary, "--arg:bye\n--stdout\nbye\n", exectest.WithResultCache())
+	})
+	assertRuns(t, runs, 2)
+}
+
+func TestExecuteResultCacheFailure(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	binary, runs := countingBinary(t)
+
+	for i := 0; i < 2; i++ {
+		fake := runFake(t, func(tb testing.TB) {
+			exectest.Execute(tb, binary, "--arg:hello\n--stdout\nbye\n", exectest.WithResultCache())
+		})
+		assertFailed(t, fake, "Failed matching stdout")
+	}
+
+	assertRuns(t, runs, 2)
+}
@@ -0,0 +1,58 @@
+package exectest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// capturePattern matches the `NAME /regexp/` value of the `--capture:`
+// directive.
+var capturePattern = regexp.MustCompile(`^(\w+)\s+/(.+)/$`)
+
+// Capture is the `--capture:NAME /regexp/` directive of the `--run` step.
+// The first group of the regular expression matched in the stdout of the
+// step, or the whole match without groups, is the value of the `{NAME}`
+// placeholder of the later steps.
+//
+//	--run
+//	--arg:create
+//	--capture:ID /created resource (\w+)/
+//	--run
+//	--arg:get
+//	--arg:{ID}
+type Capture struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+func parseCapture(text string) (Capture, error) {
+	match := capturePattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return Capture{}, fmt.Errorf("malformed --capture %q, expected NAME /regexp/", strings.TrimSpace(text))
+	}
+	pattern, err := regexp.Compile(match[2])
+	if err != nil {
+		return Capture{}, fmt.Errorf("failed to compile --capture regexp of %s: %w", match[1], err)
+	}
+	return Capture{Name: match[1], Pattern: pattern}, nil
+}
+
+// capture returns the value of the capture in the stdout.
+func (c Capture) capture(stdout string) (string, bool) {
+	match := c.Pattern.FindStringSubmatch(stdout)
+	switch {
+	case match == nil:
+		return "", false
+	case len(match) > 1:
+		return match[1], true
+	}
+	return match[0], true
+}
+
+// capture sets the `{NAME}` placeholder to the value, the captured values
+// override the other ones of the same name.
+func (v *variables) capture(name, value string) {
+	v.oldnew = append([]string{"{" + name + "}", value}, v.oldnew...)
+	v.replacer = strings.NewReplacer(v.oldnew...)
+}
@@ -0,0 +1,47 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteCapture(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--run
+--arg:-c
+--arg:echo "created resource r42 at $(date)"
+--capture:ID /created resource (\w+)/
+--capture:WHEN /at .*/
+--stdout-any
+--run
+--arg:-c
+--arg:echo "get {ID}"
+--stdout
+get r42
+--run: echo
+--arg:{WHEN}
+--stdout-lines: 1
+`)
+}
+
+func TestExecuteCaptureFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `
+--run
+--arg:forbidden
+--capture:ID /created resource (\w+)/
+--stdout
+forbidden
+--run
+--arg:{ID}
+--stdout
+never
+`)
+	})
+
+	assertFailed(t, fake, `Failed to capture ID: /created resource (\w+)/ doesn't match the stdout of step 1 (echo)`)
+	if len(fake.errors) != 1 {
+		t.Errorf("Expected the later steps not to run, got:\n%q", fake.errors)
+	}
+}
@@ -0,0 +1,127 @@
+package exectest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// casePrefix starts a case of the scheme, the lines before the first case are
+// shared by all of them.
+const casePrefix = "--case:"
+
+// schemeCase is the `--case:<name>` section of the scheme.
+type schemeCase struct {
+	name   string
+	header string
+	body   string
+}
+
+// splitCases splits the scheme into the shared head and the cases.
+func splitCases(scheme string) (string, []schemeCase, error) {
+	var head strings.Builder
+	var cases []schemeCase
+	names := make(map[string]bool)
+	var heredoc heredocBody
+	for i, line := range toLines(scheme) {
+		name, ok := strings.CutPrefix(line, casePrefix)
+		if heredoc.inside(line) || !ok {
+			if len(cases) == 0 {
+				head.WriteString(line)
+			} else {
+				cases[len(cases)-1].body += line
+			}
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return "", nil, newParseError(i+1, line, fmt.Errorf("--case must have a name"))
+		}
+		if names[name] {
+			return "", nil, newParseError(i+1, line, fmt.Errorf("case %q is defined twice", name))
+		}
+		names[name] = true
+		cases = append(cases, schemeCase{name: name, header: line})
+	}
+	return head.String(), cases, nil
+}
+
+// joinCases is the reverse of [splitCases].
+func joinCases(head string, cases []schemeCase) string {
+	var result strings.Builder
+	result.WriteString(head)
+	for _, c := range cases {
+		result.WriteString(c.header)
+		result.WriteString(c.body)
+	}
+	return result.String()
+}
+
+// replaceCase replaces the body of the named case of the scheme.
+func replaceCase(scheme, name, body string) (string, error) {
+	head, cases, err := splitCases(scheme)
+	if err != nil {
+		return "", err
+	}
+	for i := range cases {
+		if cases[i].name == name {
+			cases[i].body = body
+			return joinCases(head, cases), nil
+		}
+	}
+	return "", fmt.Errorf("case %q is not found", name)
+}
+
+// schemeSource is the scheme text along with the file it's read from, if any.
+type schemeSource struct {
+	text string
+	file string
+	// head and caseName are set for a case of the scheme, the text is the
+	// body of the case then.
+	head     string
+	caseName string
+}
+
+// executeSource runs the scheme, every combination of the `--matrix:` values
+// and every case of the scheme is run as a subtest if the t is a
+// [*testing.T], the one recorded by [ExecuteDir] too, or one by one
+// otherwise, see [runSubtest].
+func executeSource(t testing.TB, binary string, source schemeSource, cfg *config) {
+	t.Helper()
+	text, axes, err := splitMatrix(source.text)
+	if err != nil {
+		t.Fatalf("Failed to parse scheme: %s", err)
+	}
+	if len(axes) > 0 {
+		for _, m := range expandMatrix(text, axes) {
+			// the expanded schemes aren't written back in the update mode.
+			matrixSource := schemeSource{text: m.text}
+			ran := runSubtest(t, m.name, func(t testing.TB) {
+				executeSource(t, binary, matrixSource, cfg)
+			})
+			if !ran {
+				t.Logf("Matrix %s:", m.name)
+				executeSource(t, binary, matrixSource, cfg)
+			}
+		}
+		return
+	}
+	head, cases, err := splitCases(source.text)
+	if err != nil {
+		t.Fatalf("Failed to parse scheme: %s", err)
+	}
+	if len(cases) == 0 {
+		execute(t, binary, source, cfg)
+		return
+	}
+	for _, c := range cases {
+		caseSource := schemeSource{text: c.body, file: source.file, head: head, caseName: c.name}
+		ran := runSubtest(t, c.name, func(t testing.TB) {
+			execute(t, binary, caseSource, cfg)
+		})
+		if !ran {
+			t.Logf("Case %s:", c.name)
+			execute(t, binary, caseSource, cfg)
+		}
+	}
+}
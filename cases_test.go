@@ -0,0 +1,109 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteCases(t *testing.T) {
+	exectest.Execute(t, "sh", `Shared by all the cases.
+--file:name.txt
+gopher
+--case: happy path
+--arg:-c
+--arg:echo "hello, $(cat name.txt)"; touch marker
+--stdout
+hello, gopher
+--case: own directory
+--arg:-c
+--arg:test -e marker || echo "no marker"
+--stdout
+no marker
+--case: bad flag
+--arg:-c
+--arg:echo "unknown flag" >&2; exit 2
+--stderr
+unknown flag
+--return-code: 2
+`)
+}
+
+func TestExecuteCasesWithoutSubtests(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `--case: first
+--arg:one
+--stdout
+one
+--case: second
+--arg:two
+--stdout
+three
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching stdout")
+}
+
+func TestExecuteCasesDuplicateName(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", "--case: same\n--case: same\n")
+	})
+
+	assertFailed(t, fake, `Failed to parse scheme: line 2 "--case: same": case "same" is defined twice`)
+}
+
+func TestExecuteForFileUpdateCases(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `--env:NAME=gopher
+--case: first
+--arg:-c
+--arg:echo "one $NAME"
+--stdout
+stale
+--case: second
+--arg:-c
+--arg:echo two >&2
+`)
+
+	exectest.ExecuteForFile(t, "sh", file)
+
+	assertFileContent(t, file, `--env:NAME=gopher
+--case: first
+--arg:-c
+--arg:echo "one $NAME"
+--stdout
+one gopher
+--case: second
+--arg:-c
+--arg:echo two >&2
+--stderr
+two
+`)
+}
+
+func TestExecuteDirCasesSubtests(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "cases.txt"), `--case: first
+--arg:one
+--stdout
+one
+--case: second
+--arg:two
+--stdout
+two
+`)
+
+	var names []string
+	exectest.ExecuteDir(t, "echo", dir, exectest.WithAfterRun(func(tb testing.TB, _ exectest.Run) {
+		names = append(names, tb.Name())
+	}))
+
+	want := []string{t.Name() + "/cases.txt/first", t.Name() + "/cases.txt/second"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Want the cases run as subtests %q, got %q", want, names)
+	}
+}
@@ -0,0 +1,262 @@
+package exectest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// CassetteEntry is an invocation of an external command recorded to the
+// cassette, see [WithCassette].
+type CassetteEntry struct {
+	Command  string   `json:"command"`
+	Args     []string `json:"args"`
+	Stdout   string   `json:"stdout"`
+	Stderr   string   `json:"stderr"`
+	ExitCode int      `json:"exit_code"`
+}
+
+// cassette is the configuration of [WithCassette].
+type cassette struct {
+	file     string
+	commands []string
+}
+
+// WithCassette records and replays the invocations of the external commands
+// run by the binary. The commands are shadowed by the stubs on the PATH of the
+// binary.
+//
+// The stubs run the real commands and the invocations with their output and
+// exit codes are written to the JSON cassette file in the update mode, see
+// [ExecuteForFile], or if the file doesn't exist. Otherwise the stubs serve the
+// recorded output and the scheme fails if the binary invokes the commands
+// with other arguments or in another order. The stdin of the commands is
+// neither recorded nor compared. The stubs are shell scripts, so the
+// cassettes are for Unix only, and the `--stub` blocks of the scheme replace
+// them on the PATH.
+//
+// Example:
+//
+//	exectest.Execute(t, binary, scheme, exectest.WithCassette("testdata/git.json", "git"))
+func WithCassette(file string, commands ...string) Option {
+	return func(c *config) {
+		c.cassette = &cassette{file: file, commands: commands}
+	}
+}
+
+// cassetteLock serializes the stubs allocating the invocation numbers.
+const cassetteLock = `until mkdir "$dir/lock" 2>/dev/null; do sleep 0.01; done
+n=$(($(cat "$dir/count") + 1))
+echo "$n" > "$dir/count"
+rmdir "$dir/lock"
+`
+
+const recordStub = `#!/bin/sh
+dir=%s
+` + cassetteLock + `mkdir "$dir/$n"
+echo %s > "$dir/$n/command"
+printf '%%s\0' "$@" > "$dir/$n/args"
+%s "$@" > "$dir/$n/stdout" 2> "$dir/$n/stderr"
+code=$?
+echo "$code" > "$dir/$n/exit"
+cat "$dir/$n/stdout"
+cat "$dir/$n/stderr" >&2
+exit "$code"
+`
+
+const replayStub = `#!/bin/sh
+dir=%s
+` + cassetteLock + `mkdir -p "$dir/$n"
+printf '%%s\0' %s "$@" > "$dir/$n/got"
+if ! cmp -s "$dir/$n/want" "$dir/$n/got"; then
+	echo "exectest: unexpected invocation $n of %s" >&2
+	exit 127
+fi
+cat "$dir/$n/stdout"
+cat "$dir/$n/stderr" >&2
+exit "$(cat "$dir/$n/exit")"
+`
+
+// cassetteEnv prepends the stubs of the cassette commands to the path, the
+// cassette is written or the invocations are checked in the test cleanup.
+func cassetteEnv(t testing.TB, c *cassette, path string) string {
+	t.Helper()
+	entries, err := readCassette(c.file)
+	record := updateMode() || errors.Is(err, os.ErrNotExist)
+	if err != nil && !record {
+		t.Fatalf("Failed to read cassette %s: %s", c.file, err)
+	}
+	bin, dir := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "count"), []byte("0\n"), 0o644); err != nil {
+		t.Fatalf("Failed to prepare cassette %s: %s", c.file, err)
+	}
+	for _, command := range c.commands {
+		script := fmt.Sprintf(replayStub, shellQuote(dir), shellQuote(command), command)
+		if record {
+			path, err := exec.LookPath(command)
+			if err != nil {
+				t.Fatalf("Failed to find %s to record: %s", command, err)
+			}
+			script = fmt.Sprintf(recordStub, shellQuote(dir), shellQuote(command), shellQuote(path))
+		}
+		if err := os.WriteFile(filepath.Join(bin, command), []byte(script), 0o755); err != nil {
+			t.Fatalf("Failed to write stub %s: %s", command, err)
+		}
+	}
+
+	if record {
+		t.Cleanup(func() {
+			if err := saveCassette(dir, c.file); err != nil {
+				t.Errorf("Failed to record cassette %s: %s", c.file, err)
+				return
+			}
+			t.Logf("Recorded cassette %s", c.file)
+		})
+	} else {
+		if err := loadCassette(dir, entries); err != nil {
+			t.Fatalf("Failed to prepare cassette %s: %s", c.file, err)
+		}
+		t.Cleanup(func() {
+			for _, failure := range checkCassette(dir, entries) {
+				t.Errorf("%s", failure)
+			}
+		})
+	}
+	return prependPath(bin, path)
+}
+
+func readCassette(file string) ([]CassetteEntry, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var entries []CassetteEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// joinArgs encodes the command and the args as the stubs do.
+func joinArgs(command string, args []string) string {
+	var joined strings.Builder
+	for _, arg := range append([]string{command}, args...) {
+		joined.WriteString(arg + "\x00")
+	}
+	return joined.String()
+}
+
+func splitArgs(joined string) []string {
+	args := strings.Split(joined, "\x00")
+	return args[:len(args)-1]
+}
+
+// loadCassette writes the recorded invocations for the replay stubs.
+func loadCassette(dir string, entries []CassetteEntry) error {
+	for i, entry := range entries {
+		entryDir := filepath.Join(dir, strconv.Itoa(i+1))
+		if err := os.MkdirAll(entryDir, 0o755); err != nil {
+			return err
+		}
+		for name, content := range map[string]string{
+			"want":   joinArgs(entry.Command, entry.Args),
+			"stdout": entry.Stdout,
+			"stderr": entry.Stderr,
+			"exit":   strconv.Itoa(entry.ExitCode),
+		} {
+			if err := os.WriteFile(filepath.Join(entryDir, name), []byte(content), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// invocations returns the number of the invocations of the stubs.
+func invocations(dir string) (int, error) {
+	count, err := os.ReadFile(filepath.Join(dir, "count"))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(count)))
+}
+
+// saveCassette writes the invocations recorded by the stubs to the file.
+func saveCassette(dir, file string) error {
+	count, err := invocations(dir)
+	if err != nil {
+		return err
+	}
+	entries := make([]CassetteEntry, 0, count)
+	for i := 1; i <= count; i++ {
+		read := func(name string) string {
+			content, readErr := os.ReadFile(filepath.Join(dir, strconv.Itoa(i), name))
+			err = errors.Join(err, readErr)
+			return string(content)
+		}
+		entry := CassetteEntry{
+			Command: strings.TrimSpace(read("command")),
+			Args:    splitArgs(read("args")),
+			Stdout:  read("stdout"),
+			Stderr:  read("stderr"),
+		}
+		code := strings.TrimSpace(read("exit"))
+		if err != nil {
+			return err
+		}
+		if entry.ExitCode, err = strconv.Atoi(code); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	content, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(content, '\n'), 0o644)
+}
+
+// checkCassette compares the invocations of the stubs with the recorded ones.
+func checkCassette(dir string, entries []CassetteEntry) []string {
+	count, err := invocations(dir)
+	if err != nil {
+		return []string{fmt.Sprintf("Failed to replay cassette: %s", err)}
+	}
+	var failures []string
+	for i := 1; i <= max(count, len(entries)); i++ {
+		var want string
+		if i <= len(entries) {
+			want = formatInvocation(splitArgs(joinArgs(entries[i-1].Command, entries[i-1].Args)))
+		}
+		got, err := os.ReadFile(filepath.Join(dir, strconv.Itoa(i), "got"))
+		switch {
+		case err != nil && i <= len(entries):
+			failures = append(failures, fmt.Sprintf("Failed to replay invocation %d: want %s, the command is not invoked", i, want))
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("Failed to replay invocation %d: %s", i, err))
+		case i > len(entries):
+			failures = append(failures, fmt.Sprintf("Failed to replay invocation %d: got %s not recorded", i, formatInvocation(splitArgs(string(got)))))
+		case formatInvocation(splitArgs(string(got))) != want:
+			failures = append(failures, fmt.Sprintf("Failed to replay invocation %d: want %s, got %s", i, want, formatInvocation(splitArgs(string(got)))))
+		}
+	}
+	return failures
+}
+
+// formatInvocation quotes the command and the args.
+func formatInvocation(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = strconv.Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
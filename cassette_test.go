@@ -0,0 +1,108 @@
+//go:build unix
+
+package exectest_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const cassetteScheme = `
+--arg:-c
+--arg:basename /tmp/report.txt .txt; echo "code $?"
+--stdout
+report
+code 0
+`
+
+func TestExecuteCassetteRecord(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cassettes", "basename.json")
+
+	t.Run("record", func(t *testing.T) {
+		exectest.Execute(t, "sh", cassetteScheme, exectest.WithCassette(file, "basename"))
+	})
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read cassette: %s", err)
+	}
+	var got []exectest.CassetteEntry
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("Failed to parse cassette: %s", err)
+	}
+	want := []exectest.CassetteEntry{{
+		Command: "basename",
+		Args:    []string{"/tmp/report.txt", ".txt"},
+		Stdout:  "report\n",
+		Stderr:  "",
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Failed matching cassette (-want, +got): \n%s", diff)
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		exectest.Execute(t, "sh", cassetteScheme, exectest.WithCassette(file, "basename"))
+	})
+}
+
+func writeCassette(t *testing.T, entries ...exectest.CassetteEntry) string {
+	t.Helper()
+	content, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatalf("Failed to encode cassette: %s", err)
+	}
+	file := filepath.Join(t.TempDir(), "weather.json")
+	if err := os.WriteFile(file, content, 0o644); err != nil {
+		t.Fatalf("Failed to write cassette: %s", err)
+	}
+	return file
+}
+
+func TestExecuteCassetteReplay(t *testing.T) {
+	file := writeCassette(t,
+		exectest.CassetteEntry{Command: "weather", Args: []string{"today"}, Stdout: "sunny\n"},
+		exectest.CassetteEntry{Command: "weather", Args: []string{"tomorrow"}, Stderr: "no forecast\n", ExitCode: 2},
+	)
+
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:weather today; weather tomorrow; echo "code $?"
+--stdout
+sunny
+code 2
+--stderr
+no forecast
+`, exectest.WithCassette(file, "weather"))
+}
+
+func TestExecuteCassetteReplayMismatch(t *testing.T) {
+	file := writeCassette(t,
+		exectest.CassetteEntry{Command: "weather", Args: []string{"today"}, Stdout: "sunny\n"},
+		exectest.CassetteEntry{Command: "weather", Args: []string{"tomorrow"}, Stdout: "rainy\n"},
+	)
+
+	var fake *fakeTB
+	t.Run("replay", func(t *testing.T) {
+		fake = runFake(t, func(tb testing.TB) {
+			exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:weather yesterday; echo "code $?"
+--stdout
+code 127
+--stderr
+exectest: unexpected invocation 1 of weather
+`, exectest.WithCassette(file, "weather"))
+		})
+	})
+
+	assertFailed(t, fake,
+		`Failed to replay invocation 1: want "weather" "today", got "weather" "yesterday"`,
+		`Failed to replay invocation 2: want "weather" "tomorrow", the command is not invoked`,
+	)
+}
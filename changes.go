@@ -0,0 +1,138 @@
+package exectest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// The kinds of the changes of the `--expect-changes` block.
+const (
+	changeCreated  = "created"
+	changeModified = "modified"
+	changeDeleted  = "deleted"
+)
+
+// Change is a line of the `--expect-changes` block: the kind, `created`,
+// `modified` or `deleted`, and the slash separated path relative to the
+// scheme directory.
+//
+//	--expect-changes
+//	created out/report.txt
+//	modified config.toml
+//	deleted cache.db
+//
+// The files are compared with the snapshot taken right before the start of
+// the binary, a file is modified if its content or permissions changed.
+// The directories are not tracked.
+type Change struct {
+	Kind string
+	Path string
+}
+
+func parseChange(line string) (Change, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return Change{}, fmt.Errorf("malformed change %q, expected <kind> <path>", strings.TrimSpace(line))
+	}
+	switch fields[0] {
+	case changeCreated, changeModified, changeDeleted:
+	default:
+		return Change{}, fmt.Errorf("unknown change %q of %q, expected %s, %s or %s", fields[0], fields[1], changeCreated, changeModified, changeDeleted)
+	}
+	if !filepath.IsLocal(filepath.FromSlash(fields[1])) {
+		return Change{}, fmt.Errorf("changed path %q must be local to the scheme directory", fields[1])
+	}
+	return Change{Kind: fields[0], Path: fields[1]}, nil
+}
+
+func (c Change) format() string {
+	return c.Kind + " " + c.Path + "\n"
+}
+
+// fileState is the state of a file in the snapshot of the directory.
+type fileState struct {
+	mode   fs.FileMode
+	sha256 string
+}
+
+// snapshotDir records the states of the files of the dir by their slash
+// separated paths, the symlinks are compared by their targets.
+func snapshotDir(dir string) (map[string]fileState, error) {
+	snapshot := make(map[string]fileState)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		state := fileState{mode: info.Mode()}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			state.sha256, err = os.Readlink(path)
+		case info.Mode().IsRegular():
+			state.sha256, err = fileSHA256(path)
+		}
+		if err != nil {
+			return err
+		}
+		snapshot[filepath.ToSlash(rel)] = state
+		return nil
+	})
+	return snapshot, err
+}
+
+// diffSnapshots lists the changes between the snapshots sorted by the path.
+func diffSnapshots(before, after map[string]fileState) []Change {
+	var changes []Change
+	for path, state := range after {
+		previous, ok := before[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: changeCreated, Path: path})
+		case previous != state:
+			changes = append(changes, Change{Kind: changeModified, Path: path})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, Change{Kind: changeDeleted, Path: path})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// checkChanges compares the changes of the directory with the expected ones.
+func checkChanges(want, got []Change) (mismatch, bool) {
+	wantLines := make([]string, 0, len(want))
+	for _, change := range want {
+		wantLines = append(wantLines, change.format())
+	}
+	gotLines := make([]string, 0, len(got))
+	for _, change := range got {
+		gotLines = append(gotLines, change.format())
+	}
+	sort.Strings(wantLines)
+	sort.Strings(gotLines)
+	if diff := cmp.Diff(wantLines, gotLines); diff != "" {
+		return mismatch{
+			message: fmt.Sprintf("Failed matching changes (-missing line, +extra line): \n%s", diff),
+			output:  fmt.Sprintf("changes:\n%s", strings.Join(gotLines, "")),
+		}, false
+	}
+	return mismatch{}, true
+}
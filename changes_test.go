@@ -0,0 +1,49 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteExpectChanges(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--file:config.toml
+debug = false
+--file:cache.db
+stale
+--file:README.md
+untouched
+--arg:-c
+--arg:mkdir out && echo done > out/report.txt && echo 'debug = true' > config.toml && rm cache.db && touch README.md
+--expect-changes
+created out/report.txt
+modified config.toml
+deleted cache.db
+`)
+}
+
+func TestExecuteExpectChangesMode(t *testing.T) {
+	exectest.Execute(t, "chmod", `
+--file:run.sh
+--arg:+x
+--arg:run.sh
+--expect-changes
+modified run.sh
+`)
+}
+
+func TestExecuteExpectChangesFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--file:a.txt
+a
+--arg:-c
+--arg:echo b > b.txt; echo changed > a.txt
+--expect-changes
+created b.txt
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching changes", `"modified a.txt\n"`)
+}
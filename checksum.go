@@ -0,0 +1,81 @@
+package exectest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileChecksum is the `--expect-sha256:<path> <hex>` directive, the SHA-256
+// of the file in the scheme directory after the execution.
+type FileChecksum struct {
+	// Path relative to the scheme directory.
+	Path string
+	// SHA256 is the lowercase hex encoded checksum.
+	SHA256 string
+}
+
+func parseFileChecksum(text string) (FileChecksum, error) {
+	text = strings.TrimSpace(text)
+	index := strings.LastIndexAny(text, " \t")
+	if index < 0 {
+		return FileChecksum{}, fmt.Errorf("malformed --expect-sha256 %q, expected <path> <hex>", text)
+	}
+	path, sum := slashPath(text[:index]), strings.ToLower(text[index+1:])
+	if !filepath.IsLocal(path) {
+		return FileChecksum{}, fmt.Errorf("file path %q must be local to the scheme directory", path)
+	}
+	if decoded, err := hex.DecodeString(sum); err != nil || len(decoded) != sha256.Size {
+		return FileChecksum{}, fmt.Errorf("malformed sha256 %q of %q", sum, path)
+	}
+	return FileChecksum{Path: path, SHA256: sum}, nil
+}
+
+// fileSHA256 returns the hex encoded checksum of the file.
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashFiles computes checksums of the expected files of the dir, the files
+// failed to be read are reported as failures.
+func hashFiles(dir string, want []FileChecksum) (map[string]string, []string) {
+	checksums := make(map[string]string, len(want))
+	var failures []string
+	for _, checksum := range want {
+		sum, err := fileSHA256(filepath.Join(dir, checksum.Path))
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("Failed to compute sha256 of %s: %s", checksum.Path, err))
+			continue
+		}
+		checksums[checksum.Path] = sum
+	}
+	return checksums, failures
+}
+
+// checkChecksums reports the files with checksums not matching the expected
+// ones, the files not hashed are reported by [hashFiles].
+func checkChecksums(want []FileChecksum, got map[string]string) []mismatch {
+	var mismatches []mismatch
+	for _, checksum := range want {
+		sum, ok := got[checksum.Path]
+		if ok && sum != checksum.SHA256 {
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match sha256 of %s: want %s, got %s", checksum.Path, checksum.SHA256, sum),
+			})
+		}
+	}
+	return mismatches
+}
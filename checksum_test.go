@@ -0,0 +1,43 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const helloSHA256 = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+func TestExecuteExpectSHA256(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:mkdir out && printf 'hello\n' > out/hello.bin
+--expect-sha256:out/hello.bin `+helloSHA256+`
+`)
+}
+
+func TestExecuteExpectSHA256Mismatch(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:printf 'hello\n' > hello.bin
+--expect-sha256:hello.bin e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+`)
+	})
+
+	assertFailed(t, fake,
+		"Failed to match sha256 of hello.bin",
+		"want e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"got "+helloSHA256,
+	)
+}
+
+func TestExecuteExpectSHA256Missing(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "true", `
+--expect-sha256:missing.bin `+helloSHA256+`
+`)
+	})
+
+	assertFailed(t, fake, "Failed to compute sha256 of missing.bin")
+}
@@ -0,0 +1,177 @@
+// Command exectest is a companion tool for the [github.com/IlyasYOY/exectest]
+// package.
+//
+// Usage:
+//
+//	exectest run -binary BINARY FILE...
+//	exectest record [-dir DIR] [-stdin FILE] -- BINARY [ARGS...]
+//	exectest convert [FILE]
+//	exectest generate [-package NAME] [-binary EXPR] [-o FILE] DIR
+//
+// The run command executes the scheme files against the binary outside of
+// `go test` and reports the result of every file, the exit status is 1 if any
+// of them failed. EXECTEST_UPDATE=1 rewrites the expected blocks of the files.
+//
+// The record command runs the binary and prints a scheme describing the
+// invocation to the stdout.
+//
+// The convert command translates the testscript file, or the stdin, into a
+// txtar scheme printed to the stdout.
+//
+// The generate command emits the Go test file with a test function per
+// scheme file of the dir, see [exectest.GenerateTests]. The package defaults
+// to the $GOPACKAGE set by `go generate`, the binary expression defaults to
+// building the package itself.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "run":
+		err = run(os.Args[2:])
+	case "record":
+		err = record(os.Args[2:])
+	case "convert":
+		err = convert(os.Args[2:])
+	case "generate":
+		err = generate(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "exectest: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: exectest run -binary BINARY FILE...")
+	fmt.Fprintln(os.Stderr, "       exectest record [-dir DIR] [-stdin FILE] -- BINARY [ARGS...]")
+	fmt.Fprintln(os.Stderr, "       exectest convert [FILE]")
+	fmt.Fprintln(os.Stderr, "       exectest generate [-package NAME] [-binary EXPR] [-o FILE] DIR")
+	os.Exit(2)
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	binary := flags.String("binary", "", "binary to execute the schemes against")
+	_ = flags.Parse(args)
+	if *binary == "" || flags.NArg() == 0 {
+		usage()
+	}
+	// The schemes run in their own directories, so the relative binary paths
+	// are resolved against the working directory upfront.
+	if strings.ContainsRune(*binary, filepath.Separator) {
+		var err error
+		if *binary, err = filepath.Abs(*binary); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for _, file := range flags.Args() {
+		if !exectest.RunFile(os.Stdout, *binary, file) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("FAIL %d of %d schemes\n", failed, flags.NArg())
+		os.Exit(1)
+	}
+	fmt.Printf("ok %d schemes\n", flags.NArg())
+	return nil
+}
+
+func record(args []string) error {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	dir := flags.String("dir", "", "directory to run the binary in")
+	stdinFile := flags.String("stdin", "", "file to feed to the binary stdin, - for the own stdin")
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		usage()
+	}
+
+	var stdin []byte
+	var err error
+	switch *stdinFile {
+	case "":
+	case "-":
+		stdin, err = io.ReadAll(os.Stdin)
+	default:
+		stdin, err = os.ReadFile(*stdinFile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	if *dir != "" {
+		if *dir, err = filepath.Abs(*dir); err != nil {
+			return err
+		}
+	}
+
+	scheme, err := exectest.Record(flags.Arg(0), flags.Args()[1:], string(stdin), *dir)
+	if err != nil {
+		return err
+	}
+	fmt.Print(scheme)
+	return nil
+}
+
+func convert(args []string) error {
+	var script []byte
+	var err error
+	switch len(args) {
+	case 0:
+		script, err = io.ReadAll(os.Stdin)
+	case 1:
+		script, err = os.ReadFile(args[0])
+	default:
+		usage()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read script: %w", err)
+	}
+
+	scheme, err := exectest.ConvertTestscript(string(script))
+	if err != nil {
+		return err
+	}
+	fmt.Print(scheme)
+	return nil
+}
+
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	pkg := flags.String("package", os.Getenv("GOPACKAGE"), "package of the test file")
+	binary := flags.String("binary", `exectest.BuildGoBinary(t, ".")`, "Go expression of the binary path")
+	output := flags.String("o", "", "file to write the tests to instead of the stdout")
+	_ = flags.Parse(args)
+	if *pkg == "" || flags.NArg() != 1 {
+		usage()
+	}
+
+	source, err := exectest.GenerateTests(*pkg, *binary, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		fmt.Print(source)
+		return nil
+	}
+	return os.WriteFile(*output, []byte(source), 0o644)
+}
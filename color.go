@@ -0,0 +1,58 @@
+package exectest
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+// WithColorDiff colors the missing lines of the diffs of the failures red
+// and the extra ones green. The colors are disabled when the stdout of the
+// test process is not a terminal or the NO_COLOR is set.
+func WithColorDiff() Option {
+	return func(c *config) {
+		c.colorDiff = true
+	}
+}
+
+// colorEnabled reports whether the stdout of the test process is a terminal
+// accepting the colors.
+func colorEnabled() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorDiff colors the differing lines of the message the same way
+// [limitDiff] finds them, the hunk headers of the unified diffs are cyan.
+func colorDiff(message string) string {
+	lines := strings.SplitAfter(message, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		color := ""
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			color = colorCyan
+		case !isChangedLine(line):
+			continue
+		case line[0] == '-':
+			color = colorRed
+		default:
+			color = colorGreen
+		}
+		text, newline := strings.CutSuffix(line, "\n")
+		lines[i] = color + text + colorReset
+		if newline {
+			lines[i] += "\n"
+		}
+	}
+	return strings.Join(lines, "")
+}
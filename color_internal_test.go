@@ -0,0 +1,47 @@
+package exectest
+
+import (
+	"os"
+	"testing"
+)
+
+func TestColorDiff(t *testing.T) {
+	got := colorDiff("Failed matching stdout:\n--- want stdout\n+++ got stdout\n@@ -1,2 +1,2 @@\n same\n-old\n+new")
+
+	want := "Failed matching stdout:\n--- want stdout\n+++ got stdout\n" +
+		"\x1b[36m@@ -1,2 +1,2 @@\x1b[0m\n same\n\x1b[31m-old\x1b[0m\n\x1b[32m+new\x1b[0m"
+	if got != want {
+		t.Errorf("Expected colored diff %q, got %q", want, got)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	stdout := os.Stdout
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	pipe, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to open pipe: %s", err)
+	}
+	defer pipe.Close()
+	defer writer.Close()
+	os.Stdout = pipe
+	if colorEnabled() {
+		t.Errorf("Expected colors disabled for the pipe")
+	}
+
+	terminal, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %s", os.DevNull, err)
+	}
+	defer terminal.Close()
+	os.Stdout = terminal
+	t.Setenv("NO_COLOR", "")
+	if !colorEnabled() {
+		t.Errorf("Expected colors enabled for the character device")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled() {
+		t.Errorf("Expected colors disabled with NO_COLOR")
+	}
+}
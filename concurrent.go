@@ -0,0 +1,51 @@
+package exectest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// WithConcurrentRuns launches n instances of the binary at the same time in
+// the shared scheme directory, the same as the `--concurrent:` directive does.
+//
+// The results of every instance are asserted, the `--expect-tree`,
+// `--expect-changes` and `--expect-sha256` ones are asserted once against
+// the directory left after all the instances exited.
+func WithConcurrentRuns(n int) Option {
+	return func(c *config) {
+		c.concurrent = n
+	}
+}
+
+// runConcurrent executes n instances of the binary in the same prepared
+// directory and checks their results.
+func runConcurrent(t testing.TB, binary string, scheme *Scheme, cfg *config, n int) ([]mismatch, executionResult) {
+	t.Helper()
+	want := prepareRun(t, scheme, cfg)
+	instance := want
+	instance.ExpectTree, instance.SHA256, instance.ExpectChanges = false, nil, false
+	instance.GoldenDir, instance.Stats, instance.Symlinks = "", nil, nil
+
+	results := make([]executionResult, n)
+	before := snapshotBefore(t, want)
+	start := time.Now()
+	runGoroutines(t, n, func(t testing.TB, i int) {
+		results[i] = executeCommand(t, binary, instance, cfg.cmdOpts)
+	})
+
+	var mismatches []mismatch
+	for i, result := range results {
+		for _, m := range checkResult(instance, result) {
+			m.message = fmt.Sprintf("Instance %d of %d: %s", i+1, n, m.message)
+			mismatches = append(mismatches, m)
+		}
+	}
+	final := executionResult{Duration: time.Since(start)}
+	inspectDir(want, before, &final)
+	for _, failure := range final.Failures {
+		mismatches = append(mismatches, mismatch{message: failure})
+	}
+	mismatches = append(mismatches, checkDir(want, final)...)
+	return mismatches, final
+}
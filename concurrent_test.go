@@ -0,0 +1,41 @@
+package exectest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteConcurrent(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--concurrent: 4
+--arg:-c
+--arg:echo run >> log && echo done
+--stdout
+done
+--expect-tree
+log size=16
+`)
+}
+
+func TestExecuteConcurrentReportsInstances(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "mkdir", `
+--arg:lock
+--expect-tree
+lock/
+`, exectest.WithConcurrentRuns(3))
+	})
+
+	assertFailed(t, fake, "of 3: Failed to match return code: want 0, got 1")
+	var failed int
+	for _, e := range fake.errors {
+		if strings.Contains(e, "Failed to match return code") {
+			failed++
+		}
+	}
+	if failed != 2 {
+		t.Errorf("Expected 2 instances to fail, got %q", fake.errors)
+	}
+}
@@ -0,0 +1,77 @@
+package exectest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// WithCoverage collects coverage data of the executed Go binaries built with
+// `-cover`, see [BuildGoBinary].
+//
+// When tests run with the coverage enabled (`go test -cover`), the data is
+// written to the coverage directory of the test binary, so it shows up in the
+// `go test` reports. Otherwise the data is written to the `covdata`
+// subdirectory of the test artifacts directory (or a temporary directory with
+// Go versions lacking artifacts), use [MergeCoverage] to convert it to a
+// profile.
+func WithCoverage() Option {
+	return func(c *config) {
+		c.coverage = true
+	}
+}
+
+// WithCoverDir collects coverage data of the executed Go binaries built with
+// `-cover` to the dir, see [WithCoverage].
+func WithCoverDir(dir string) Option {
+	return func(c *config) {
+		c.coverage = true
+		c.coverDir = dir
+	}
+}
+
+// MergeCoverage merges coverage data directories into the text profile
+// accepted by `go tool cover`.
+func MergeCoverage(dirs []string, profile string) error {
+	output, err := exec.Command("go", "tool", "covdata", "textfmt",
+		"-i="+strings.Join(dirs, ","),
+		"-o="+profile,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to merge coverage: %w:\n%s", err, output)
+	}
+	return nil
+}
+
+// coverDir returns the directory for the coverage data of the binaries.
+func coverDir(t testing.TB, cfg *config) string {
+	t.Helper()
+	dir := cfg.coverDir
+	if dir == "" {
+		dir = testCoverDir()
+	}
+	if dir == "" {
+		if artifacts, ok := unwrapTB(t).(interface{ ArtifactDir() string }); ok {
+			dir = filepath.Join(artifacts.ArtifactDir(), "covdata")
+		} else {
+			dir = filepath.Join(t.TempDir(), "covdata")
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("Failed to create coverage directory %s: %s", dir, err)
+	}
+	return dir
+}
+
+// testCoverDir is the coverage directory of the test binary, it is set by
+// `go test -cover`.
+func testCoverDir() string {
+	if f := flag.Lookup("test.gocoverdir"); f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package exectest_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteWithCoverageSetsCoverDir(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:test -d "$GOCOVERDIR"
+`, exectest.WithCoverage())
+}
+
+func TestMergeCoverage(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "hello")
+	if output, err := exec.Command("go", "build", "-cover", "-o", binary, "./testdata/hello").CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build: %s\n%s", err, output)
+	}
+	dir := t.TempDir()
+
+	exectest.Execute(t, binary, `
+--arg:coverage
+--stdout
+hello, coverage
+`, exectest.WithCoverDir(dir))
+
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	if err := exectest.MergeCoverage([]string{dir}, profile); err != nil {
+		t.Fatalf("Failed to merge: %s", err)
+	}
+	content, err := os.ReadFile(profile)
+	if err != nil {
+		t.Fatalf("Failed to read profile: %s", err)
+	}
+	if !strings.Contains(string(content), "testdata/hello/main.go") {
+		t.Errorf("Profile misses the binary sources:\n%s", content)
+	}
+}
@@ -0,0 +1,93 @@
+package exectest
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// daemon is the process of the `--daemon` step running in the background.
+type daemon struct {
+	name   string
+	stdout *outputBuffer
+	stderr *outputBuffer
+	group  *processGroup
+	exited chan struct{}
+}
+
+func startDaemon(t testing.TB, name, program string, prepared schemeResult, opts []func(*exec.Cmd)) *daemon {
+	t.Helper()
+	// the daemon runs until the end of the scheme, so its files are not brought back.
+	cmd, stdout, stderr, _ := newCommand(t, program, prepared, opts, t.Cleanup)
+	group := newProcessGroup(cmd, prepared.ProcessGroup)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start %s: %s", name, err)
+	}
+	d := &daemon{
+		name:   name,
+		stdout: stdout,
+		stderr: stderr,
+		group:  group,
+		exited: make(chan struct{}),
+	}
+	go func() {
+		defer close(d.exited)
+		// this is intentional, the daemon is killed in the end anyway
+		_ = cmd.Wait()
+	}()
+	group.watch(0)(cmd.Process, stdout, d.exited)
+	return d
+}
+
+// waitReady waits for the daemon to become ready within the timeout.
+func (d *daemon) waitReady(ready Readiness, timeout time.Duration) error {
+	switch {
+	case ready.Stdout != "":
+		if _, ok := d.stdout.waitFor(ready.Stdout, 0, timeout, d.exited); !ok {
+			return fmt.Errorf("%q did not appear on stdout within %s", ready.Stdout, timeout)
+		}
+	case ready.Port != "":
+		port, err := strconv.Atoi(ready.Port)
+		if err != nil || port <= 0 {
+			return fmt.Errorf("failed to convert ready port %q to positive int", ready.Port)
+		}
+		return waitPort(port, timeout, d.exited)
+	}
+	return nil
+}
+
+// waitPort waits for the port to accept connections.
+func waitPort(port int, timeout time.Duration, exited <-chan struct{}) error {
+	address := net.JoinHostPort("localhost", strconv.Itoa(port))
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", address, 100*time.Millisecond)
+		if err == nil {
+			return conn.Close()
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("port %d is not open within %s", port, timeout)
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("the process exited before port %d is open", port)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// stop kills the daemon along with its group, the output is logged if the
+// test failed.
+func (d *daemon) stop(t testing.TB) {
+	t.Helper()
+	d.group.kill()
+	<-d.exited
+	d.group.release()
+	if t.Failed() {
+		t.Logf("%s stdout:\n%s", d.name, d.stdout)
+		t.Logf("%s stderr:\n%s", d.name, d.stderr)
+	}
+}
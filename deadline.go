@@ -0,0 +1,47 @@
+package exectest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// deadliner is the [testing.TB] with the deadline, e.g. the [*testing.T].
+type deadliner interface {
+	Deadline() (time.Time, bool)
+}
+
+// watchTimeout is the watcher of the `--timeout:` of the process. Without
+// one the process is killed a margin before the deadline of the test, so the
+// hung binary fails the test with the output captured so far instead of the
+// panic of `go test -timeout`.
+func watchTimeout(t testing.TB, group *processGroup, timeout time.Duration) watcher {
+	if timeout > 0 {
+		return group.watch(timeout)
+	}
+	timeout, ok := deadlineTimeout(t, time.Now())
+	if !ok {
+		return group.watch(0)
+	}
+	return group.watchWithin(timeout, fmt.Sprintf("Failed to finish within %s left before the test deadline, the process is killed", timeout))
+}
+
+// deadlineTimeout returns the time left until the deadline of the test
+// without the margin of reporting the failure: the tenth of the time left
+// from a second to a minute, at most its half.
+func deadlineTimeout(t testing.TB, now time.Time) (time.Duration, bool) {
+	d, ok := unwrapTB(t).(deadliner)
+	if !ok {
+		return 0, false
+	}
+	deadline, ok := d.Deadline()
+	if !ok {
+		return 0, false
+	}
+	left := deadline.Sub(now)
+	if left <= 0 {
+		return 0, false
+	}
+	margin := min(max(left/10, time.Second), time.Minute, left/2)
+	return left - margin, true
+}
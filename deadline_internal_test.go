@@ -0,0 +1,72 @@
+package exectest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// deadlineTB is the [testing.TB] with the deadline recording the errors.
+type deadlineTB struct {
+	testing.TB
+	deadline time.Time
+
+	mu     sync.Mutex
+	errors []string
+}
+
+func (d *deadlineTB) Deadline() (time.Time, bool) {
+	return d.deadline, !d.deadline.IsZero()
+}
+
+func (d *deadlineTB) Helper() {}
+
+func (d *deadlineTB) Logf(string, ...any) {}
+
+func (d *deadlineTB) Errorf(format string, args ...any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.errors = append(d.errors, fmt.Sprintf(format, args...))
+}
+
+func TestDeadlineTimeout(t *testing.T) {
+	now := time.Now()
+	for left, want := range map[time.Duration]time.Duration{
+		time.Hour:        59 * time.Minute,
+		10 * time.Minute: 9 * time.Minute,
+		20 * time.Second: 18 * time.Second,
+		5 * time.Second:  4 * time.Second,
+		time.Second:      500 * time.Millisecond,
+	} {
+		got, ok := deadlineTimeout(&deadlineTB{TB: t, deadline: now.Add(left)}, now)
+		if !ok || got != want {
+			t.Errorf("Expected timeout %s for %s left, got %s (%v)", want, left, got, ok)
+		}
+	}
+	recorded := &recordingTB{TB: &deadlineTB{TB: t, deadline: now.Add(time.Minute)}}
+	if got, ok := deadlineTimeout(recorded, now); !ok || got != 54*time.Second {
+		t.Errorf("Expected timeout 54s of the recorded test, got %s (%v)", got, ok)
+	}
+	if _, ok := deadlineTimeout(&deadlineTB{TB: t}, now); ok {
+		t.Errorf("Expected no timeout without the deadline")
+	}
+	if _, ok := deadlineTimeout(&deadlineTB{TB: t, deadline: now.Add(-time.Second)}, now); ok {
+		t.Errorf("Expected no timeout past the deadline")
+	}
+}
+
+func TestExecuteKilledBeforeDeadline(t *testing.T) {
+	tb := &deadlineTB{TB: t, deadline: time.Now().Add(time.Second)}
+	Execute(tb, "sh", `
+--arg:-c
+--arg:echo started; sleep 10
+--stdout
+started
+`)
+
+	if all := strings.Join(tb.errors, "\n"); !strings.Contains(all, "left before the test deadline, the process is killed") {
+		t.Errorf("Expected the kill before the deadline, got:\n%s", all)
+	}
+}
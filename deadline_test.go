@@ -0,0 +1,42 @@
+package exectest_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+// TestExecuteDirDeadlineChild hangs the ExecuteDir scheme until the deadline
+// of the test binary run by the TestExecuteDirDeadline.
+func TestExecuteDirDeadlineChild(t *testing.T) {
+	dir := os.Getenv("EXECTEST_DEADLINE_DIR")
+	if dir == "" {
+		t.Skip("Run by TestExecuteDirDeadline")
+	}
+	exectest.ExecuteDir(t, "sh", dir)
+}
+
+func TestExecuteDirDeadline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Waits for the deadline of the test binary")
+	}
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "hang.txt"), `--arg:-c
+--arg:sleep 30
+`)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExecuteDirDeadlineChild$", "-test.timeout=3s")
+	cmd.Env = append(os.Environ(), "EXECTEST_DEADLINE_DIR="+dir)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected the hung scheme to fail, got:\n%s", output)
+	}
+	if !strings.Contains(string(output), "left before the test deadline, the process is killed") ||
+		strings.Contains(string(output), "panic: test timed out") {
+		t.Errorf("Expected the scheme killed before the deadline, got:\n%s", output)
+	}
+}
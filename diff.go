@@ -0,0 +1,113 @@
+package exectest
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// DiffOption relaxes the comparison of the outputs with the expected ones,
+// see [WithDiffOptions]. The options are combined with `|`.
+type DiffOption uint
+
+const (
+	// IgnoreCase compares lines case-insensitively.
+	IgnoreCase DiffOption = 1 << iota
+	// IgnoreAllSpace ignores all the whitespace when comparing lines.
+	IgnoreAllSpace
+	// ExactBytes compares the raw output with the block byte by byte, the
+	// lines aren't split, so the missing final newline and the `\r` fail
+	// the comparison. The other options are ignored then. The
+	// `--stdout-bytes` and `--stderr-bytes` blocks hold any bytes.
+	ExactBytes
+	// InOrder matches the lines of the block in the same order in the
+	// output, the other lines of the output in between are ignored.
+	InOrder
+	// Prefix and Suffix match the lines of the block with the first or the
+	// last lines of the output, the rest of the output is ignored.
+	Prefix
+	Suffix
+)
+
+// diffOptionNames are the names of the options in the block headers.
+var diffOptionNames = map[string]DiffOption{
+	"ignore-case":      IgnoreCase,
+	"ignore-all-space": IgnoreAllSpace,
+	"bytes":            ExactBytes,
+	"in-order":         InOrder,
+	"prefix":           Prefix,
+	"suffix":           Suffix,
+}
+
+// headerOptions are the options of the block variants.
+var headerOptions = map[string]DiffOption{
+	stdoutBytesPrefix:   ExactBytes,
+	stderrBytesPrefix:   ExactBytes,
+	stdoutHexPrefix:     ExactBytes,
+	stderrHexPrefix:     ExactBytes,
+	stdoutInOrderPrefix: InOrder,
+	stderrInOrderPrefix: InOrder,
+	stdoutPrefixPrefix:  Prefix,
+	stderrPrefixPrefix:  Prefix,
+	stdoutSuffixPrefix:  Suffix,
+	stderrSuffixPrefix:  Suffix,
+}
+
+// WithDiffOptions applies the options to all the output comparisons. The
+// options of a single block are set in its header:
+//
+//	--stderr: ignore-case, ignore-all-space
+//	ls: cannot access 'missing': No such file or directory
+func WithDiffOptions(opts ...DiffOption) Option {
+	return func(c *config) {
+		for _, opt := range opts {
+			c.diff |= opt
+		}
+	}
+}
+
+// parseBlockOptions parses the `: <option>, ...` rest of the block header.
+func parseBlockOptions(rest string) (DiffOption, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(rest), ":")
+	if !ok {
+		return 0, nil
+	}
+	var result DiffOption
+	for _, name := range splitList(rest) {
+		opt, ok := diffOptionNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown diff option %q", name)
+		}
+		result |= opt
+	}
+	return result, nil
+}
+
+func (o DiffOption) normalize(line string) string {
+	if o&IgnoreCase != 0 {
+		line = strings.ToLower(line)
+	}
+	if o&IgnoreAllSpace != 0 {
+		line = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, line)
+	}
+	return line
+}
+
+func (o DiffOption) equal(a, b string) bool {
+	return o.normalize(a) == o.normalize(b)
+}
+
+// cmpOptions are the options of [cmp.Diff] comparing lines.
+func (o DiffOption) cmpOptions() []cmp.Option {
+	if o == 0 {
+		return nil
+	}
+	return []cmp.Option{cmp.Comparer(o.equal)}
+}
@@ -0,0 +1,70 @@
+package exectest_test
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExecuteWithDiffOptions(t *testing.T) {
+	exectest.Execute(t, "echo", `
+--arg:No  such File
+--stdout
+no such file
+`, exectest.WithDiffOptions(exectest.IgnoreCase, exectest.IgnoreAllSpace))
+}
+
+func TestExecuteBlockDiffOptions(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo Hello; echo "a   b" >&2
+--stdout: ignore-case
+hello
+--stderr: ignore-all-space
+a b
+`)
+}
+
+func TestExecuteBlockDiffOptionsApplyToTheBlock(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:echo Hello; echo World >&2
+--stdout: ignore-case
+hello
+--stderr
+world
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching stderr")
+	if len(fake.errors) != 1 {
+		t.Errorf("Expected only stderr to fail, got:\n%q", fake.errors)
+	}
+}
+
+func TestExecuteWithCmpOptions(t *testing.T) {
+	trim := cmp.Transformer("trim", strings.TrimSpace)
+	exectest.Execute(t, "printf", `
+--arg:  padded  \n
+--stdout
+padded
+`, exectest.WithCmpOptions(trim))
+}
+
+func TestExecuteWithCmpOptionsSpooled(t *testing.T) {
+	approx := cmp.Comparer(func(a, b string) bool {
+		x, errX := strconv.ParseFloat(strings.TrimSpace(a), 64)
+		y, errY := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		return errX == nil && errY == nil && math.Abs(x-y) < 0.01
+	})
+	exectest.Execute(t, "echo", `
+--arg:3.1415
+--stdout
+3.14
+`, exectest.WithCmpOptions(approx), exectest.WithOutputSpool(t.TempDir()))
+}
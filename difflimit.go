@@ -0,0 +1,72 @@
+package exectest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// WithMaxDiffLines shows only the first n differing lines of the diffs of
+// the failures followed by the number of the other ones, e.g.
+// `... +1203 lines differ`. The full diffs are written to
+// `exectest-diffs/<test name>` of the [os.TempDir] and their paths are
+// logged.
+func WithMaxDiffLines(n int) Option {
+	return func(c *config) {
+		c.maxDiffLines = n
+	}
+}
+
+// limitDiff keeps the first max differing lines of the message of the
+// mismatch, the full message is kept aside. The lines starting with `-` or
+// `+` after the first one differ, except for the `---` and `+++` headers of
+// the unified diffs.
+func limitDiff(m mismatch, max int) mismatch {
+	if max <= 0 {
+		return m
+	}
+	lines := strings.SplitAfter(m.message, "\n")
+	cut, changed := 0, 0
+	for i := 1; i < len(lines); i++ {
+		if !isChangedLine(lines[i]) {
+			continue
+		}
+		changed++
+		if changed == max+1 {
+			cut = i
+		}
+	}
+	if cut == 0 {
+		return m
+	}
+	m.full = m.message
+	m.message = strings.Join(lines[:cut], "") + fmt.Sprintf("... +%d lines differ", changed-max)
+	return m
+}
+
+func isChangedLine(line string) bool {
+	if strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+		return false
+	}
+	return strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+")
+}
+
+// writeFullDiff writes the full message of the limited mismatch to the
+// artifact file of the test and returns its path.
+func writeFullDiff(t testing.TB, m mismatch) (string, error) {
+	dir := filepath.Join(os.TempDir(), "exectest-diffs", testFileName(t.Name()))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp(dir, "*.diff")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(m.full); err != nil {
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
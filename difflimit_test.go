@@ -0,0 +1,56 @@
+package exectest_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteWithMaxDiffLines(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:seq 1 50
+--stdout
+`+strings.Repeat("x\n", 50), exectest.WithMaxDiffLines(3))
+	})
+
+	assertFailed(t, fake, "Failed matching stdout", "... +97 lines differ")
+	if all := strings.Join(fake.errors, "\n"); strings.Contains(all, `"4\n"`) {
+		t.Errorf("Expected the diff limited to 3 lines, got:\n%s", all)
+	}
+
+	var path string
+	for _, log := range fake.logs {
+		if rest, ok := strings.CutPrefix(log, "Wrote the full diff to "); ok {
+			path = rest
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the full diff %q: %s", path, err)
+	}
+	if !strings.Contains(string(content), `"50\n"`) || strings.Contains(string(content), "lines differ") {
+		t.Errorf("Expected the full diff, got:\n%s", content)
+	}
+}
+
+func TestExecuteWithMaxDiffLinesShortDiff(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `
+--arg:got
+--stdout
+want
+`, exectest.WithMaxDiffLines(2))
+	})
+
+	assertFailed(t, fake, `"want\n"`, `"got\n"`)
+	for _, log := range fake.logs {
+		if strings.HasPrefix(log, "Wrote the full diff") {
+			t.Errorf("Unexpected full diff artifact: %s", log)
+		}
+	}
+}
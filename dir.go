@@ -0,0 +1,97 @@
+package exectest
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ExecuteDir runs every scheme file of the dir as a subtest named after the
+// `--name:` of the scheme or the file, see [ExecuteForFile]. Subdirectories
+// and hidden files are ignored.
+//
+// Unlike [Execute] it requires a [*testing.T] to run subtests.
+//
+// Subtests are run in parallel with [WithParallel]. Results of the schemes
+// are reported with [WithTAP] and [WithReport].
+func ExecuteDir(t *testing.T, binary string, dir string, opts ...Option) {
+	t.Helper()
+	cfg := newConfig(opts)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read scheme directory %s: %v", dir, err)
+	}
+	read := func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, name))
+	}
+	executeEntries(t, entries, cfg, read, func(t testing.TB, name string) {
+		executeFile(t, binary, filepath.Join(dir, name), cfg)
+	})
+}
+
+// ExecuteDirFS is the same as the [ExecuteDir] but reads the scheme files
+// of the dir from the fsys, e.g. the ones embedded with `go:embed`. The
+// update mode doesn't apply to them.
+func ExecuteDirFS(t *testing.T, binary string, fsys fs.FS, dir string, opts ...Option) {
+	t.Helper()
+	cfg := newConfig(opts)
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		t.Fatalf("Failed to read scheme directory %s: %v", dir, err)
+	}
+	read := func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, path.Join(dir, name))
+	}
+	executeEntries(t, entries, cfg, read, func(t testing.TB, name string) {
+		executeFS(t, binary, fsys, path.Join(dir, name), cfg)
+	})
+}
+
+// executeEntries runs the scheme files of the entries as subtests, the read
+// returns the content of the file to find the `--name:` of the subtest.
+func executeEntries(t *testing.T, entries []fs.DirEntry, cfg *config, read func(name string) ([]byte, error), execute func(t testing.TB, name string)) {
+	t.Helper()
+	var semaphore chan struct{}
+	if cfg.parallel > 0 {
+		semaphore = make(chan struct{}, cfg.parallel)
+	}
+
+	results := &caseResults{}
+	t.Cleanup(func() {
+		for _, r := range cfg.reporters {
+			if err := r.report(t.Name(), results.sorted()); err != nil {
+				t.Errorf("Failed to report results: %s", err)
+			}
+		}
+	})
+
+	var index int
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		name := entry.Name()
+		title := name
+		if content, err := read(name); err == nil && !isTxtar(name) {
+			// the read errors are reported by the execution.
+			if scheme := schemeName(string(content)); scheme != "" {
+				title = scheme
+			}
+		}
+		caseIndex := index
+		index++
+		t.Run(title, func(t *testing.T) {
+			if semaphore != nil {
+				t.Parallel()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+			}
+			runCase(t, results, caseIndex, title, func(t testing.TB) {
+				execute(t, name)
+			})
+		})
+	}
+}
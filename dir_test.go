@@ -0,0 +1,136 @@
+package exectest_test
+
+import (
+	"embed"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/IlyasYOY/exectest"
+	"github.com/IlyasYOY/exectest/junit"
+)
+
+func TestExecuteDir(t *testing.T) {
+	exectest.ExecuteDir(t, "cat", "testdata/cat")
+}
+
+//go:embed testdata/cat
+var catSchemes embed.FS
+
+func TestExecuteDirFS(t *testing.T) {
+	exectest.ExecuteDirFS(t, "cat", catSchemes, "testdata/cat")
+}
+
+func TestExecuteForFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schemes/echo.txt": {Data: []byte("--arg:hello\n--stdout\nhello\n")},
+	}
+	exectest.ExecuteForFS(t, "echo", fsys, "schemes/echo.txt")
+}
+
+func TestExecuteForFSMissingFile(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.ExecuteForFS(tb, "echo", fstest.MapFS{}, "missing.txt")
+	})
+
+	assertFailed(t, fake, "Failed to read test file missing.txt")
+}
+
+func TestExecuteDirParallel(t *testing.T) {
+	exectest.ExecuteDir(t, "cat", "testdata/cat", exectest.WithParallel(2))
+}
+
+func TestExecuteDirParallelLimit(t *testing.T) {
+	dir := t.TempDir()
+	lock := filepath.Join(t.TempDir(), "lock")
+	for i := 0; i < 4; i++ {
+		writeFile(t, filepath.Join(dir, fmt.Sprintf("%d.txt", i)), `Fails if another scheme holds the lock.
+--arg:-c
+--arg:mkdir {lock} && sleep 0.05 && rmdir {lock}
+`)
+	}
+
+	exectest.ExecuteDir(t, "sh", dir,
+		exectest.WithParallel(1),
+		exectest.WithVariable("lock", func(string) string { return lock }),
+	)
+}
+
+func TestExecuteDirTAP(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pass.txt"), `
+--stdin
+pass
+--stdout
+pass
+`)
+	writeFile(t, filepath.Join(dir, "skip.txt"), `
+--tags:slow
+`)
+
+	var out strings.Builder
+	t.Run("dir", func(t *testing.T) {
+		exectest.ExecuteDir(t, "cat", dir,
+			exectest.WithTAP(&out),
+			exectest.WithTagFilter("!slow"),
+			exectest.WithParallel(2),
+		)
+	})
+
+	want := `TAP version 13
+1..2
+ok 1 - pass.txt
+ok 2 - skip.txt # SKIP Scheme tags [slow] don't match the filter
+`
+	if got := out.String(); got != want {
+		t.Errorf("Unexpected TAP output:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestExecuteDirJUnitReport(t *testing.T) {
+	var out strings.Builder
+	t.Run("dir", func(t *testing.T) {
+		exectest.ExecuteDir(t, "cat", "testdata/cat", exectest.WithReport(junit.NewWriter(&out)))
+	})
+
+	got := out.String()
+	for _, part := range []string{
+		`<testsuite name="TestExecuteDirJUnitReport/dir" tests="3" failures="0" skipped="0"`,
+		`<testcase name="file.txt" classname="TestExecuteDirJUnitReport/dir"`,
+		`<testcase name="missing.txt"`,
+		`<testcase name="stdin.txt"`,
+	} {
+		if !strings.Contains(got, part) {
+			t.Errorf("Report misses %q:\n%s", part, got)
+		}
+	}
+}
+
+func TestExecuteDirSubtests(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "greet.txt"), `--matrix:GREETING=hi,hello
+--case: gopher
+--arg:{GREETING} gopher
+--stdout
+{GREETING} gopher
+--case: world
+--arg:{GREETING} world
+--stdout
+{GREETING} world
+`)
+
+	var names []string
+	exectest.ExecuteDir(t, "echo", dir, exectest.WithAfterRun(func(tb testing.TB, _ exectest.Run) {
+		names = append(names, strings.TrimPrefix(tb.Name(), t.Name()+"/"))
+	}))
+
+	want := []string{
+		"greet.txt/GREETING=hi/gopher", "greet.txt/GREETING=hi/world",
+		"greet.txt/GREETING=hello/gopher", "greet.txt/GREETING=hello/world",
+	}
+	if strings.Join(names, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Want the subtests %q, got %q", want, names)
+	}
+}
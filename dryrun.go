@@ -0,0 +1,40 @@
+package exectest
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// WithDryRun prepares the scheme without running the binary: the files are
+// created, the placeholders of the arguments and the environment are
+// expanded and the invocation is logged with the t.Logf, then the test is
+// skipped without the assertions. Every `--run` step is logged in the order
+// of the scheme.
+func WithDryRun() Option {
+	return func(c *config) {
+		c.dryRun = true
+	}
+}
+
+// dryRun logs the invocations of the scheme and skips the test.
+func dryRun(t testing.TB, binary string, parsed *Scheme, cfg *config) {
+	t.Helper()
+	if len(parsed.Steps) > 0 {
+		executeSteps(t, binary, parsed, cfg)
+	} else {
+		logInvocation(t, filepath.Base(binary), binary, prepareRun(t, parsed, cfg), cfg.cmdOpts)
+	}
+	t.Skipf("Dry run, the binary is not executed")
+}
+
+// logInvocation logs the cmd the binary would be run with in the prepared
+// conditions.
+func logInvocation(t testing.TB, name, binary string, prepared schemeResult, opts []func(*exec.Cmd)) {
+	t.Helper()
+	cmd, _, _, finish := newCommand(t, binary, prepared, opts, t.Cleanup)
+	t.Logf("Dry run of %s:\n%s", name, describeInvocation(cmd, prepared))
+	if err := finish(); err != nil {
+		t.Fatalf("Failed to finish the run: %s", err)
+	}
+}
@@ -0,0 +1,59 @@
+package exectest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteWithDryRun(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--file:input.txt
+hello
+--env:GREETING=hi
+--arg:-c
+--arg:touch ran; cat {dir}/input.txt
+--stdout
+never matched
+`, exectest.WithDryRun())
+	})
+
+	assertPassed(t, fake)
+	if !fake.skipped {
+		t.Errorf("Expected the dry run to be skipped")
+	}
+	logs := strings.Join(fake.logs, "\n")
+	for _, part := range []string{
+		"Dry run of sh:\n",
+		"' '-c' 'touch ran; cat ",
+		"/input.txt'\n",
+		"  env: +GREETING=hi\n",
+		"  stdin: 0 bytes",
+	} {
+		if !strings.Contains(logs, part) {
+			t.Errorf("Expected dry run log containing %q, got:\n%s", part, logs)
+		}
+	}
+}
+
+func TestExecuteWithDryRunSteps(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--run
+--arg:-c
+--arg:exit 1
+--run:echo
+--arg:second
+`, exectest.WithDryRun())
+	})
+
+	assertPassed(t, fake)
+	logs := strings.Join(fake.logs, "\n")
+	for _, part := range []string{"Dry run of step 1 (sh):\n", "Dry run of step 2 (echo):\n", "' 'second'\n"} {
+		if !strings.Contains(logs, part) {
+			t.Errorf("Expected dry run log containing %q, got:\n%s", part, logs)
+		}
+	}
+}
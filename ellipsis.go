@@ -0,0 +1,67 @@
+package exectest
+
+import (
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	// ellipsisLine matches zero or more arbitrary lines of the output.
+	ellipsisLine = "...\n"
+	// ellipsisToken matches zero or more arbitrary characters of a line.
+	ellipsisToken = "[...]"
+)
+
+func hasEllipsis(lines []string) bool {
+	for _, line := range lines {
+		if line == ellipsisLine || strings.Contains(line, ellipsisToken) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchEllipsis reports whether the got lines match the want ones with the
+// `...` lines and `[...]` tokens.
+func matchEllipsis(want, got []string, diff DiffOption, opts []cmp.Option) bool {
+	// matched[i][j] tells the want[i:] matches the got[j:].
+	matched := make([][]bool, len(want)+1)
+	for i := range matched {
+		matched[i] = make([]bool, len(got)+1)
+	}
+	matched[len(want)][len(got)] = true
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got); j >= 0; j-- {
+			switch {
+			case want[i] == ellipsisLine:
+				matched[i][j] = matched[i+1][j] || (j < len(got) && matched[i][j+1])
+			case j < len(got):
+				matched[i][j] = matched[i+1][j+1] && matchLine(want[i], got[j], diff, opts)
+			}
+		}
+	}
+	return matched[0][0]
+}
+
+// matchLine compares the lines, the want line might have `[...]` tokens.
+func matchLine(want, got string, diff DiffOption, opts []cmp.Option) bool {
+	if !strings.Contains(want, ellipsisToken) {
+		return equalLines(want, got, opts)
+	}
+	parts := strings.Split(diff.normalize(want), ellipsisToken)
+	got = diff.normalize(got)
+	first, last := parts[0], parts[len(parts)-1]
+	if !strings.HasPrefix(got, first) {
+		return false
+	}
+	got = got[len(first):]
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(got, part)
+		if index < 0 {
+			return false
+		}
+		got = got[index+len(part):]
+	}
+	return strings.HasSuffix(got, last)
+}
@@ -0,0 +1,45 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const verboseScript = `echo "starting v1.2.3"; echo "loading config"; echo "loading plugins"; echo "done in 12ms"`
+
+func TestExecuteEllipsis(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+verboseScript+`
+--stdout
+starting [...]
+...
+done in [...]ms
+`)
+}
+
+func TestExecuteEllipsisMatchesNoLines(t *testing.T) {
+	exectest.Execute(t, "echo", `
+--arg:only
+--stdout
+...
+only
+...
+`)
+}
+
+func TestExecuteEllipsisMismatch(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:`+verboseScript+`
+--stdout
+starting [...]
+...
+failed in [...]ms
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching stdout", `"failed in [...]ms\n"`)
+}
@@ -0,0 +1,29 @@
+package exectest
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkEmpty checks nothing is written to the output of the `--stdout-empty`
+// or `--stderr-empty` directive, the spooled output is checked by the size
+// of the file.
+func checkEmpty(name, got, spool string) (mismatch, bool) {
+	if spool != "" {
+		info, err := os.Stat(spool)
+		if err != nil {
+			return mismatch{message: fmt.Sprintf("Failed to read spooled %s: %s", name, err)}, false
+		}
+		if info.Size() > 0 {
+			return mismatch{message: fmt.Sprintf("Failed to match empty %s: got %d bytes in %s", name, info.Size(), spool)}, false
+		}
+		return mismatch{}, true
+	}
+	if got == "" {
+		return mismatch{}, true
+	}
+	return mismatch{
+		message: fmt.Sprintf("Failed to match empty %s: got %d bytes", name, len(got)),
+		output:  fmt.Sprintf("%s:\n%s", name, got),
+	}, false
+}
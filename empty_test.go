@@ -0,0 +1,56 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteStdoutEmpty(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo $RANDOM >&2
+--stdout-empty
+--stderr-any
+`)
+}
+
+func TestExecuteStdoutEmptyFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:printf ' '; printf 'warning\n' >&2
+--stdout-empty
+--stderr-empty
+`)
+	})
+
+	assertFailed(t, fake,
+		"Failed to match empty stdout: got 1 bytes",
+		"Failed to match empty stderr: got 8 bytes",
+	)
+
+	fake = runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", "--stdout-empty\n", exectest.WithOutputSpool(t.TempDir()))
+	})
+
+	assertFailed(t, fake, "Failed to match empty stdout: got 1 bytes in ")
+}
+
+func TestExecuteForFileUpdateKeepsStdoutAny(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `--arg:-c
+--arg:date; exit 1
+--stdout-any
+`)
+
+	exectest.ExecuteForFile(t, "sh", file)
+
+	assertFileContent(t, file, `--arg:-c
+--arg:date; exit 1
+--stdout-any
+--return-code: 1
+`)
+}
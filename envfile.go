@@ -0,0 +1,38 @@
+package exectest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadEnvFile reads the KEY=VALUE lines of the `--env-file:` directive, the
+// empty lines and the `#` comments are skipped.
+//
+// The relative path is resolved in the scheme dir if the file exists there,
+// e.g. it's created with the `--file:` directive, and in the working
+// directory otherwise, e.g. for the testdata files.
+func loadEnvFile(dir, path string) ([]string, error) {
+	if !filepath.IsAbs(path) {
+		if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+			path = filepath.Join(dir, path)
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var env []string
+	for i, line := range toLines(string(content)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.Contains(line, "=") {
+			return nil, fmt.Errorf("line %d: malformed entry %q, expected KEY=VALUE", i+1, line)
+		}
+		env = append(env, line)
+	}
+	return env, nil
+}
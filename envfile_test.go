@@ -0,0 +1,45 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteEnvFile(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--file:service.env
+# created by the scheme
+NAME=svc
+HOME_DIR={dir}/home
+--env-file:service.env
+--env-file:testdata/env/service.env
+--env:LEVEL=debug
+--arg:-c
+--arg:echo $NAME $HOME_DIR $REGION $LEVEL
+--stdout
+svc {dir}/home eu debug
+`)
+}
+
+func TestExecuteEnvFileMalformed(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "true", `
+--file:service.env
+NAME
+--env-file:service.env
+`)
+	})
+
+	assertFailed(t, fake, "Failed to load env file service.env: line 1: malformed entry")
+}
+
+func TestExecuteEnvFileMissing(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "true", `
+--env-file:missing.env
+`)
+	})
+
+	assertFailed(t, fake, "Failed to load env file missing.env")
+}
@@ -0,0 +1,73 @@
+package exectest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// excludeRegexpPrefix marks the exclude as a regular expression.
+const excludeRegexpPrefix = "re:"
+
+// compileExclude returns the function matching the output lines with the
+// exclude: the text is matched as a substring, the `re:` prefixed one as a
+// regular expression.
+func compileExclude(exclude string) (func(line string) bool, error) {
+	pattern, ok := strings.CutPrefix(exclude, excludeRegexpPrefix)
+	if !ok {
+		return func(line string) bool {
+			return strings.Contains(line, exclude)
+		}, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile exclude %q: %w", exclude, err)
+	}
+	return re.MatchString, nil
+}
+
+// checkExcludes reports the excludes matching lines of the output, the
+// spooled output is read from the file line by line.
+func checkExcludes(name string, excludes []string, got, spool string) []mismatch {
+	if len(excludes) == 0 {
+		return nil
+	}
+	var output io.Reader = strings.NewReader(got)
+	if spool != "" {
+		file, err := os.Open(spool)
+		if err != nil {
+			return []mismatch{{message: fmt.Sprintf("Failed to read spooled %s: %s", name, err)}}
+		}
+		defer file.Close()
+		output = file
+	}
+
+	matchers := make([]func(string) bool, len(excludes))
+	for i, exclude := range excludes {
+		// the excludes are validated by the parser.
+		matchers[i], _ = compileExclude(exclude)
+	}
+	reported := make([]bool, len(excludes))
+	var mismatches []mismatch
+	reader := bufio.NewReader(output)
+	for number := 1; ; number++ {
+		line, ok := readSpoolLine(reader)
+		if !ok {
+			break
+		}
+		line = strings.TrimSuffix(line, "\n")
+		for i, match := range matchers {
+			if reported[i] || !match(line) {
+				continue
+			}
+			reported[i] = true
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed excluding %q from %s, found on line %d: %s", excludes[i], name, number, line),
+			})
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,47 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const warningScript = `echo "INFO started"; echo "token=abc123" >&2; echo "WARNING: deprecated flag"`
+
+func TestExecuteExcludes(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo "INFO started"; echo "INFO done" >&2
+--stdout-excludes
+WARNING
+re:^token=\w+
+--stderr-excludes
+ERROR
+--stdout
+INFO started
+--stderr
+INFO done
+`)
+}
+
+func TestExecuteExcludesFound(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:`+warningScript+`
+--stdout-excludes
+WARNING
+--stderr-excludes
+re:token=\w+
+--stdout
+...
+--stderr
+...
+`)
+	})
+
+	assertFailed(t, fake,
+		`Failed excluding "WARNING" from stdout, found on line 2: WARNING: deprecated flag`,
+		`Failed excluding "re:token=\\w+" from stderr, found on line 1: token=abc123`,
+	)
+}
@@ -0,0 +1,6 @@
+//go:build windows
+
+package exectest
+
+// exeSuffix is the `{exe}` placeholder, the suffix of the executables.
+const exeSuffix = ".exe"
@@ -5,37 +5,59 @@ package exectest
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
 
-const (
-	// This is the comment start in Lua, so there might be problems.
-	filePrefix       = "--file:"
-	stdoutPrefix     = "--stdout"
-	stderrPrefix     = "--stderr"
-	stdinPrefix      = "--stdin"
-	envPrefix        = "--env:"
-	argPrefix        = "--arg:"
-	returnCodePrefix = "--return-code:"
-)
-
-type cmdOption func(*exec.Cmd)
-
 // ExecuteForFile the same as the [Execute] but uses a file (path) with a scheme.
-func ExecuteForFile(t *testing.T, binary string, file string, opts ...cmdOption) {
+func ExecuteForFile(t testing.TB, binary string, file string, opts ...Option) {
+	t.Helper()
+	executeFile(t, binary, file, newConfig(opts))
+}
+
+func executeFile(t testing.TB, binary string, file string, cfg *config) {
 	t.Helper()
 	content, err := os.ReadFile(file)
 	if err != nil {
 		t.Fatalf("Failed to read test file %s: %v", file, err)
 	}
-	Execute(t, binary, string(content), opts...)
+	if isTxtar(file) {
+		executeTxtar(t, binary, string(content), cfg)
+		return
+	}
+	executeSource(t, binary, schemeSource{text: string(content), file: file}, cfg)
+}
+
+// ExecuteForFS is the same as the [ExecuteForFile] but reads the scheme file
+// from the fsys, e.g. the one embedded with `go:embed`. The update mode
+// doesn't apply to it.
+func ExecuteForFS(t testing.TB, binary string, fsys fs.FS, path string, opts ...Option) {
+	t.Helper()
+	executeFS(t, binary, fsys, path, newConfig(opts))
+}
+
+func executeFS(t testing.TB, binary string, fsys fs.FS, path string, cfg *config) {
+	t.Helper()
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		t.Fatalf("Failed to read test file %s: %v", path, err)
+	}
+	if isTxtar(path) {
+		executeTxtar(t, binary, string(content), cfg)
+		return
+	}
+	executeSource(t, binary, schemeSource{text: string(content)}, cfg)
 }
 
 // Execute is the main testing facility of the package.
@@ -59,229 +81,974 @@ func ExecuteForFile(t *testing.T, binary string, file string, opts ...cmdOption)
 //
 // This is a desciption of the command `ls -a` run in the
 // directory with a.txt and .b.txt files.
-func Execute(t *testing.T, binary, scheme string, opts ...cmdOption) {
+//
+// The t is a [testing.TB], so schemes might be run in tests, benchmarks, fuzz
+// targets and custom wrappers alike.
+func Execute(t testing.TB, binary, scheme string, opts ...Option) {
 	t.Helper()
-	schemeResult := prepareScheme(t, scheme)
+	executeSource(t, binary, schemeSource{text: scheme}, newConfig(opts))
+}
 
-	executionResult := executeCommand(t, binary, schemeResult.Dir, schemeResult.Args, schemeResult.Stdin, schemeResult.Env, opts)
+// execute runs the scheme of the source.
+func execute(t testing.TB, binary string, source schemeSource, cfg *config) {
+	t.Helper()
+	scheme := source.head + source.text
+	logSchemeOnFailure(t, scheme)
+	executeScheme(t, binary, source, parseScheme(t, scheme, cfg), cfg)
+}
 
-	assertReturnCode(t, schemeResult.ReturnCode, executionResult.ReturnCode)
-	if assertNoDiff(t, "stdout", schemeResult.Stdout, executionResult.Stdout) {
-		t.Logf("stdout:\n%s", executionResult.Stdout)
+// executeScheme runs the parsed scheme of the source.
+func executeScheme(t testing.TB, binary string, source schemeSource, parsed *Scheme, cfg *config) {
+	t.Helper()
+	if !cfg.tagFilter.match(parsed.Tags) || !envTagFilter().match(parsed.Tags) {
+		t.Skipf("Scheme tags %v don't match the filter", parsed.Tags)
 	}
-	if assertNoDiff(t, "stderr", schemeResult.Stderr, executionResult.Stderr) {
-		t.Logf("stderr:\n%s", executionResult.Stderr)
+	skipShort(t, parsed)
+	if err := checkFIFOHandlers(parsed, cfg.fifos); err != nil {
+		t.Fatalf("Failed to serve named pipes: %s", err)
+	}
+	if cfg.dryRun {
+		dryRun(t, binary, parsed, cfg)
+	}
+	if cachedResult(t, binary, source, cfg) {
+		return
+	}
+	if len(parsed.Steps) > 0 {
+		executeSteps(t, binary, parsed, cfg)
+		return
+	}
+	repeat := max(parsed.Repeat, 1)
+	for iteration := 1; iteration <= repeat; iteration++ {
+		mismatches, executionResult, done := executeAttempts(t, binary, source, parsed, cfg)
+		if done {
+			return
+		}
+		if len(mismatches) > 0 {
+			reportSchemeName(t, parsed)
+			if repeat > 1 {
+				t.Errorf("Iteration %d of %d failed:", iteration, repeat)
+			}
+			reportMismatches(t, mismatches, executionResult, cfg)
+			return
+		}
 	}
 }
 
-func assertReturnCode(t *testing.T, want, got int) bool {
+// executeAttempts runs the scheme until it passes or the retries are
+// exhausted and returns the mismatches of the last attempt. It's done if the
+// scheme file is updated instead.
+func executeAttempts(t testing.TB, binary string, source schemeSource, parsed *Scheme, cfg *config) ([]mismatch, executionResult, bool) {
 	t.Helper()
-	if got != want {
-		t.Errorf("Failed to match return code: want %d, got %d", want, got)
-		return true
+	backoff := parsed.RetryBackoff
+	concurrent := parsed.Concurrent
+	if concurrent == 0 {
+		concurrent = cfg.concurrent
 	}
-	return false
+	for attempt := 1; ; attempt++ {
+		var mismatches []mismatch
+		var got executionResult
+		if concurrent > 1 {
+			mismatches, got = runConcurrent(t, binary, parsed, cfg, concurrent)
+		} else {
+			var want schemeResult
+			want, got = run(t, binary, parsed, cfg)
+			if source.file != "" && updateMode() {
+				updateSchemeFile(t, source, want, got)
+				return nil, got, true
+			}
+			mismatches = checkResult(want, got)
+		}
+
+		if len(mismatches) == 0 || attempt > parsed.Retries {
+			return mismatches, got, false
+		}
+		t.Logf("Attempt %d of %d failed:", attempt, parsed.Retries+1)
+		for _, m := range mismatches {
+			t.Logf("%s", m.message)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// run prepares the scheme and executes the binary in it.
+func run(t testing.TB, binary string, scheme *Scheme, cfg *config) (schemeResult, executionResult) {
+	t.Helper()
+	schemeResult := prepareRun(t, scheme, cfg)
+	executionResult := executeCommand(t, binary, schemeResult, cfg.cmdOpts)
+	return schemeResult, executionResult
 }
 
-func assertNoDiff(t *testing.T, name string, want string, got string) bool {
+// prepareRun prepares the scheme with the environment of the configured
+// coverage.
+func prepareRun(t testing.TB, scheme *Scheme, cfg *config) schemeResult {
 	t.Helper()
+	schemeResult := prepare(t, scheme, cfg)
+	if cfg.coverage {
+		schemeResult.Env = append(schemeResult.Env, "GOCOVERDIR="+coverDir(t, cfg))
+	}
+	return schemeResult
+}
+
+func assertResult(t testing.TB, want schemeResult, got executionResult, cfg *config) {
+	t.Helper()
+	reportMismatches(t, checkResult(want, got), got, cfg)
+}
+
+// mismatch is a failed assertion of the execution results.
+type mismatch struct {
+	// message describes the mismatch.
+	message string
+	// output is the actual output logged along with the message, if any.
+	output string
+	// full is the message before [limitDiff], if it's limited.
+	full string
+}
+
+func checkResult(want schemeResult, got executionResult) []mismatch {
+	var mismatches []mismatch
+	for _, failure := range got.Failures {
+		mismatches = append(mismatches, mismatch{message: failure})
+	}
+	started, ok := checkStart(want, got)
+	mismatches = append(mismatches, started...)
+	if !ok {
+		return append(mismatches, checkDir(want, got)...)
+	}
+	switch {
+	case want.KilledBy != "":
+		if got.KilledBy != want.KilledBy {
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match killing signal: want %s, got %s", want.KilledBy, describeTermination(got)),
+			})
+		}
+	case want.StopSignal != "" && want.ReturnCode == 0 && got.KilledBy == want.StopSignal:
+		// the process is stopped as planned.
+	case want.ReturnCodes != nil:
+		if !matchesReturnCodes(want.ReturnCodes, got) {
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match return code: want any of %v, got %s", want.ReturnCodes, describeTermination(got)),
+			})
+		}
+	case got.ReturnCode != want.ReturnCode:
+		mismatches = append(mismatches, mismatch{
+			message: fmt.Sprintf("Failed to match return code: want %d, got %s", want.ReturnCode, describeTermination(got)),
+		})
+	}
+	if want.MaxDuration > 0 && got.Duration > want.MaxDuration {
+		mismatches = append(mismatches, mismatch{
+			message: fmt.Sprintf("Failed to fit max duration: want at most %s, took %s", want.MaxDuration, got.Duration),
+		})
+	}
+	if m, ok := checkMaxRSS(want, got); !ok {
+		mismatches = append(mismatches, m)
+	}
+	mismatches = append(mismatches, checkDir(want, got)...)
+	stdout, stdoutSpool, stderr, stderrSpool := got.Stdout, got.StdoutSpool, got.Stderr, got.StderrSpool
+	if want.CombinedOutput {
+		stdout, stdoutSpool, stderr, stderrSpool = got.Output, got.OutputSpool, got.Output, got.OutputSpool
+	}
+	mismatches = append(mismatches, checkFinalNewline(want, got)...)
+	mismatches = append(mismatches, checkLineCount("stdout", want.StdoutLines, stdout, stdoutSpool)...)
+	mismatches = append(mismatches, checkLineCount("stderr", want.StderrLines, stderr, stderrSpool)...)
+	mismatches = append(mismatches, checkExcludes("stdout", want.StdoutExcludes, stdout, stdoutSpool)...)
+	mismatches = append(mismatches, checkExcludes("stderr", want.StderrExcludes, stderr, stderrSpool)...)
+	if want.CombinedOutput {
+		if m, ok := checkOutput("output", want.Output, got.Output, got.OutputSpool, want.OutputDiff, want.CmpOptions, want.DiffFormat); !ok {
+			mismatches = append(mismatches, m)
+		}
+		return mismatches
+	}
+	// the content isn't compared with the `--stdout-any` and with the line
+	// count without the block.
+	switch {
+	case want.StdoutAny, want.StdoutLines != nil && want.Stdout == "":
+	case want.StdoutEmpty:
+		if m, ok := checkEmpty("stdout", got.Stdout, got.StdoutSpool); !ok {
+			mismatches = append(mismatches, m)
+		}
+	default:
+		if m, ok := checkOutput("stdout", want.Stdout, got.Stdout, got.StdoutSpool, want.StdoutDiff, want.CmpOptions, want.DiffFormat); !ok {
+			mismatches = append(mismatches, m)
+		}
+	}
+	switch {
+	case want.StderrAny, want.StderrLines != nil && want.Stderr == "":
+	case want.StderrEmpty:
+		if m, ok := checkEmpty("stderr", got.Stderr, got.StderrSpool); !ok {
+			mismatches = append(mismatches, m)
+		}
+	default:
+		if m, ok := checkOutput("stderr", want.Stderr, got.Stderr, got.StderrSpool, want.StderrDiff, want.CmpOptions, want.DiffFormat); !ok {
+			mismatches = append(mismatches, m)
+		}
+	}
+	return mismatches
+}
+
+// describeTermination tells how the process terminated.
+func describeTermination(got executionResult) string {
+	if got.KilledBy != "" {
+		return fmt.Sprintf("%d (killed by %s)", got.ReturnCode, got.KilledBy)
+	}
+	return strconv.Itoa(got.ReturnCode)
+}
+
+// checkOutput compares the output with the expected one, the spooled output
+// is compared with [checkNoSpoolDiff]. The expected output might have
+// ellipses, see [matchEllipsis], unless it's spooled or compared with
+// [checkBytes], [checkInOrder] or [checkAnchored].
+func checkOutput(name, want, got, spool string, diff DiffOption, extra []cmp.Option, format DiffFormat) (mismatch, bool) {
+	if diff&ExactBytes != 0 {
+		return checkBytes(name, want, got, spool)
+	}
+	opts := append(diff.cmpOptions(), extra...)
+	if diff&InOrder != 0 {
+		return checkInOrder(name, want, got, spool, opts)
+	}
+	if diff&(Prefix|Suffix) != 0 {
+		return checkAnchored(name, want, got, spool, diff, opts)
+	}
+	if spool != "" {
+		return checkNoSpoolDiff(name, want, spool, opts)
+	}
+	if wantLines := toLines(want); hasEllipsis(wantLines) && matchEllipsis(wantLines, toLines(got), diff, opts) {
+		return mismatch{}, true
+	}
+	return checkNoDiff(name, want, got, opts, format)
+}
+
+func checkNoDiff(name string, want string, got string, opts []cmp.Option, format DiffFormat) (mismatch, bool) {
 	wantLines := toLines(want)
 	gotLines := toLines(got)
-	if diff := cmp.Diff(wantLines, gotLines); diff != "" {
-		t.Errorf("Failed matching %s (-missing line, +extra line): \n%s", name, diff)
-		return true
+	binary := isBinary(want) || isBinary(got)
+	if format == Unified {
+		if diff := unifiedDiff(name, wantLines, gotLines, opts); diff != "" {
+			if binary {
+				return hexMismatch(name, want, got), false
+			}
+			return mismatch{
+				message: fmt.Sprintf("Failed matching %s:\n%s", name, diff),
+				output:  fmt.Sprintf("%s:\n%s", name, got),
+			}, false
+		}
+		return mismatch{}, true
+	}
+	if diff := cmp.Diff(wantLines, gotLines, opts...); diff != "" {
+		if binary {
+			return hexMismatch(name, want, got), false
+		}
+		return mismatch{
+			message: fmt.Sprintf("Failed matching %s (-missing line, +extra line): \n%s", name, diff),
+			output:  fmt.Sprintf("%s:\n%s", name, got),
+		}, false
+	}
+	return mismatch{}, true
+}
+
+// checkDir checks the state of the scheme directory after the execution.
+func checkDir(want schemeResult, got executionResult) []mismatch {
+	var mismatches []mismatch
+	if want.ExpectTree {
+		if m, ok := checkTree(want.Tree, got.Tree); !ok {
+			mismatches = append(mismatches, m)
+		}
+	}
+	if want.ExpectChanges {
+		if m, ok := checkChanges(want.Changes, got.Changes); !ok {
+			mismatches = append(mismatches, m)
+		}
+	}
+	mismatches = append(mismatches, got.Golden...)
+	mismatches = append(mismatches, got.Stats...)
+	mismatches = append(mismatches, got.Symlinks...)
+	return append(mismatches, checkChecksums(want.SHA256, got.SHA256)...)
+}
+
+func reportMismatches(t testing.TB, mismatches []mismatch, got executionResult, cfg *config) {
+	t.Helper()
+	if len(mismatches) > 0 {
+		t.Logf("Execution took %s", got.Duration)
+	}
+	for _, m := range mismatches {
+		m = limitDiff(m, cfg.maxDiffLines)
+		message := m.message
+		if cfg.colorDiff && colorEnabled() {
+			message = colorDiff(message)
+		}
+		t.Errorf("%s", message)
+		if m.full != "" {
+			if path, err := writeFullDiff(t, m); err != nil {
+				t.Logf("Failed to write the full diff: %s", err)
+			} else {
+				t.Logf("Wrote the full diff to %s", path)
+			}
+		}
+		if m.output != "" {
+			t.Logf("%s", m.output)
+		}
 	}
-	return false
 }
 
 type executionResult struct {
-	Stdout     string
-	Stderr     string
-	ReturnCode int
+	Stdout string
+	Stderr string
+	// StdoutSpool and StderrSpool are the files the output is spooled to with
+	// [WithOutputSpool], the Stdout and Stderr are empty then.
+	StdoutSpool string
+	StderrSpool string
+	// Output is the combined stdout and stderr for the `--output` block, the
+	// Stdout and Stderr are empty then.
+	Output      string
+	OutputSpool string
+	ReturnCode  int
+	// KilledBy is the name of the signal terminated the process, if any.
+	KilledBy string
+	Duration time.Duration
+	// MaxRSS is the peak resident set size of the binary in bytes, 0 if it's
+	// not measured.
+	MaxRSS uint64
+	// Tree of the scheme directory, walked if expected.
+	Tree []TreeEntry
+	// SHA256 checksums of the expected files by their paths.
+	SHA256 map[string]string
+	// Changes of the scheme directory, listed if expected.
+	Changes []Change
+	// Golden are the mismatches of the files with the golden directory.
+	Golden []mismatch
+	// Stats are the mismatches of the metadata of the files.
+	Stats []mismatch
+	// Symlinks are the mismatches of the symbolic links.
+	Symlinks []mismatch
+	// StartError is the failure to start the binary, the other results are
+	// empty then.
+	StartError string
+	// Failures happened during the execution, e.g. failed interaction.
+	Failures []string
+}
+
+func executeCommand(t testing.TB, binary string, prepared schemeResult, opts []func(*exec.Cmd)) executionResult {
+	t.Helper()
+	return runCommand(t, binary, prepared, opts, t.Cleanup)
 }
 
-func executeCommand(t *testing.T, binary string, dir string, args []string, stdin string, env []string, opts []cmdOption) executionResult {
+// runCommand is the [executeCommand] passing the release of the resources of
+// the run, e.g. the process group, to the cleanup instead of the t.Cleanup.
+func runCommand(t testing.TB, binary string, prepared schemeResult, opts []func(*exec.Cmd), cleanup func(func())) executionResult {
 	t.Helper()
 
-	cmd := exec.Command(binary)
-	var stdoutBuilder strings.Builder
-	cmd.Stdout = &stdoutBuilder
-	var stderrBuilder strings.Builder
-	cmd.Stderr = &stderrBuilder
-	cmd.Dir = dir
-	cmd.Args = append(cmd.Args, args...)
-	cmd.Stdin = strings.NewReader(stdin)
+	if prepared.MaxRSS > 0 && (!rssSupported || prepared.Runner != nil) {
+		t.Skipf("Failed to measure peak memory: --max-rss is not supported on this platform or with a runner")
+	}
+	cmd, stdout, stderr, finish := newCommand(t, binary, prepared, opts, cleanup)
+
+	feed := feedString(prepared.Stdin)
+	if prepared.Interaction != nil {
+		feed = interact(prepared.Interaction)
+	}
+	group := newProcessGroup(cmd, prepared.ProcessGroup)
+	cleanup(group.release)
+	watchers := []watcher{watchTimeout(t, group, prepared.Timeout)}
+	if prepared.IdleTimeout > 0 {
+		watchers = append(watchers, group.watchIdle(prepared.IdleTimeout, stderr))
+	}
+	for _, signal := range prepared.Signals {
+		watchers = append(watchers, sendSignal(signal, group))
+	}
+	for _, fifo := range prepared.FIFOs {
+		watchers = append(watchers, serveFIFO(fifo))
+	}
+
+	before := snapshotBefore(t, prepared)
+	restoreDir := func() {}
+	if prepared.ReadOnly {
+		restore, err := makeReadOnly(prepared.Dir, prepared.FakeHome)
+		if err != nil {
+			t.Fatalf("Failed to make the scheme directory read-only: %s", err)
+		}
+		var restored bool
+		restoreDir = func() {
+			if restored {
+				return
+			}
+			restored = true
+			if err := restore(); err != nil {
+				t.Errorf("Failed to restore the permissions of the scheme directory: %s", err)
+			}
+		}
+		// the permissions are restored for the removal of the dir on fatal failures.
+		defer restoreDir()
+	}
+	start := time.Now()
+	var failures []string
+	var err error
+	switch {
+	case prepared.PTY != nil:
+		failures, err = runInPTY(cmd, *prepared.PTY, stdout, feed, watchers)
+		if errors.Is(err, errPTYUnsupported) {
+			t.Skipf("Failed to run in a PTY: %s", err)
+		}
+		var startErr *startError
+		if err != nil && !errors.As(err, &startErr) {
+			t.Fatalf("Failed to run in a PTY: %s", err)
+		}
+	case prepared.Interaction != nil:
+		failures, err = runWithFeeder(cmd, stdout, feed, watchers)
+	default:
+		failures, err = runWithWatchers(cmd, stdout, watchers)
+	}
+	duration := time.Since(start)
+	restoreDir()
+
+	returnCode, killedBy := termination(cmd.ProcessState, group.lastSignal())
+	result := executionResult{
+		Stdout:      stdout.String(),
+		Stderr:      stderr.String(),
+		StdoutSpool: stdout.spoolName(),
+		StderrSpool: stderr.spoolName(),
+		ReturnCode:  returnCode,
+		KilledBy:    killedBy,
+		Duration:    duration,
+		MaxRSS:      maxRSS(cmd.ProcessState),
+		Failures:    failures,
+	}
+	if err != nil && prepared.Offline && networkIsolationFailed(err) {
+		t.Skipf("Failed to isolate network: %s", err)
+	}
+	if err != nil {
+		result.StartError = err.Error()
+	}
+	if prepared.Trace {
+		traceRun(t, cmd, prepared, result)
+	}
+	if err := finish(); err != nil {
+		t.Fatalf("Failed to finish the run: %s", err)
+	}
+	inspectDir(prepared, before, &result)
+	for _, hook := range prepared.AfterRun {
+		hook(t, Run{Dir: prepared.Dir, ReturnCode: result.ReturnCode, expand: prepared.Expand})
+	}
+	if prepared.CombinedOutput {
+		result.Output, result.OutputSpool = result.Stdout, result.StdoutSpool
+		result.Stdout, result.Stderr = "", ""
+		result.StdoutSpool, result.StderrSpool = "", ""
+	}
+	return result
+}
+
+// newCommand builds the cmd running the binary in the prepared conditions with
+// the output captured to the returned buffers. The finish function is called
+// once the cmd exits, the spools are removed by the cleanup.
+func newCommand(t testing.TB, binary string, prepared schemeResult, opts []func(*exec.Cmd), cleanup func(func())) (*exec.Cmd, *outputBuffer, *outputBuffer, func() error) {
+	t.Helper()
+	if prepared.Shell != "" {
+		binary = shellCommand[0]
+	}
+	cmd, finish := exec.Command(binary), func() error { return nil }
+	if prepared.Runner != nil {
+		invocation := Invocation{Binary: binary, Args: prepared.Args, Env: prepared.Env, Dir: prepared.Dir}
+		var err error
+		if cmd, finish, err = prepared.Runner.Command(t, invocation); err != nil {
+			t.Fatalf("Failed to prepare the runner: %s", err)
+		}
+	} else {
+		cmd.Dir = prepared.Dir
+		cmd.Args = append(cmd.Args, prepared.Args...)
+		if prepared.Shell != "" {
+			configureShell(cmd, prepared.Shell)
+		}
+	}
+	if len(prepared.Limits) > 0 {
+		if prepared.Runner != nil {
+			t.Fatalf("Failed to limit resources: --limit can't be used with a runner")
+		}
+		if err := limitCommand(cmd, prepared.Limits); errors.Is(err, errLimitsUnsupported) {
+			t.Skipf("Failed to limit resources: %s", err)
+		} else if err != nil {
+			t.Fatalf("Failed to limit resources: %s", err)
+		}
+	}
+	stdout, stderr := newOutputBuffer(), newOutputBuffer()
+	if prepared.SpoolDir != "" {
+		stdout = newSpool(t, prepared.SpoolDir, "stdout", cleanup)
+		stderr = newSpool(t, prepared.SpoolDir, "stderr", cleanup)
+	}
+	if prepared.CombinedOutput {
+		// the same writer keeps the order of writes to both streams.
+		stderr = stdout
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if prepared.StripANSI {
+		cmd.Stdout = &ansiStripper{w: stdout}
+		cmd.Stderr = &ansiStripper{w: stderr}
+		if prepared.CombinedOutput {
+			cmd.Stderr = cmd.Stdout
+		}
+	}
+	if prepared.Offline {
+		if err := isolateNetwork(cmd); err != nil {
+			t.Skipf("Failed to isolate network: %s", err)
+		}
+	}
+	cmd.Stdin = strings.NewReader(prepared.Stdin)
 	for _, opt := range opts {
 		opt(cmd)
 	}
-	if len(env) > 0 {
+	if prepared.Runner != nil {
+		return cmd, stdout, stderr, finish
+	}
+	env := append([]string(nil), prepared.Env...)
+	if selfEnv, ok := selfEnvFor(binary); ok {
+		env = append(env, selfEnv)
+	}
+	if prepared.CleanEnv {
+		cmd.Env = append(inheritedEnv(cmd.Environ(), prepared.InheritEnv), env...)
+	} else if len(env) > 0 {
 		cmd.Env = append(cmd.Environ(), env...)
 	}
+	return cmd, stdout, stderr, finish
+}
 
-	// this is intentional, we will assert exit code manually
-	_ = cmd.Run()
+// runWithFeeder runs the cmd with the stdin written by the feed and the
+// watchers running along, the process is killed if the feed fails.
+func runWithFeeder(cmd *exec.Cmd, stdout *outputBuffer, feed feeder, watchers []watcher) ([]string, error) {
+	cmd.Stdin = nil
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return []string{fmt.Sprintf("Failed to open stdin: %s", err)}, nil
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, &startError{err}
+	}
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		_ = cmd.Wait()
+	}()
+	waitWatchers := startWatchers(watchers, cmd.Process, stdout, exited)
+
+	failures := feed(stdin, stdout, exited)
+	if len(failures) > 0 {
+		_ = cmd.Process.Kill()
+	}
+	_ = stdin.Close()
+	<-exited
+	return append(failures, waitWatchers()...), nil
+}
 
-	return executionResult{
-		Stdout:     stdoutBuilder.String(),
-		Stderr:     stderrBuilder.String(),
-		ReturnCode: cmd.ProcessState.ExitCode(),
+// runWithWatchers runs the cmd with the watchers running along.
+func runWithWatchers(cmd *exec.Cmd, stdout *outputBuffer, watchers []watcher) ([]string, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, &startError{err}
 	}
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		// this is intentional, we will assert exit code manually
+		_ = cmd.Wait()
+	}()
+	waitWatchers := startWatchers(watchers, cmd.Process, stdout, exited)
+	<-exited
+	return waitWatchers(), nil
 }
 
 type schemeResult struct {
 	Stdout     string
 	Stderr     string
-	Stdin      string
-	ReturnCode int
-	Args       []string
-	Env        []string
-	Dir        string
+	Output     string
+	StdoutDiff DiffOption
+	StderrDiff DiffOption
+	OutputDiff DiffOption
+	CmpOptions []cmp.Option
+	DiffFormat DiffFormat
+	// StdoutExcludes and StderrExcludes are checked against the Output if
+	// it's combined.
+	StdoutExcludes []string
+	StderrExcludes []string
+	Stdin          string
+	ReturnCode     int
+	ReturnCodes    []int
+	KilledBy       string
+	MaxDuration    time.Duration
+	MaxRSS         uint64
+	StdoutLines    *LineCount
+	StderrLines    *LineCount
+	StdoutEmpty    bool
+	StderrEmpty    bool
+	StdoutAny      bool
+	StderrAny      bool
+	FinalNewline   string
+	Timeout        time.Duration
+	IdleTimeout    time.Duration
+	PTY            *TerminalSize
+	Interaction    []InteractionStep
+	Signals        []Signal
+	SpoolDir       string
+	Args           []string
+	Env            []string
+	Dir            string
+	// CleanEnv passes only the InheritEnv variables of the test process.
+	CleanEnv   bool
+	InheritEnv []string
+	// CombinedOutput asserts the Output instead of the Stdout and Stderr.
+	CombinedOutput bool
+	// ProcessGroup runs the process in its own group, see [WithProcessGroup].
+	ProcessGroup bool
+	// StripANSI removes the ANSI escape sequences from the output.
+	StripANSI bool
+	// ExpectTree compares the Tree with the scheme directory.
+	ExpectTree bool
+	Tree       []TreeEntry
+	// ExpectChanges compares the Changes with the ones of the Dir.
+	ExpectChanges bool
+	Changes       []Change
+	// GoldenDir the files of the Dir are compared with.
+	GoldenDir string
+	// Stats expected of the files of the Dir.
+	Stats []FileStat
+	// Symlinks expected in the Dir.
+	Symlinks []Symlink
+	// StopSignal is the signal of the `--stop-on:` accepted as the termination.
+	StopSignal string
+	// SHA256 checksums expected of the files of the Dir.
+	SHA256 []FileChecksum
+	// StartError is the pattern of the expected failure to start the binary.
+	StartError string
+	// Offline runs the binary without network access.
+	Offline bool
+	// Runner runs the binary instead of the local exec if set.
+	Runner Runner
+	// ReadOnly removes the write permissions of the Dir during the run.
+	ReadOnly bool
+	// FakeHome is the directory of the [WithFakeHome] in the Dir, it is
+	// left out of the tree, the golden directory and the read-only Dir.
+	FakeHome string
+	// Limits are applied to the binary before the exec.
+	Limits []Limit
+	// Shell is the script run with the [Shell] instead of the binary, the
+	// Args are the ones of the shell then.
+	Shell string
+	// FIFOs are the named pipes of the Dir served during the run.
+	FIFOs []fifoHandler
+	// AfterRun hooks are called once the binary exits.
+	AfterRun []func(t testing.TB, run Run)
+	// Trace logs the execution.
+	Trace bool
+	// Expand evaluates the placeholders of the scheme for the hooks.
+	Expand func(string) string
+}
+
+// dirSnapshot is the state of the scheme directory before the execution.
+type dirSnapshot struct {
+	// files are recorded if the changes are expected.
+	files map[string]fileState
+	// modTimes of the files expected to be fresh.
+	modTimes map[string]time.Time
 }
 
-func prepareScheme(t *testing.T, scheme string) schemeResult {
+// snapshotBefore takes the snapshot of the scheme directory before the
+// execution as much as the expectations need.
+func snapshotBefore(t testing.TB, prepared schemeResult) dirSnapshot {
 	t.Helper()
+	snapshot := dirSnapshot{modTimes: modTimes(prepared.Dir, prepared.Stats)}
+	if prepared.ExpectChanges {
+		var err error
+		if snapshot.files, err = snapshotDir(prepared.Dir); err != nil {
+			t.Fatalf("Failed to snapshot the scheme directory: %s", err)
+		}
+	}
+	return snapshot
+}
 
+// inspectDir walks the tree, hashes the files, checks their metadata and
+// lists the changes since the snapshot of the scheme directory if they are
+// expected.
+func inspectDir(prepared schemeResult, before dirSnapshot, result *executionResult) {
+	if prepared.ExpectChanges {
+		after, err := snapshotDir(prepared.Dir)
+		if err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("Failed to snapshot the scheme directory: %s", err))
+		}
+		result.Changes = diffSnapshots(before.files, after)
+	}
+	result.Stats = checkStats(prepared.Dir, prepared.Stats, before.modTimes)
+	result.Symlinks = checkSymlinks(prepared.Dir, prepared.Symlinks)
+	if prepared.GoldenDir != "" {
+		inspectGoldenDir(prepared, result)
+	}
+	if prepared.ExpectTree {
+		var err error
+		if result.Tree, err = walkTree(prepared.Dir, prepared.FakeHome); err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("Failed to walk the scheme directory: %s", err))
+		}
+	}
+	if len(prepared.SHA256) > 0 {
+		var failures []string
+		result.SHA256, failures = hashFiles(prepared.Dir, prepared.SHA256)
+		result.Failures = append(result.Failures, failures...)
+	}
+}
+
+func prepareScheme(t testing.TB, scheme string, cfg *config) schemeResult {
+	t.Helper()
+	logSchemeOnFailure(t, scheme)
+	return prepare(t, parseScheme(t, scheme, cfg), cfg)
+}
+
+// logSchemeOnFailure logs the scheme if the test fails, the scheme texts are
+// rendered with the line numbers.
+func logSchemeOnFailure(t testing.TB, scheme any) {
+	if text, ok := scheme.(string); ok {
+		scheme = "\n" + numberLines(text)
+	}
 	t.Cleanup(func() {
 		if t.Failed() {
-			t.Logf("Test scheme: %s\n", scheme)
+			t.Logf("Test scheme: %+v\n", scheme)
 		}
 	})
+}
 
-	var stdout strings.Builder
-	var stderr strings.Builder
-	var stdin strings.Builder
-	var returnCode int
-	var args []string
-	var env []string
-	files := make(map[string]string)
-	dir := t.TempDir()
-
-	// TODO: Make test fail if the same field defined twice.
-	// TODO: Replace with enum. FSM is always good for readability.
-	var isStdout bool
-	var isStderr bool
-	var isStdin bool
-	var isFile bool
-
-	var currentFileName string
-	var currentFile strings.Builder
-
-	saveFile := func(name string) {
-		if isFile {
-			resultPath := filepath.Join(dir, currentFileName)
-			files[resultPath] = currentFile.String()
-		}
-		currentFileName = name
-		currentFile.Reset()
-	}
-
-	for _, line := range toLines(scheme) {
-		if strings.HasPrefix(line, stderrPrefix) {
-			saveFile("")
-			isFile = false
-			isStderr = true
-			isStdin = false
-			isStdout = false
-			continue
-		}
-		if strings.HasPrefix(line, stdoutPrefix) {
-			saveFile("")
-			isFile = false
-			isStderr = false
-			isStdin = false
-			isStdout = true
-			continue
-		}
-		if fileName, ok := strings.CutPrefix(line, filePrefix); ok {
-			saveFile(strings.TrimSpace(fileName))
-			isFile = true
-			isStderr = false
-			isStdin = false
-			isStdout = false
-			continue
-		}
-		if strings.HasPrefix(line, stdinPrefix) {
-			saveFile("")
-			isFile = false
-			isStderr = false
-			isStdin = true
-			isStdout = false
-			continue
-		}
-
-		if rtCodeText, ok := strings.CutPrefix(line, returnCodePrefix); ok {
-			rtCodeText = strings.TrimSpace(rtCodeText)
-			var err error
-			returnCode, err = strconv.Atoi(rtCodeText)
-			if err != nil {
-				t.Fatalf("Failed to convert return code %q to int: %s", rtCodeText, err)
-			}
-			continue
-		}
-		if arg, ok := strings.CutPrefix(line, argPrefix); ok {
-			arg = strings.TrimSpace(arg)
-			arg = evaluateVariables(arg, dir)
-			args = append(args, arg)
-			continue
-		}
-		if kv, ok := strings.CutPrefix(line, envPrefix); ok {
-			kv = strings.TrimSpace(kv)
-			kv = evaluateVariables(kv, dir)
-			if !strings.Contains(kv, "=") {
-				t.Fatalf("Malformed --env entry %q, expected KEY=VALUE", kv)
-			}
-			env = append(env, kv)
-			continue
-		}
+func parseScheme(t testing.TB, scheme string, cfg *config) *Scheme {
+	t.Helper()
+	parsed, err := parseSchemeWith(scheme, cfg)
+	if err != nil {
+		t.Fatalf("Failed to parse scheme: %s", err)
+	}
+	return parsed
+}
 
-		if isStderr {
-			line = evaluateVariables(line, dir)
-			stderr.WriteString(line)
-			continue
-		}
-		if isStdout {
-			line = evaluateVariables(line, dir)
-			stdout.WriteString(line)
-			continue
-		}
-		if isFile {
-			line = evaluateVariables(line, dir)
-			currentFile.WriteString(line)
-			continue
-		}
-		if isStdin {
-			stdin.WriteString(line)
-			continue
+// parseSchemeWith parses the scheme honoring [WithStrictScheme].
+func parseSchemeWith(scheme string, cfg *config) (*Scheme, error) {
+	if cfg.strictScheme {
+		if err := checkUnknownDirectives(scheme); err != nil {
+			return nil, err
 		}
 	}
-	if isFile {
-		saveFile("")
+	return ParseScheme(scheme)
+}
+
+// prepare creates the scheme directory with files and expands placeholders.
+func prepare(t testing.TB, scheme *Scheme, cfg *config) schemeResult {
+	t.Helper()
+	dir := schemeDir(t, cfg)
+	result := prepareIn(t, dir, resolveVariables(t, dir, cfg), scheme, cfg)
+	result.Env = append(configEnv(t, dir, cfg), result.Env...)
+	return result
+}
+
+// configEnv returns the environment of the options for the scheme dir, it
+// goes before the one of the scheme.
+func configEnv(t testing.TB, dir string, cfg *config) []string {
+	t.Helper()
+	var env []string
+	if cfg.hermeticEnv {
+		env = append(env, hermeticEnv(t)...)
+	}
+	if cfg.fakeHome {
+		env = append(env, fakeHomeEnv(t, dir)...)
 	}
+	if cfg.hasPath {
+		env = append(env, "PATH="+binaryPath(t, cfg))
+	}
+	if cfg.cassette != nil {
+		env = append(env, cassetteEnv(t, cfg.cassette, binaryPath(t, cfg)))
+	}
+	return env
+}
 
-	for path, content := range files {
+// prepareIn creates the scheme files in the dir and expands placeholders.
+func prepareIn(t testing.TB, dir string, vars *variables, scheme *Scheme, cfg *config) schemeResult {
+	t.Helper()
+
+	for _, file := range scheme.Files {
+		if !filepath.IsLocal(file.Path) {
+			t.Fatalf("File path %q must be local to the scheme directory", file.Path)
+		}
+		path := filepath.Join(dir, file.Path)
 		fileDir := filepath.Dir(path)
 		if err := os.MkdirAll(fileDir, 0o755); err != nil {
 			t.Fatalf("Failed to create directory (%q) for test file: %s", fileDir, err)
 		}
-		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		if err := os.WriteFile(path, []byte(evaluateVariables(file.Content, vars)), 0o644); err != nil {
 			t.Fatalf("Failed to write file (%v): %s", path, err)
 		}
 	}
 
+	fifos, err := prepareFIFOs(dir, scheme.FIFOs, cfg.fifos, vars)
+	if errors.Is(err, errFIFOUnsupported) {
+		t.Skipf("Failed to create named pipes: %s", err)
+	}
+	if err != nil {
+		t.Fatalf("Failed to create named pipes: %s", err)
+	}
+
+	stdin := scheme.Stdin
+	if scheme.StdinGenerator != nil {
+		stdin = scheme.StdinGenerator.generate()
+	}
+
+	args := make([]string, 0, len(scheme.Args))
+	for _, arg := range scheme.Args {
+		args = append(args, evaluateVariables(arg, vars))
+	}
+	shell := evaluateVariables(scheme.Shell, vars)
+	if shell != "" {
+		args = append(Shell()[1:], shell)
+	}
+	var env []string
+	if len(scheme.Stubs) > 0 {
+		stubDir := t.TempDir()
+		if err := writeStubs(stubDir, scheme.Stubs, vars); err != nil {
+			t.Fatalf("Failed to write stubs: %s", err)
+		}
+		env = append(env, prependPath(stubDir, binaryPath(t, cfg)))
+	}
+	for _, envFile := range scheme.EnvFiles {
+		path := evaluateVariables(envFile, vars)
+		loaded, err := loadEnvFile(dir, path)
+		if err != nil {
+			t.Fatalf("Failed to load env file %s: %s", path, err)
+		}
+		env = append(env, evaluateAll(loaded, vars)...)
+	}
+	for _, kv := range scheme.Env {
+		env = append(env, evaluateVariables(kv, vars))
+	}
+	var interaction []InteractionStep
+	for _, step := range scheme.Interaction {
+		step.Expect = evaluateVariables(step.Expect, vars)
+		interaction = append(interaction, step)
+	}
+	var signals []Signal
+	for _, signal := range scheme.Signals {
+		signal.On = evaluateVariables(signal.On, vars)
+		signals = append(signals, signal)
+	}
+	var symlinks []Symlink
+	for _, link := range scheme.ExpectedSymlinks {
+		link.Target = evaluateVariables(link.Target, vars)
+		symlinks = append(symlinks, link)
+	}
+	var stopSignal string
+	if scheme.StopOn != nil {
+		stop := *scheme.StopOn
+		stop.On = evaluateVariables(stop.On, vars)
+		signals = append(signals, stop)
+		stopSignal = stop.Name
+	}
+	pty := scheme.PTY
+	if cfg.pty != nil {
+		pty = cfg.pty
+	}
+
 	return schemeResult{
-		Stdout:     stdout.String(),
-		Stderr:     stderr.String(),
-		Stdin:      stdin.String(),
-		ReturnCode: returnCode,
-		Args:       args,
-		Env:        env,
-		Dir:        dir,
+		Stdout:         evaluateVariables(scheme.ExpectedStdout, vars),
+		Stderr:         evaluateVariables(scheme.ExpectedStderr, vars),
+		Output:         evaluateVariables(scheme.ExpectedOutput, vars),
+		StdoutDiff:     scheme.StdoutDiff | cfg.diff,
+		StderrDiff:     scheme.StderrDiff | cfg.diff,
+		OutputDiff:     scheme.OutputDiff | cfg.diff,
+		CmpOptions:     cfg.cmpOptions,
+		DiffFormat:     cfg.diffFormat,
+		StdoutExcludes: evaluateAll(scheme.StdoutExcludes, vars),
+		StderrExcludes: evaluateAll(scheme.StderrExcludes, vars),
+		CombinedOutput: scheme.CombinedOutput,
+		ProcessGroup:   cfg.processGroup,
+		StripANSI:      scheme.StripANSI || cfg.stripANSI,
+		ExpectTree:     scheme.ExpectTree,
+		Tree:           scheme.ExpectedTree,
+		ExpectChanges:  scheme.ExpectChanges,
+		Changes:        scheme.ExpectedChanges,
+		GoldenDir:      scheme.GoldenDir,
+		Stats:          scheme.ExpectedStats,
+		Symlinks:       symlinks,
+		SHA256:         scheme.ExpectedSHA256,
+		StartError:     evaluateVariables(scheme.ExpectedStartError, vars),
+		Offline:        cfg.networkIsolation,
+		Runner:         cfg.runner,
+		ReadOnly:       scheme.ReadOnly,
+		FakeHome:       fakeHomeFor(dir, cfg),
+		Limits:         scheme.Limits,
+		Shell:          shell,
+		FIFOs:          fifos,
+		AfterRun:       cfg.afterRun,
+		Trace:          cfg.trace,
+		Expand:         func(data string) string { return evaluateVariables(data, vars) },
+		Stdin:          stdin,
+		ReturnCode:     scheme.ExpectedReturnCode,
+		ReturnCodes:    scheme.ExpectedReturnCodes,
+		KilledBy:       scheme.ExpectedKilledBy,
+		MaxDuration:    scheme.MaxDuration,
+		MaxRSS:         scheme.MaxRSS,
+		StdoutLines:    scheme.StdoutLines,
+		StderrLines:    scheme.StderrLines,
+		StdoutEmpty:    scheme.StdoutEmpty,
+		StderrEmpty:    scheme.StderrEmpty,
+		StdoutAny:      scheme.StdoutAny,
+		StderrAny:      scheme.StderrAny,
+		FinalNewline:   scheme.FinalNewline,
+		Timeout:        scheme.Timeout,
+		IdleTimeout:    scheme.IdleTimeout,
+		PTY:            pty,
+		Interaction:    interaction,
+		Signals:        signals,
+		StopSignal:     stopSignal,
+		SpoolDir:       cfg.spoolDir,
+		Args:           args,
+		Env:            env,
+		Dir:            dir,
+		CleanEnv:       scheme.CleanEnv,
+		InheritEnv:     scheme.InheritEnv,
 	}
 }
 
-func evaluateVariables(data string, dir string) string {
-	data = strings.ReplaceAll(data, "{dir}", dir)
-	return data
+// variables expands the placeholders of the scheme.
+type variables struct {
+	// oldnew are the pairs of the replacer.
+	oldnew   []string
+	replacer *strings.Replacer
+	ports    *ports
+	sockets  *sockets
+	randoms  *randoms
+}
+
+// resolveVariables builds the placeholders of the scheme. The `{dir}`
+// placeholder always refers to the scheme directory, the `{sep}` and `{exe}`
+// ones are the path separator and the executable suffix of the OS, the
+// `{port}`, `{socket}`, `{rand}` and `{uuid}` ones are allocated on the first
+// use.
+func resolveVariables(t testing.TB, dir string, cfg *config) *variables {
+	custom := cfg.variables
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	oldnew := []string{"{dir}", dir, "{sep}", string(filepath.Separator), "{exe}", exeSuffix}
+	for _, name := range names {
+		oldnew = append(oldnew, "{"+name+"}", custom[name](dir))
+	}
+	return &variables{
+		oldnew:   oldnew,
+		replacer: strings.NewReplacer(oldnew...),
+		ports:    newPorts(t),
+		sockets:  newSockets(t, dir),
+		randoms:  newRandoms(t, cfg),
+	}
+}
+
+func evaluateVariables(data string, vars *variables) string {
+	return vars.replacer.Replace(vars.randoms.replace(vars.sockets.replace(vars.ports.replace(data))))
+}
+
+func evaluateAll(data []string, vars *variables) []string {
+	var result []string
+	for _, d := range data {
+		result = append(result, evaluateVariables(d, vars))
+	}
+	return result
 }
 
 // toLines splits strings to lines compatible with [strings.Lines].
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/IlyasYOY/exectest"
@@ -127,6 +128,39 @@ Path: {dir}
 `)
 }
 
+func TestExecuteSeparatorAndExecutablePlaceholders(t *testing.T) {
+	exectest.Execute(t, "echo", `
+--arg:{dir}{sep}tool{exe}
+--stdout
+{dir}/tool
+`)
+}
+
+func TestExecuteBackslashFilePath(t *testing.T) {
+	exectest.Execute(t, "cat", `
+--file:sub\inner.txt
+content
+--arg:sub/inner.txt
+--stdout
+content
+--expect-stat: sub\inner.txt size=8
+`)
+}
+
+func TestExecuteCRLFOutput(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:printf 'line1\r\nline2\r\n'
+--stdout
+line1
+line2
+`)
+}
+
+func TestExecuteCRLFScheme(t *testing.T) {
+	exectest.Execute(t, "cat", "--file:a.txt\r\nline1\r\n--arg:a.txt\r\n--return-code: 0\r\n--stdout\r\nline1\r\n")
+}
+
 func TestExecuteEmptySchemeNoPrefixesBinaryThatSucceeds(t *testing.T) {
 	exectest.Execute(t, "true", ``)
 }
@@ -138,6 +172,25 @@ func TestExecuteUnknownPrefixIsIgnored(t *testing.T) {
 `)
 }
 
+func TestExecuteStrictSchemeRejectsUnknownDirective(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "false", `
+--retrun-code: 1
+`, exectest.WithStrictScheme())
+	})
+
+	assertFailed(t, fake, `Failed to parse scheme: line 2 "--retrun-code: 1": unknown directive`)
+}
+
+func TestExecuteStrictSchemeAllowsDashedContent(t *testing.T) {
+	exectest.Execute(t, "echo", `
+--arg:--
+--arg:-n
+--stdout
+-- -n
+`, exectest.WithStrictScheme())
+}
+
 func TestExecuteCmdOptionSetEnvironmentVariable(t *testing.T) {
 	exectest.Execute(t, "sh", `
 --arg:-c
@@ -149,6 +202,51 @@ value123
 	})
 }
 
+func TestExecuteCustomVariable(t *testing.T) {
+	exectest.Execute(t, "cat", `
+--file:fixture.txt
+sha: {gitsha}
+--arg:{fixture}
+--stdout
+sha: abc123
+`,
+		exectest.WithVariable("gitsha", func(string) string { return "abc123" }),
+		exectest.WithVariable("fixture", func(dir string) string {
+			return filepath.Join(dir, "fixture.txt")
+		}),
+	)
+}
+
+func TestExecuteCustomVariableInEnv(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--env:NAME={name}
+--arg:-c
+--arg:printf "%s\n" "$NAME"
+--stdout
+custom
+`, exectest.WithVariable("name", func(string) string { return "custom" }))
+}
+
+func TestExecuteCustomVariableWithCmdOption(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo {name} $TEST_VAR
+--stdout
+custom value123
+`, exectest.WithVariable("name", func(string) string { return "custom" }), func(c *exec.Cmd) {
+		c.Env = append(c.Env, "TEST_VAR=value123")
+	})
+}
+
+func TestExecuteUnsupportedOptionPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for unsupported option")
+		}
+	}()
+	exectest.Execute(t, "true", "", "verbose")
+}
+
 func TestExecuteParallelExecutions(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		i := i
@@ -196,3 +294,116 @@ func TestExecuteMultipleEnvVariables(t *testing.T) {
 1:2
 `)
 }
+
+func TestExecuteReportsFailuresToTB(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:echo out; echo err >&2; exit 1
+--stdout
+other
+`)
+	})
+
+	assertFailed(t, fake,
+		"Failed to match return code: want 0, got 1",
+		"Failed matching stdout",
+		"Failed matching stderr",
+	)
+}
+
+func TestExecuteFatalForMalformedScheme(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "true", `--return-code: zero`)
+	})
+
+	assertFailed(t, fake, "Failed to parse scheme")
+}
+
+func TestExecuteRetriesFlakyCommand(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	exectest.Execute(t, "sh", `
+--retries: 2 1ms
+--arg:-c
+--arg:n=$(cat {counter} 2>/dev/null || echo 0); echo $((n+1)) > {counter}; test $n -ge 2
+`, exectest.WithVariable("counter", func(string) string { return counter }))
+}
+
+func TestExecuteRetriesReportOnlyLastAttempt(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "false", `
+--retries:1
+`)
+	})
+
+	assertFailed(t, fake, "Failed to match return code: want 0, got 1")
+	if len(fake.errors) != 1 {
+		t.Errorf("Expected a single failure, got %q", fake.errors)
+	}
+}
+
+func TestExecuteRepeatInFreshDirectory(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--repeat: 3
+--arg:-c
+--arg:test ! -e marker && touch marker
+`)
+}
+
+func TestExecuteRepeatReportsFailedIteration(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--repeat: 5
+--arg:-c
+--arg:n=$(cat {counter} 2>/dev/null || echo 0); echo $((n+1)) > {counter}; test $n -lt 2
+`, exectest.WithVariable("counter", func(string) string { return counter }))
+	})
+
+	assertFailed(t, fake, "Iteration 3 of 5 failed", "Failed to match return code: want 0, got 1")
+	if content, _ := os.ReadFile(counter); string(content) != "3\n" {
+		t.Errorf("Expected the repeat to stop after the failed iteration, got %q runs", content)
+	}
+}
+
+func TestExecuteMaxDuration(t *testing.T) {
+	exectest.Execute(t, "true", `
+--max-duration: 10s
+`)
+}
+
+func TestExecuteMaxDurationExceeded(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sleep", `
+--arg:0.2
+--max-duration: 50ms
+`)
+	})
+
+	assertFailed(t, fake, "Failed to fit max duration: want at most 50ms, took")
+}
+
+func TestExecuteCombinedOutput(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo one; echo two >&2; echo three
+--output
+one
+two
+three
+`)
+}
+
+func TestExecuteCombinedOutputMismatch(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:echo one >&2; echo two
+--output
+two
+one
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching output (-missing line, +extra line)")
+}
@@ -0,0 +1,45 @@
+//go:build windows
+
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestWindowsShell(t *testing.T) {
+	exectest.Execute(t, "cmd", `
+--file:sub\a.txt
+content
+--shell: type sub\a.txt && exit /b 3
+--stdout
+content
+--return-code: 3
+`)
+}
+
+func TestWindowsExecutablePlaceholder(t *testing.T) {
+	exectest.Execute(t, "cmd", `
+--shell: echo {dir}{sep}tool{exe}
+--stdout
+{dir}\tool.exe
+`)
+}
+
+func TestWindowsKilledBy(t *testing.T) {
+	exectest.Execute(t, "cmd", `
+--shell: ping -n 30 127.0.0.1 >NUL
+--signal: SIGKILL after 200ms
+--killed-by: SIGKILL
+`)
+}
+
+func TestWindowsStopOn(t *testing.T) {
+	exectest.Execute(t, "cmd", `
+--shell: echo ready&& ping -n 30 127.0.0.1 >NUL
+--stop-on: ready
+--stdout
+ready
+`)
+}
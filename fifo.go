@@ -0,0 +1,204 @@
+package exectest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errFIFOUnsupported is returned by makeFIFO on platforms without named
+// pipes.
+var errFIFOUnsupported = errors.New("named pipes are not supported on this platform")
+
+// FIFO is the `--fifo:<path>` block, the named pipe created in the scheme
+// directory. The content of the block, if any, is written to the pipe once
+// the binary opens it for reading. The named pipes are for Unix only.
+//
+//	--fifo: events.pipe
+//	started
+//	stopped
+type FIFO struct {
+	// Path relative to the scheme directory.
+	Path string
+	// Content written to the pipe.
+	Content string
+}
+
+// WithFIFOFeed writes to the `--fifo:` named pipe of the path with the feed
+// once the binary opens it for reading, instead of the content of the block.
+// The pipe is closed once the feed returns, so the binary reads to the end.
+//
+// Example:
+//
+//	exectest.WithFIFOFeed("events.pipe", func(w io.Writer) error {
+//		_, err := io.WriteString(w, "started\n")
+//		return err
+//	})
+func WithFIFOFeed(path string, feed func(w io.Writer) error) Option {
+	return func(c *config) {
+		c.fifos = append(c.fifos, fifoHandler{path: slashPath(path), feed: feed})
+	}
+}
+
+// WithFIFODrain reads the `--fifo:` named pipe of the path with the drain
+// once the binary opens it for writing. The drain reads to the end once the
+// binary closes the pipe.
+//
+// Example:
+//
+//	var events bytes.Buffer
+//	exectest.WithFIFODrain("events.pipe", func(r io.Reader) error {
+//		_, err := io.Copy(&events, r)
+//		return err
+//	})
+func WithFIFODrain(path string, drain func(r io.Reader) error) Option {
+	return func(c *config) {
+		c.fifos = append(c.fifos, fifoHandler{path: slashPath(path), drain: drain})
+	}
+}
+
+// fifoHandler serves the named pipe, either feed or drain is set.
+type fifoHandler struct {
+	path  string
+	feed  func(w io.Writer) error
+	drain func(r io.Reader) error
+}
+
+// prepareFIFOs creates the named pipes of the scheme in the dir and returns
+// their handlers, the content of the blocks is fed unless the options serve
+// the pipes.
+func prepareFIFOs(dir string, fifos []FIFO, handlers []fifoHandler, vars *variables) ([]fifoHandler, error) {
+	served := make(map[string]fifoHandler, len(handlers))
+	for _, handler := range handlers {
+		served[filepath.Clean(handler.path)] = handler
+	}
+	var result []fifoHandler
+	for _, fifo := range fifos {
+		path := filepath.Join(dir, fifo.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := makeFIFO(path); err != nil {
+			return nil, err
+		}
+		handler, ok := served[filepath.Clean(fifo.Path)]
+		if !ok {
+			if fifo.Content == "" {
+				continue
+			}
+			content := evaluateVariables(fifo.Content, vars)
+			handler.feed = func(w io.Writer) error {
+				_, err := io.WriteString(w, content)
+				return err
+			}
+		}
+		handler.path = path
+		result = append(result, handler)
+	}
+	return result, nil
+}
+
+// checkFIFOHandlers returns the error if the handlers serve the named pipes
+// not defined in the scheme or its steps.
+func checkFIFOHandlers(scheme *Scheme, handlers []fifoHandler) error {
+	defined := make(map[string]bool)
+	for _, s := range append([]*Scheme{scheme}, stepSchemes(scheme)...) {
+		for _, fifo := range s.FIFOs {
+			defined[filepath.Clean(fifo.Path)] = true
+		}
+	}
+	for _, handler := range handlers {
+		if !defined[filepath.Clean(handler.path)] {
+			return fmt.Errorf("named pipe %s is not defined with --fifo", handler.path)
+		}
+	}
+	return nil
+}
+
+// stepSchemes returns the schemes of the steps.
+func stepSchemes(scheme *Scheme) []*Scheme {
+	schemes := make([]*Scheme, 0, len(scheme.Steps))
+	for _, step := range scheme.Steps {
+		schemes = append(schemes, step.Scheme)
+	}
+	return schemes
+}
+
+// serveFIFO is the watcher feeding or draining the named pipe. The pipe is
+// opened from the other side once the process exits, so the handler blocked
+// in the open returns.
+func serveFIFO(handler fifoHandler) watcher {
+	return func(_ *os.Process, _ *outputBuffer, exited <-chan struct{}) []string {
+		flag := os.O_RDONLY
+		if handler.feed != nil {
+			flag = os.O_WRONLY
+		}
+		opened, done := make(chan struct{}), make(chan error, 1)
+		go func() {
+			f, err := os.OpenFile(handler.path, flag, 0)
+			close(opened)
+			if err != nil {
+				done <- err
+				return
+			}
+			defer f.Close()
+			if handler.feed != nil {
+				done <- handler.feed(f)
+			} else {
+				done <- handler.drain(f)
+			}
+		}()
+
+		var err error
+		select {
+		case err = <-done:
+		case <-exited:
+			select {
+			case err = <-done:
+			default:
+				err = unblockFIFO(handler.path, flag, opened, done)
+			}
+		}
+		if err != nil {
+			name := filepath.Base(handler.path)
+			if handler.feed != nil {
+				return []string{fmt.Sprintf("Failed to feed named pipe %s: %s", name, err)}
+			}
+			return []string{fmt.Sprintf("Failed to drain named pipe %s: %s", name, err)}
+		}
+		return nil
+	}
+}
+
+// unblockFIFO opens the pipe for reading and writing, so the open of the
+// handler returns, and waits for the handler to finish. The fed data is
+// discarded, the drain reads to the end once the pipe is closed. The pipe is
+// kept open until the open of the handler returns, otherwise the handler not
+// scheduled yet would block in the open without the other side.
+func unblockFIFO(path string, flag int, opened <-chan struct{}, done <-chan error) error {
+	other, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	if flag == os.O_WRONLY {
+		go func() { _, _ = io.Copy(io.Discard, other) }()
+	} else {
+		<-opened
+		_ = other.Close()
+	}
+	err = <-done
+	_ = other.Close()
+	return err
+}
+
+// parseFIFOPath parses the path of the `--fifo:` block.
+func parseFIFOPath(text string) (string, error) {
+	path := slashPath(text)
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("file path %q must be local to the scheme directory", strings.TrimSpace(text))
+	}
+	return path, nil
+}
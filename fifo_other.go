@@ -0,0 +1,7 @@
+//go:build !unix
+
+package exectest
+
+func makeFIFO(string) error {
+	return errFIFOUnsupported
+}
@@ -0,0 +1,95 @@
+package exectest_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSchemeFIFOs(t *testing.T) {
+	scheme, err := exectest.ParseScheme(`--fifo: events.pipe
+started
+--fifo: sub\out.pipe
+--stdout
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+
+	want := []exectest.FIFO{
+		{Path: "events.pipe", Content: "started\n"},
+		{Path: "sub/out.pipe"},
+	}
+	if diff := cmp.Diff(want, scheme.FIFOs); diff != "" {
+		t.Errorf("Unexpected named pipes (-want, +got):\n%s", diff)
+	}
+}
+
+func TestExecuteFIFOContent(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--fifo: events.pipe
+started
+stopped
+--arg:-c
+--arg:test -p events.pipe && cat events.pipe
+--stdout
+started
+stopped
+`)
+}
+
+func TestExecuteFIFOFeedAndDrain(t *testing.T) {
+	var drained bytes.Buffer
+	exectest.Execute(t, "sh", `
+--fifo: in.pipe
+--fifo: out.pipe
+--arg:-c
+--arg:tr a-z A-Z < in.pipe > out.pipe
+`,
+		exectest.WithFIFOFeed("in.pipe", func(w io.Writer) error {
+			_, err := io.WriteString(w, "hello\n")
+			return err
+		}),
+		exectest.WithFIFODrain("out.pipe", func(r io.Reader) error {
+			_, err := io.Copy(&drained, r)
+			return err
+		}),
+	)
+
+	if got := drained.String(); got != "HELLO\n" {
+		t.Errorf("Expected the drained %q, got %q", "HELLO\n", got)
+	}
+}
+
+func TestExecuteFIFONotOpened(t *testing.T) {
+	exectest.Execute(t, "true", `
+--fifo: events.pipe
+started
+--fifo: out.pipe
+`, exectest.WithFIFODrain("out.pipe", func(r io.Reader) error {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	}))
+}
+
+func TestExecuteFIFONotOpenedRepeated(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		exectest.Execute(t, "true", `
+--fifo: out.pipe
+`, exectest.WithFIFODrain("out.pipe", func(r io.Reader) error {
+			_, err := io.Copy(io.Discard, r)
+			return err
+		}))
+	}
+}
+
+func TestExecuteFIFOUndefined(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "true", "", exectest.WithFIFODrain("out.pipe", func(io.Reader) error { return nil }))
+	})
+
+	assertFailed(t, fake, "Failed to serve named pipes: named pipe out.pipe is not defined with --fifo")
+}
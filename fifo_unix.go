@@ -0,0 +1,9 @@
+//go:build unix
+
+package exectest
+
+import "syscall"
+
+func makeFIFO(path string) error {
+	return syscall.Mkfifo(path, 0o644)
+}
@@ -0,0 +1,66 @@
+package exectest
+
+import (
+	"strings"
+	"testing"
+)
+
+// Fuzz runs the binary against schemes produced by the fuzzing engine.
+//
+// The seedSchemes are added to the seed corpus. Every input (seed or
+// generated) is turned into a scheme by the mutate function, nil mutate uses
+// the input as a scheme as is. This way mutate might put the input into a
+// template, e.g. as a stdin or an argument.
+//
+// The expected outputs and return code of the scheme are not asserted, only
+// the invariants are:
+//
+//   - the binary is not terminated by a signal;
+//   - the return code is less than 128;
+//   - the stderr contains no Go panic traces.
+//
+// Inputs that are not valid schemes are skipped instead of failing.
+//
+// Example:
+//
+//	func FuzzTool(f *testing.F) {
+//		exectest.Fuzz(f, "./tool", []string{"hello"}, func(data []byte) string {
+//			return "--stdin\n" + string(data)
+//		})
+//	}
+func Fuzz(f *testing.F, binary string, seedSchemes []string, mutate func([]byte) string, opts ...Option) {
+	f.Helper()
+	cfg := newConfig(opts)
+	for _, seed := range seedSchemes {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		scheme := string(data)
+		if mutate != nil {
+			scheme = mutate(data)
+		}
+		parsed, err := parseSchemeWith(scheme, cfg)
+		if err != nil {
+			t.Skipf("Invalid scheme: %s", err)
+		}
+		logSchemeOnFailure(t, scheme)
+		_, executionResult := run(t, binary, parsed, cfg)
+		assertInvariants(t, executionResult)
+	})
+}
+
+func assertInvariants(t testing.TB, got executionResult) {
+	t.Helper()
+	if got.ReturnCode < 0 {
+		t.Errorf("Binary was terminated by a signal")
+	} else if got.ReturnCode >= 128 {
+		t.Errorf("Binary crashed with return code %d", got.ReturnCode)
+	}
+	if isPanicTrace(got.Stderr) {
+		t.Errorf("Binary panicked:\n%s", got.Stderr)
+	}
+}
+
+func isPanicTrace(stderr string) bool {
+	return strings.Contains(stderr, "panic: ") && strings.Contains(stderr, "goroutine ")
+}
@@ -0,0 +1,27 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func FuzzCatStdin(f *testing.F) {
+	exectest.Fuzz(f, "cat", []string{"hello", "multiple\nlines"}, func(data []byte) string {
+		return "--stdin\n" + string(data)
+	})
+}
+
+func FuzzTrueScheme(f *testing.F) {
+	exectest.Fuzz(f, "true", []string{
+		"--arg:-a\n",
+		"--env:A=B\n--stdin\ninput\n",
+	}, nil)
+}
+
+func FuzzInvalidSchemeSkipped(f *testing.F) {
+	exectest.Fuzz(f, "true", []string{
+		"--return-code: x\n",
+		"--unknown-directive\n",
+	}, nil, exectest.WithStrictScheme())
+}
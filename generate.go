@@ -0,0 +1,71 @@
+package exectest
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateTests returns the source of the Go test file of the package with a
+// test function per scheme file of the dir, so the names of the tests in the
+// `go test -run` output follow the names of the scheme files: the
+// `help_flag.scheme` becomes the `TestHelpFlag` executing the file with
+// [ExecuteForFile]. The files are skipped the same way as by [ExecuteDir].
+//
+// The binary is the Go expression of the binary path evaluated in every test
+// function, e.g. `exectest.BuildGoBinary(t, ".")`. The dir is relative to the
+// package directory, the `exectest generate` command emits the file with the
+// `go:generate` directive:
+//
+//	//go:generate go run github.com/IlyasYOY/exectest/cmd/exectest generate -o schemes_test.go testdata
+func GenerateTests(pkg, binary, dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read scheme directory %s: %w", dir, err)
+	}
+
+	var source strings.Builder
+	source.WriteString("// Code generated by exectest generate; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&source, "package %s\n\n", pkg)
+	source.WriteString("import (\n\t\"testing\"\n\n\t\"github.com/IlyasYOY/exectest\"\n)\n")
+
+	// TestMain is reserved by the testing package for the test binary setup.
+	names := map[string]bool{"TestMain": true}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		name := testName(entry.Name())
+		for i := 2; names[name]; i++ {
+			name = testName(entry.Name()) + strconv.Itoa(i)
+		}
+		names[name] = true
+		file := path.Join(filepath.ToSlash(dir), entry.Name())
+		fmt.Fprintf(&source, "\nfunc %s(t *testing.T) {\n\texectest.ExecuteForFile(t, %s, %q)\n}\n", name, binary, file)
+	}
+
+	formatted, err := format.Source([]byte(source.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format tests: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// testName returns the name of the test function of the scheme file: the
+// words of the name without the extension in the camel case.
+func testName(file string) string {
+	words := strings.FieldsFunc(strings.TrimSuffix(file, filepath.Ext(file)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	name := "Test"
+	for _, word := range words {
+		runes := []rune(word)
+		name += string(unicode.ToUpper(runes[0])) + string(runes[1:])
+	}
+	return name
+}
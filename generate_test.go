@@ -0,0 +1,52 @@
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestGenerateTests(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"help_flag.scheme", "help-flag.txtar", "main.scheme", ".hidden.scheme"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("Failed to write scheme: %s", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %s", err)
+	}
+
+	source, err := exectest.GenerateTests("mytool", `exectest.BuildGoBinary(t, ".")`, dir)
+	if err != nil {
+		t.Fatalf("Failed to generate tests: %s", err)
+	}
+
+	for _, part := range []string{
+		"// Code generated by exectest generate; DO NOT EDIT.",
+		"package mytool",
+		"func TestHelpFlag(t *testing.T) {",
+		"func TestHelpFlag2(t *testing.T) {",
+		"func TestMain2(t *testing.T) {",
+		`exectest.ExecuteForFile(t, exectest.BuildGoBinary(t, "."), "` + filepath.ToSlash(dir) + `/help_flag.scheme")`,
+	} {
+		if !strings.Contains(source, part) {
+			t.Errorf("Expected source containing %q, got:\n%s", part, source)
+		}
+	}
+	for _, part := range []string{"hidden", "sub", "func TestMain("} {
+		if strings.Contains(source, part) {
+			t.Errorf("Expected source without %q, got:\n%s", part, source)
+		}
+	}
+}
+
+func TestGenerateTestsMissingDir(t *testing.T) {
+	_, err := exectest.GenerateTests("mytool", "binary", filepath.Join(t.TempDir(), "missing"))
+	if err == nil || !strings.Contains(err.Error(), "failed to read scheme directory") {
+		t.Errorf("Expected the read failure, got: %v", err)
+	}
+}
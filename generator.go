@@ -0,0 +1,55 @@
+package exectest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StdinGenerator is the `--stdin-generate: lines=<n> [pattern=<pattern>]`
+// directive, it synthesizes the stdin of n lines during the preparation.
+//
+// The `{i}` of the pattern is replaced with the line number starting from 1,
+// the pattern takes the rest of the directive line:
+//
+//	--stdin-generate: lines=100000 pattern=line-{i}
+type StdinGenerator struct {
+	Lines   int
+	Pattern string
+}
+
+const defaultStdinPattern = "{i}"
+
+func parseStdinGenerator(text string) (*StdinGenerator, error) {
+	generator := &StdinGenerator{Lines: -1, Pattern: defaultStdinPattern}
+	attributes, pattern, hasPattern := strings.Cut(text, "pattern=")
+	if hasPattern {
+		generator.Pattern = strings.TrimSpace(pattern)
+	}
+	for _, attribute := range strings.Fields(attributes) {
+		lines, ok := strings.CutPrefix(attribute, "lines=")
+		if !ok {
+			return nil, fmt.Errorf("unknown --stdin-generate attribute %q", attribute)
+		}
+		count, err := strconv.Atoi(lines)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("malformed --stdin-generate lines %q, expected non-negative int", lines)
+		}
+		generator.Lines = count
+	}
+	if generator.Lines < 0 {
+		return nil, fmt.Errorf("malformed --stdin-generate %q, expected lines=<n>", strings.TrimSpace(text))
+	}
+	return generator, nil
+}
+
+// generate returns the lines of the stdin.
+func (g *StdinGenerator) generate() string {
+	var stdin strings.Builder
+	stdin.Grow(g.Lines * (len(g.Pattern) + 1))
+	for i := 1; i <= g.Lines; i++ {
+		stdin.WriteString(strings.ReplaceAll(g.Pattern, "{i}", strconv.Itoa(i)))
+		stdin.WriteByte('\n')
+	}
+	return stdin.String()
+}
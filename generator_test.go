@@ -0,0 +1,30 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteStdinGenerate(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:sed -n '1p;$p' && echo done
+--stdin-generate: lines=100000 pattern=line {i} of many
+--stdout
+line 1 of many
+line 100000 of many
+done
+`)
+}
+
+func TestParseSchemeStdinGenerate(t *testing.T) {
+	scheme, err := exectest.ParseScheme("--stdin-generate: lines=3\n")
+	if err != nil {
+		t.Fatalf("Failed to parse scheme: %s", err)
+	}
+	want := exectest.StdinGenerator{Lines: 3, Pattern: "{i}"}
+	if scheme.StdinGenerator == nil || *scheme.StdinGenerator != want {
+		t.Errorf("Unexpected generator: want %+v, got %+v", want, scheme.StdinGenerator)
+	}
+}
@@ -0,0 +1,135 @@
+package exectest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+var goBuilds = struct {
+	mu     sync.Mutex
+	dir    string
+	builds map[string]*goBuild
+}{builds: make(map[string]*goBuild)}
+
+type goBuild struct {
+	once sync.Once
+	// dir to put the binary to.
+	dir  string
+	path string
+	err  error
+}
+
+// BuildGoBinary builds the Go main package with `go build` and returns the
+// path to the binary.
+//
+// The package is built exactly once per test binary: binaries are shared by
+// all the tests and put to the temporary directory of the process removed by
+// [RemoveTempDirs]. When tests run with the coverage enabled, the binary is
+// built with `-cover` as well, see [WithCoverage].
+//
+// Example:
+//
+//	exectest.Execute(t, exectest.BuildGoBinary(t, "./cmd/mytool"), scheme)
+func BuildGoBinary(t testing.TB, pkg string) string {
+	t.Helper()
+	build, err := goBuildFor(pkg)
+	if err != nil {
+		t.Fatalf("Failed to build %s: %s", pkg, err)
+	}
+	build.once.Do(func() {
+		build.path, build.err = buildGoBinary(pkg, build.dir)
+	})
+	if build.err != nil {
+		t.Fatalf("Failed to build %s: %s", pkg, build.err)
+	}
+	return build.path
+}
+
+// ExecuteGoPackage builds the Go main package with [BuildGoBinary] and
+// executes the scheme against the binary.
+//
+// Example:
+//
+//	exectest.ExecuteGoPackage(t, "./cmd/mytool", scheme)
+func ExecuteGoPackage(t testing.TB, pkg, scheme string, opts ...Option) {
+	t.Helper()
+	Execute(t, BuildGoBinary(t, pkg), scheme, opts...)
+}
+
+// RemoveTempDirs removes the temporary directories shared by the tests of the
+// process: the binaries of [BuildGoBinary] and the links of [Self]. Call it
+// from the TestMain once the tests are run, the directories are created again
+// if needed later:
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		if err := exectest.RemoveTempDirs(); err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//		}
+//		os.Exit(code)
+//	}
+func RemoveTempDirs() error {
+	return errors.Join(removeGoBuilds(), removeSelfLinks())
+}
+
+// removeGoBuilds removes the build directory, the packages are built again
+// on the next use.
+func removeGoBuilds() error {
+	goBuilds.mu.Lock()
+	defer goBuilds.mu.Unlock()
+	if goBuilds.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(goBuilds.dir)
+	goBuilds.dir = ""
+	goBuilds.builds = make(map[string]*goBuild)
+	return err
+}
+
+// goBuildFor returns the shared build of the package.
+func goBuildFor(pkg string) (*goBuild, error) {
+	goBuilds.mu.Lock()
+	defer goBuilds.mu.Unlock()
+	if goBuilds.dir == "" {
+		dir, err := os.MkdirTemp("", "exectest-build-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create build directory: %w", err)
+		}
+		goBuilds.dir = dir
+	}
+	build, ok := goBuilds.builds[pkg]
+	if !ok {
+		build = &goBuild{
+			dir: filepath.Join(goBuilds.dir, strconv.Itoa(len(goBuilds.builds))),
+		}
+		goBuilds.builds[pkg] = build
+	}
+	return build, nil
+}
+
+func buildGoBinary(pkg string, dir string) (string, error) {
+	name := filepath.Base(pkg)
+	if name == "." || name == string(filepath.Separator) {
+		name = "main"
+	}
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	path := filepath.Join(dir, name)
+	args := []string{"build", "-o", path}
+	if mode := testing.CoverMode(); mode != "" {
+		args = append(args, "-cover", "-covermode="+mode)
+	}
+	output, err := exec.Command("go", append(args, pkg)...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%w:\n%s", err, output)
+	}
+	return path, nil
+}
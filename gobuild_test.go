@@ -0,0 +1,63 @@
+package exectest_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestBuildGoBinary(t *testing.T) {
+	binary := exectest.BuildGoBinary(t, "./testdata/hello")
+
+	exectest.Execute(t, binary, `
+--arg:world
+--arg:gopher
+--stdout
+hello, world
+hello, gopher
+`)
+}
+
+func TestExecuteGoPackage(t *testing.T) {
+	exectest.ExecuteGoPackage(t, "./testdata/hello", `
+--arg:gopher
+--stdout
+hello, gopher
+`)
+}
+
+func TestBuildGoBinaryBuildsOnce(t *testing.T) {
+	first := exectest.BuildGoBinary(t, "./testdata/hello")
+	second := exectest.BuildGoBinary(t, "./testdata/hello")
+
+	if first != second {
+		t.Errorf("Expected the same binary, got %s and %s", first, second)
+	}
+}
+
+func TestBuildGoBinaryFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.BuildGoBinary(tb, "./testdata/missing")
+	})
+
+	assertFailed(t, fake, "Failed to build ./testdata/missing")
+}
+
+func TestRemoveTempDirsGoBinary(t *testing.T) {
+	binary := exectest.BuildGoBinary(t, "./testdata/hello")
+
+	if err := exectest.RemoveTempDirs(); err != nil {
+		t.Fatalf("Failed to remove temporary directories: %s", err)
+	}
+	if _, err := os.Stat(binary); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the binary %s to be removed, got %v", binary, err)
+	}
+	exectest.ExecuteGoPackage(t, "./testdata/hello", `
+--arg:gopher
+--stdout
+hello, gopher
+`)
+}
@@ -0,0 +1,124 @@
+package exectest
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// inspectGoldenDir compares the scheme directory with the golden one, the
+// golden directory is rewritten instead in the update mode.
+func inspectGoldenDir(prepared schemeResult, result *executionResult) {
+	if updateMode() {
+		if err := updateGoldenDir(prepared.Dir, prepared.GoldenDir, prepared.FakeHome); err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("Failed to update golden directory %s: %s", prepared.GoldenDir, err))
+		}
+		return
+	}
+	var err error
+	if result.Golden, err = compareGoldenDir(prepared.Dir, prepared.GoldenDir, prepared.FakeHome); err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("Failed to compare golden directory %s: %s", prepared.GoldenDir, err))
+	}
+}
+
+// readFiles reads the regular files of the dir by their slash separated
+// paths, a missing dir has no files. The skip directory is left out if set.
+func readFiles(dir, skip string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == dir || path == skip {
+			return fs.SkipDir
+		}
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	return files, err
+}
+
+// compareGoldenDir compares the files of the dir with the ones of the golden
+// directory file by file, the skip directory of the dir is left out.
+func compareGoldenDir(dir, golden, skip string) ([]mismatch, error) {
+	want, err := readFiles(golden, "")
+	if err != nil {
+		return nil, err
+	}
+	got, err := readFiles(dir, skip)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(want)+len(got))
+	for path := range want {
+		paths = append(paths, path)
+	}
+	for path := range got {
+		if _, ok := want[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var mismatches []mismatch
+	for _, path := range paths {
+		wantContent, inGolden := want[path]
+		gotContent, inDir := got[path]
+		switch {
+		case !inDir:
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to find golden file %s in the scheme directory", path),
+			})
+		case !inGolden:
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed matching golden directory %s: unexpected file %s", golden, path),
+			})
+		case !bytes.Equal(wantContent, gotContent) && (isBinary(string(wantContent)) || isBinary(string(gotContent))):
+			mismatches = append(mismatches, hexMismatch("golden file "+path, string(wantContent), string(gotContent)))
+		case !bytes.Equal(wantContent, gotContent):
+			diff := cmp.Diff(toLines(string(wantContent)), toLines(string(gotContent)))
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed matching golden file %s (-missing line, +extra line): \n%s", path, diff),
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+// updateGoldenDir replaces the golden directory with the files of the dir
+// except the skip directory.
+func updateGoldenDir(dir, golden, skip string) error {
+	files, err := readFiles(dir, skip)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(golden); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(golden, 0o755); err != nil {
+		return err
+	}
+	for path, content := range files {
+		path = filepath.Join(golden, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
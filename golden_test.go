@@ -0,0 +1,65 @@
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const generateScheme = `
+--arg:-c
+--arg:mkdir sub; echo a > a.txt; echo b > sub/b.txt
+`
+
+func TestExecuteGoldenDir(t *testing.T) {
+	exectest.Execute(t, "sh", generateScheme+"--golden-dir:testdata/golden\n")
+}
+
+func TestExecuteGoldenDirFailure(t *testing.T) {
+	golden := t.TempDir()
+	writeFile(t, filepath.Join(golden, "a.txt"), "stale\n")
+	writeFile(t, filepath.Join(golden, "missing.txt"), "missing\n")
+
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", generateScheme+"--golden-dir:"+golden+"\n")
+	})
+
+	assertFailed(t, fake,
+		"Failed matching golden file a.txt", `"stale\n"`,
+		"Failed to find golden file missing.txt in the scheme directory",
+		"unexpected file sub/b.txt",
+	)
+}
+
+func TestExecuteGoldenDirUpdate(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	golden := t.TempDir()
+	writeFile(t, filepath.Join(golden, "stale.txt"), "stale\n")
+
+	exectest.Execute(t, "sh", generateScheme+"--golden-dir:"+golden+"\n")
+
+	assertFileContent(t, filepath.Join(golden, "a.txt"), "a\n")
+	assertFileContent(t, filepath.Join(golden, "sub", "b.txt"), "b\n")
+	if _, err := os.Stat(filepath.Join(golden, "stale.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected stale.txt to be removed, got %v", err)
+	}
+}
+
+func TestExecuteGoldenDirBinaryFailure(t *testing.T) {
+	golden := t.TempDir()
+	writeFile(t, filepath.Join(golden, "image.bin"), "\x89PNG\x00")
+
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:printf '\211PNG\001' > image.bin
+--golden-dir:`+golden+"\n")
+	})
+
+	assertFailed(t, fake,
+		"Failed matching golden file image.bin (hex dump, ! differing row):",
+		"00000000  89 50 4e 47 00          |.PNG.   |  89 50 4e 47 01          |.PNG.   | !",
+	)
+}
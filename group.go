@@ -0,0 +1,144 @@
+package exectest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// WithProcessGroup controls whether the binary is run in its own process
+// group, enabled by default. The group is killed on the `--timeout:` and once
+// the test is finished, so the descendants of the binary, e.g. shell pipelines
+// or daemons, don't outlive the test holding its directory.
+//
+// The group is a process group created with setpgid on Unix and a Job Object
+// on Windows. On other platforms only the binary itself is killed.
+func WithProcessGroup(enabled bool) Option {
+	return func(c *config) {
+		c.processGroup = enabled
+	}
+}
+
+// processGroup kills the process along with its descendants unless the group
+// is disabled.
+type processGroup struct {
+	enabled bool
+
+	mu       sync.Mutex
+	process  *os.Process
+	handle   groupHandle
+	attached bool
+	// signaled is the name of the last signal delivered to the process.
+	signaled string
+}
+
+// newProcessGroup configures the cmd to be started in a new group.
+func newProcessGroup(cmd *exec.Cmd, enabled bool) *processGroup {
+	if enabled {
+		configureGroup(cmd)
+	}
+	return &processGroup{enabled: enabled}
+}
+
+// watch is the watcher attaching the started process to the group and killing
+// it after the timeout, if positive.
+func (g *processGroup) watch(timeout time.Duration) watcher {
+	return g.watchWithin(timeout, fmt.Sprintf("Failed to finish within %s, the process is killed", timeout))
+}
+
+// watchWithin is the [processGroup.watch] reporting the failure once the
+// process is killed.
+func (g *processGroup) watchWithin(timeout time.Duration, failure string) watcher {
+	return func(process *os.Process, _ *outputBuffer, exited <-chan struct{}) []string {
+		g.mu.Lock()
+		g.process = process
+		if g.enabled {
+			// the process alone is killed if the group can't be attached.
+			handle, err := attachGroup(process)
+			g.handle, g.attached = handle, err == nil
+		}
+		g.mu.Unlock()
+		if timeout <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-exited:
+			return nil
+		case <-timer.C:
+		}
+		g.kill()
+		return []string{failure}
+	}
+}
+
+// watchIdle is the watcher killing the process group once neither the stdout
+// nor the stderr is written for the timeout.
+func (g *processGroup) watchIdle(timeout time.Duration, stderr *outputBuffer) watcher {
+	return func(_ *os.Process, stdout *outputBuffer, exited <-chan struct{}) []string {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		for {
+			stdout.mu.Lock()
+			stdoutChanged := stdout.changed
+			stdout.mu.Unlock()
+			stderr.mu.Lock()
+			stderrChanged := stderr.changed
+			stderr.mu.Unlock()
+
+			select {
+			case <-exited:
+				return nil
+			case <-stdoutChanged:
+			case <-stderrChanged:
+			case <-timer.C:
+				g.kill()
+				return []string{fmt.Sprintf("Failed to produce output within %s, the process is killed", timeout)}
+			}
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(timeout)
+		}
+	}
+}
+
+func (g *processGroup) kill() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.signaled = "SIGKILL"
+	if g.attached {
+		_ = killGroup(g.handle)
+	} else if g.process != nil {
+		_ = g.process.Kill()
+	}
+}
+
+// sent records the signal delivered to the process.
+func (g *processGroup) sent(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.signaled = name
+}
+
+// lastSignal returns the name of the last signal delivered to the process.
+func (g *processGroup) lastSignal() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.signaled
+}
+
+// release kills the descendants left running after the process exited.
+func (g *processGroup) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.attached {
+		_ = killGroup(g.handle)
+		releaseGroup(g.handle)
+		g.attached = false
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !unix && !windows
+
+package exectest
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// groupHandle is never created, there are no process groups on the platform.
+type groupHandle struct{}
+
+func configureGroup(*exec.Cmd) {}
+
+func attachGroup(*os.Process) (groupHandle, error) {
+	return groupHandle{}, errors.ErrUnsupported
+}
+
+func killGroup(groupHandle) error {
+	return errors.ErrUnsupported
+}
+
+func releaseGroup(groupHandle) {}
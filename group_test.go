@@ -0,0 +1,90 @@
+//go:build unix
+
+package exectest_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteTimeoutKillsProcessGroup(t *testing.T) {
+	start := time.Now()
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:sleep 30 | cat
+--timeout: 200ms
+`)
+	})
+
+	assertFailed(t, fake, "Failed to finish within 200ms, the process is killed")
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected the pipeline to be killed, took %s", elapsed)
+	}
+}
+
+func TestExecuteIdleTimeout(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:for i in 1 2 3 4 5; do echo $i >&2; sleep 0.1; done
+--idle-timeout: 2s
+--stderr
+1
+2
+3
+4
+5
+`)
+}
+
+func TestExecuteIdleTimeoutKillsSilentProcess(t *testing.T) {
+	start := time.Now()
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:echo started; sleep 30
+--idle-timeout: 200ms
+--stdout
+started
+`)
+	})
+
+	assertFailed(t, fake, "Failed to produce output within 200ms, the process is killed")
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected the silent process to be killed, took %s", elapsed)
+	}
+}
+
+func TestExecuteCleanupKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	t.Run("scheme", func(t *testing.T) {
+		exectest.Execute(t, "sh", `
+--arg:-c
+--arg:sleep 30 >/dev/null 2>&1 & echo $! >`+pidFile+`
+`)
+	})
+
+	pid, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("Failed to read pid: %s", err)
+	}
+	// the kill is delivered asynchronously and the killed process might be
+	// left as a zombie until it is reaped.
+	var stat string
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(50 * time.Millisecond) {
+		out, _ := exec.Command("ps", "-o", "stat=", "-p", strings.TrimSpace(string(pid))).Output()
+		if stat = strings.TrimSpace(string(out)); stat == "" || strings.HasPrefix(stat, "Z") {
+			return
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+	}
+	t.Errorf("Expected the background process to be killed, got state %q", stat)
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package exectest
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// groupHandle is the id of the process group, the same as the leader pid.
+type groupHandle int
+
+func configureGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func attachGroup(process *os.Process) (groupHandle, error) {
+	return groupHandle(process.Pid), nil
+}
+
+func killGroup(handle groupHandle) error {
+	err := syscall.Kill(-int(handle), syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
+
+func releaseGroup(groupHandle) {}
@@ -0,0 +1,55 @@
+//go:build windows
+
+package exectest
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	createJobObject          = kernel32.NewProc("CreateJobObjectW")
+	assignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	terminateJobObject       = kernel32.NewProc("TerminateJobObject")
+)
+
+// processSetQuota is the access right required to assign a process to a job.
+const processSetQuota = 0x0100
+
+// groupHandle is the Job Object the process is assigned to.
+type groupHandle syscall.Handle
+
+func configureGroup(*exec.Cmd) {}
+
+// attachGroup assigns the process to a new Job Object, the descendants started
+// by the process afterwards belong to the job as well.
+func attachGroup(process *os.Process) (groupHandle, error) {
+	job, _, err := createJobObject.Call(0, 0)
+	if job == 0 {
+		return 0, err
+	}
+	handle, err := syscall.OpenProcess(processSetQuota|syscall.PROCESS_TERMINATE, false, uint32(process.Pid))
+	if err != nil {
+		_ = syscall.CloseHandle(syscall.Handle(job))
+		return 0, err
+	}
+	defer syscall.CloseHandle(handle)
+	if ok, _, err := assignProcessToJobObject.Call(job, uintptr(handle)); ok == 0 {
+		_ = syscall.CloseHandle(syscall.Handle(job))
+		return 0, err
+	}
+	return groupHandle(job), nil
+}
+
+func killGroup(handle groupHandle) error {
+	if ok, _, err := terminateJobObject.Call(uintptr(handle), 1); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+func releaseGroup(handle groupHandle) {
+	_ = syscall.CloseHandle(syscall.Handle(handle))
+}
@@ -0,0 +1,86 @@
+package exectest_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTB records failures instead of reporting them to the wrapped test.
+type fakeTB struct {
+	testing.TB
+
+	mu      sync.Mutex
+	errors  []string
+	logs    []string
+	skipped bool
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Logf(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs = append(f.logs, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func (f *fakeTB) Skipf(string, ...any) {
+	f.mu.Lock()
+	f.skipped = true
+	f.mu.Unlock()
+	runtime.Goexit()
+}
+
+func (f *fakeTB) Failed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.errors) > 0
+}
+
+// runFake runs fn with a [fakeTB] wrapping t and returns the recorded failures.
+func runFake(t *testing.T, fn func(tb testing.TB)) *fakeTB {
+	t.Helper()
+	fake := &fakeTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(fake)
+	}()
+	<-done
+	return fake
+}
+
+// assertFailed checks the fake failed with an error containing every part.
+func assertFailed(t *testing.T, fake *fakeTB, parts ...string) {
+	t.Helper()
+	if !fake.Failed() {
+		t.Fatalf("Expected failure containing %q", parts)
+	}
+	all := strings.Join(fake.errors, "\n")
+	for _, part := range parts {
+		if !strings.Contains(all, part) {
+			t.Errorf("Expected failure containing %q, got:\n%s", part, all)
+		}
+	}
+}
+
+// assertPassed checks the fake recorded no failures.
+func assertPassed(t *testing.T, fake *fakeTB) {
+	t.Helper()
+	if fake.Failed() {
+		t.Errorf("Unexpected failures:\n%s", strings.Join(fake.errors, "\n"))
+	}
+}
@@ -0,0 +1,36 @@
+package exectest
+
+import "strings"
+
+// heredocArgPrefix starts the `--arg<<DELIM` argument spanning the lines up
+// to the DELIM one:
+//
+//	--arg<<SQL
+//	SELECT *
+//	FROM users
+//	SQL
+//
+// The lines of the argument aren't interpreted, the final newline is
+// dropped.
+const heredocArgPrefix = "--arg<<"
+
+// heredocBody tracks the bodies of the heredoc arguments of the scheme lines.
+type heredocBody struct {
+	delimiter string
+}
+
+// inside reports whether the line belongs to the heredoc body, the delimiter
+// line included, and not to be interpreted. The line must be passed in the
+// order of the scheme.
+func (h *heredocBody) inside(line string) bool {
+	if h.delimiter != "" {
+		if strings.TrimSpace(line) == h.delimiter {
+			h.delimiter = ""
+		}
+		return true
+	}
+	if delimiter, ok := strings.CutPrefix(line, heredocArgPrefix); ok {
+		h.delimiter = strings.TrimSpace(delimiter)
+	}
+	return false
+}
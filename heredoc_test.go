@@ -0,0 +1,41 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExecuteHeredocArg(t *testing.T) {
+	exectest.Execute(t, "printf", `
+--arg:[%s]\n
+--arg<<EOF
+{
+  "path": "{dir}",
+}
+EOF
+--stdout
+[{
+  "path": "{dir}",
+}]
+`)
+}
+
+func TestParseSchemeHeredocArg(t *testing.T) {
+	scheme, err := exectest.ParseScheme("--arg:-c\n--arg<<SQL\nSELECT *\n--run\n\nSQL\n--arg:last\n")
+	if err != nil {
+		t.Fatalf("Failed to parse scheme: %s", err)
+	}
+	if diff := cmp.Diff([]string{"-c", "SELECT *\n--run\n", "last"}, scheme.Args); diff != "" {
+		t.Errorf("Unexpected args (-want +got):\n%s", diff)
+	}
+}
+
+func TestExecuteStrictSchemeHeredocArg(t *testing.T) {
+	exectest.Execute(t, "true", `
+--arg<<EOF
+--unknown
+EOF
+`, exectest.WithStrictScheme())
+}
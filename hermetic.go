@@ -0,0 +1,26 @@
+package exectest
+
+import "testing"
+
+// WithHermeticEnv pins the environment affecting the output of the binary:
+// TZ=UTC, LANG=C, LC_ALL=C, TERM=dumb, NO_COLOR=1 and the HOME in a temporary
+// directory, so dates, locales and colors are the same on every machine.
+// The `--env:` directives override them.
+func WithHermeticEnv() Option {
+	return func(c *config) {
+		c.hermeticEnv = true
+	}
+}
+
+// hermeticEnv returns the environment of the [WithHermeticEnv].
+func hermeticEnv(t testing.TB) []string {
+	t.Helper()
+	return []string{
+		"TZ=UTC",
+		"LANG=C",
+		"LC_ALL=C",
+		"TERM=dumb",
+		"NO_COLOR=1",
+		"HOME=" + t.TempDir(),
+	}
+}
@@ -0,0 +1,31 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteHermeticEnv(t *testing.T) {
+	t.Setenv("TZ", "Asia/Tokyo")
+	t.Setenv("NO_COLOR", "")
+	exectest.Execute(t, "sh", `
+--env:TERM=xterm
+--arg:-c
+--arg:echo $TZ $LANG $LC_ALL $TERM $NO_COLOR; test -d "$HOME" && test "$HOME" != "{dir}" && date -d @0 +%H
+--stdout
+UTC C C xterm 1
+00
+`, exectest.WithHermeticEnv())
+}
+
+func TestExecuteHermeticEnvSharedBySteps(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--run
+--arg:-c
+--arg:touch "$HOME/marker"
+--run
+--arg:-c
+--arg:test -e "$HOME/marker"
+`, exectest.WithHermeticEnv())
+}
@@ -0,0 +1,128 @@
+package exectest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// hexRowSize is the number of bytes of a hex dump row.
+	hexRowSize = 8
+	// maxHexRows limits the rows of the hex dump diff.
+	maxHexRows = 64
+)
+
+// decodeHex returns the bytes of the `--stdout-hex` block, the whitespace
+// between the hex digits is ignored.
+func decodeHex(block string) (string, error) {
+	decoded, err := hex.DecodeString(strings.Join(strings.Fields(block), ""))
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// formatHexBlock turns the output into the `--stdout-hex` block content of
+// the rows of 16 bytes.
+func formatHexBlock(output string) string {
+	var block strings.Builder
+	for start := 0; start < len(output); start += 2 * hexRowSize {
+		row := output[start:min(start+2*hexRowSize, len(output))]
+		for i := 0; i < len(row); i++ {
+			if i > 0 {
+				block.WriteByte(' ')
+			}
+			fmt.Fprintf(&block, "%02x", row[i])
+		}
+		block.WriteByte('\n')
+	}
+	return block.String()
+}
+
+// isBinary reports whether the output is mangled as text: it's not UTF-8 or
+// has control characters other than the tab, the newline and the carriage
+// return.
+func isBinary(output string) bool {
+	if !utf8.ValidString(output) {
+		return true
+	}
+	for _, r := range output {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return true
+		}
+	}
+	return false
+}
+
+// hexMismatch is the mismatch of the binary output rendered as the hex dump.
+func hexMismatch(name, want, got string) mismatch {
+	return mismatch{message: fmt.Sprintf("Failed matching %s (hex dump, ! differing row):\n%s", name, hexDiff(want, got))}
+}
+
+// hexDiff renders the want and got bytes as the side-by-side hex dump with
+// the offsets. The differing rows are marked with `!`, the runs of the equal
+// rows away from them are elided.
+func hexDiff(want, got string) string {
+	rows := (max(len(want), len(got)) + hexRowSize - 1) / hexRowSize
+	differ := func(row int) bool {
+		return row >= 0 && row < rows && hexRow(want, row) != hexRow(got, row)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-8s  %-34s  %s\n", "offset", "want", "got")
+	var printed int
+	var elided bool
+	for row := 0; row < rows; row++ {
+		if !differ(row-1) && !differ(row) && !differ(row+1) {
+			if !elided {
+				b.WriteString("...\n")
+			}
+			elided = true
+			continue
+		}
+		if printed == maxHexRows {
+			fmt.Fprintf(&b, "... %d more bytes\n", max(len(want), len(got))-row*hexRowSize)
+			break
+		}
+		marker := ""
+		if differ(row) {
+			marker = " !"
+		}
+		fmt.Fprintf(&b, "%08x  %s  %s%s\n", row*hexRowSize, formatHexRow(hexRow(want, row)), formatHexRow(hexRow(got, row)), marker)
+		printed++
+		elided = false
+	}
+	return b.String()
+}
+
+// hexRow returns the bytes of the row of the hex dump of the data.
+func hexRow(data string, row int) string {
+	start := min(row*hexRowSize, len(data))
+	return data[start:min(start+hexRowSize, len(data))]
+}
+
+// formatHexRow renders the bytes as the hex digits followed by the
+// printable characters, the row is padded to the full width.
+func formatHexRow(row string) string {
+	var digits, chars strings.Builder
+	for i := 0; i < hexRowSize; i++ {
+		if i > 0 {
+			digits.WriteByte(' ')
+		}
+		if i >= len(row) {
+			digits.WriteString("  ")
+			chars.WriteByte(' ')
+			continue
+		}
+		fmt.Fprintf(&digits, "%02x", row[i])
+		if c := row[i]; c >= ' ' && c < 0x7f {
+			chars.WriteByte(c)
+		} else {
+			chars.WriteByte('.')
+		}
+	}
+	return digits.String() + " |" + chars.String() + "|"
+}
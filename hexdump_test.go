@@ -0,0 +1,65 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteStdoutHex(t *testing.T) {
+	exectest.Execute(t, "printf", `
+--arg:\211PNG\r\n\032\n\000
+--stdout-hex
+89 50 4e 47 0d 0a
+1a0a00
+`)
+}
+
+func TestExecuteBinaryOutputHexDiff(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "printf", `
+--arg:\000\001zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz\002\003
+--stdout-hex
+00 01 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a
+7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a
+7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 7a 02 04
+`)
+	})
+
+	assertFailed(t, fake, `Failed matching stdout (hex dump, ! differing row):
+offset    want                                got
+...
+00000020  7a 7a 7a 7a 7a 7a 7a 7a |zzzzzzzz|  7a 7a 7a 7a 7a 7a 7a 7a |zzzzzzzz|
+00000028  7a 7a 7a 7a 02 04       |zzzz..  |  7a 7a 7a 7a 02 03       |zzzz..  | !
+`)
+}
+
+func TestExecuteBinaryStdoutHexDiff(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "printf", `
+--arg:a\000
+--stdout
+a
+`)
+	})
+
+	assertFailed(t, fake, `00000000  61 0a                   |a.      |  61 00                   |a.      | !`)
+}
+
+func TestExecuteForFileUpdateRewritesStdoutHex(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `--arg:\000\001\002\003\004\005\006\007\010\011\012\013\014\015\016\017\377
+--stdout-hex
+00
+`)
+
+	exectest.ExecuteForFile(t, "printf", file)
+
+	assertFileContent(t, file, `--arg:\000\001\002\003\004\005\006\007\010\011\012\013\014\015\016\017\377
+--stdout-hex
+00 01 02 03 04 05 06 07 08 09 0a 0b 0c 0d 0e 0f
+ff
+`)
+}
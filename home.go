@@ -0,0 +1,66 @@
+package exectest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeHomeDir is the HOME of the [WithFakeHome] in the scheme directory.
+const fakeHomeDir = ".home"
+
+// WithFakeHome isolates the binary from the user configuration: the HOME,
+// XDG_CONFIG_HOME and XDG_CACHE_HOME are the `.home`, `.home/.config` and
+// `.home/.cache` directories created in the scheme directory. They are
+// available as the `{home}`, `{config}` and `{cache}` placeholders, so the
+// configuration files can be created with the `--file:` directive:
+//
+//	--file:.home/.config/tool/config.toml
+//	verbose = true
+//
+// The `.home` is not a part of the `--expect-tree` and the `--golden-dir`
+// comparisons and stays writable under the `--read-only`.
+func WithFakeHome() Option {
+	return func(c *config) {
+		c.fakeHome = true
+		c.variables["home"] = fakeHome
+		c.variables["config"] = func(dir string) string {
+			return filepath.Join(fakeHome(dir), ".config")
+		}
+		c.variables["cache"] = func(dir string) string {
+			return filepath.Join(fakeHome(dir), ".cache")
+		}
+	}
+}
+
+func fakeHome(dir string) string {
+	return filepath.Join(dir, fakeHomeDir)
+}
+
+// fakeHomeFor returns the fake home of the dir if the [WithFakeHome] is
+// used, the empty string otherwise.
+func fakeHomeFor(dir string, cfg *config) string {
+	if !cfg.fakeHome {
+		return ""
+	}
+	return fakeHome(dir)
+}
+
+// fakeHomeEnv creates the directories of the [WithFakeHome] and returns the
+// environment pointing to them.
+func fakeHomeEnv(t testing.TB, dir string) []string {
+	t.Helper()
+	home := fakeHome(dir)
+	env := []string{"HOME=" + home}
+	for _, xdg := range []struct{ name, dir string }{
+		{"XDG_CONFIG_HOME", ".config"},
+		{"XDG_CACHE_HOME", ".cache"},
+	} {
+		path := filepath.Join(home, xdg.dir)
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			t.Fatalf("Failed to create directory (%q) for fake home: %s", path, err)
+		}
+		env = append(env, xdg.name+"="+path)
+	}
+	return env
+}
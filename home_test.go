@@ -0,0 +1,59 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteFakeHome(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--file:.home/.config/tool/config.toml
+verbose = true
+--arg:-c
+--arg:echo $HOME $XDG_CONFIG_HOME $XDG_CACHE_HOME && cat {config}/tool/config.toml && test -d {cache}
+--stdout
+{home} {dir}/.home/.config {dir}/.home/.cache
+verbose = true
+`, exectest.WithFakeHome())
+}
+
+func TestExecuteFakeHomeOverridesHermeticHome(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo $HOME $TZ
+--stdout
+{home} UTC
+`, exectest.WithHermeticEnv(), exectest.WithFakeHome())
+}
+
+func TestExecuteFakeHomeExpectTree(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--file:.home/.config/tool/config.toml
+verbose = true
+--arg:-c
+--arg:echo out > out.txt && echo cached > $XDG_CACHE_HOME/tool
+--expect-tree
+out.txt size=4
+`, exectest.WithFakeHome())
+}
+
+func TestExecuteFakeHomeGoldenDir(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:mkdir sub; echo a > a.txt; echo b > sub/b.txt; echo cached > $XDG_CACHE_HOME/tool
+--golden-dir:testdata/golden
+`, exectest.WithFakeHome())
+}
+
+func TestExecuteFakeHomeReadOnly(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--read-only
+--arg:-c
+--arg:stat -c %a . {home} {cache}
+--stdout
+555
+755
+755
+`, exectest.WithFakeHome())
+}
@@ -0,0 +1,39 @@
+package exectest
+
+import "testing"
+
+// Run is the finished execution of the binary passed to the [WithAfterRun]
+// hooks.
+type Run struct {
+	// Dir is the scheme directory.
+	Dir string
+	// ReturnCode of the binary.
+	ReturnCode int
+	// expand evaluates the placeholders of the scheme.
+	expand func(string) string
+}
+
+// Expand returns the text with the placeholders expanded to the values of
+// the execution, e.g. the paths of the `{socket:NAME}` ones. The zero Run
+// returns the text unchanged.
+func (r Run) Expand(text string) string {
+	if r.expand == nil {
+		return text
+	}
+	return r.expand(text)
+}
+
+// WithAfterRun calls the hook once the binary exits, the scheme directory is
+// checked by the hook then, e.g. with the [AssertSocket]. The hooks run for
+// every `--run` step and `--concurrent:` instance.
+//
+// Example:
+//
+//	exectest.WithAfterRun(func(t testing.TB, run exectest.Run) {
+//		exectest.AssertNoSocket(t, run.Expand("{socket:ctl}"))
+//	})
+func WithAfterRun(hook func(t testing.TB, run Run)) Option {
+	return func(c *config) {
+		c.afterRun = append(c.afterRun, hook)
+	}
+}
@@ -0,0 +1,36 @@
+package exectest
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// parseInheritEnv returns the names of the `--inherit-env:` directive, the
+// empty list inherits no variables.
+func parseInheritEnv(text string) ([]string, error) {
+	names := splitList(text)
+	for _, name := range names {
+		if strings.ContainsAny(name, "= \t") {
+			return nil, fmt.Errorf("malformed --inherit-env name %q", name)
+		}
+	}
+	return names, nil
+}
+
+// inheritedEnv returns the variables of the environment with the names, the
+// names are case-insensitive on Windows as the variables are. The result is
+// never nil, the nil environment of the [exec.Cmd] is the inherited one.
+func inheritedEnv(env []string, names []string) []string {
+	inherited := []string{}
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		for _, inherit := range names {
+			if name == inherit || runtime.GOOS == "windows" && strings.EqualFold(name, inherit) {
+				inherited = append(inherited, entry)
+				break
+			}
+		}
+	}
+	return inherited
+}
@@ -0,0 +1,50 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteInheritEnv(t *testing.T) {
+	t.Setenv("EXECTEST_KEPT", "kept")
+	t.Setenv("EXECTEST_DROPPED", "dropped")
+	exectest.Execute(t, "sh", `
+--inherit-env:PATH, EXECTEST_KEPT
+--env:OWN=own
+--arg:-c
+--arg:echo "$EXECTEST_KEPT ${EXECTEST_DROPPED:-unset} $OWN"
+--stdout
+kept unset own
+`)
+}
+
+func TestExecuteInheritEnvNothing(t *testing.T) {
+	t.Setenv("EXECTEST_DROPPED", "dropped")
+	exectest.Execute(t, "/bin/sh", `
+--inherit-env:
+--arg:-c
+--arg:echo "${EXECTEST_DROPPED:-unset}"
+--stdout
+unset
+`)
+}
+
+func TestExecuteInheritEnvSteps(t *testing.T) {
+	t.Setenv("EXECTEST_KEPT", "kept")
+	t.Setenv("EXECTEST_DROPPED", "dropped")
+	exectest.Execute(t, "sh", `
+--inherit-env:PATH
+--run
+--inherit-env:EXECTEST_KEPT
+--arg:-c
+--arg:echo "$EXECTEST_KEPT ${EXECTEST_DROPPED:-unset}"
+--stdout
+kept unset
+--run
+--arg:-c
+--arg:echo "${EXECTEST_KEPT:-unset}"
+--stdout
+unset
+`)
+}
@@ -0,0 +1,53 @@
+package exectest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// checkInOrder checks the lines of the want appear in the same order in the
+// output, the other lines of the output might be in between.
+func checkInOrder(name, want, got, spool string, opts []cmp.Option) (mismatch, bool) {
+	var output io.Reader = strings.NewReader(got)
+	if spool != "" {
+		file, err := os.Open(spool)
+		if err != nil {
+			return mismatch{message: fmt.Sprintf("Failed to read spooled %s: %s", name, err)}, false
+		}
+		defer file.Close()
+		output = file
+	}
+	wantLines := toLines(want)
+	var matched, lastNumber int
+	reader := bufio.NewReader(output)
+	for number := 1; matched < len(wantLines); number++ {
+		line, ok := readSpoolLine(reader)
+		if !ok {
+			break
+		}
+		if equalLines(wantLines[matched], line, opts) {
+			matched++
+			lastNumber = number
+		}
+	}
+	if matched == len(wantLines) {
+		return mismatch{}, true
+	}
+	where := "in the output"
+	if matched > 0 {
+		where = fmt.Sprintf("after line %d of the output", lastNumber)
+	}
+	m := mismatch{
+		message: fmt.Sprintf("Failed to match %s in order: line %d %q is not found %s",
+			name, matched+1, strings.TrimSuffix(wantLines[matched], "\n"), where),
+	}
+	if spool == "" {
+		m.output = fmt.Sprintf("%s:\n%s", name, got)
+	}
+	return m, false
+}
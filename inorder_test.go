@@ -0,0 +1,47 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const progressScript = `echo "debug: start"; echo "downloading"; echo "debug: 50%"; echo "extracting"; echo "done"`
+
+func TestExecuteStdoutInOrder(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+progressScript+`; echo WARN >&2
+--stdout-in-order
+downloading
+extracting
+done
+--stderr: in-order, ignore-case
+warn
+`)
+}
+
+func TestExecuteStdoutInOrderFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:`+progressScript+`
+--stdout-in-order
+extracting
+downloading
+`)
+	})
+
+	assertFailed(t, fake, `Failed to match stdout in order: line 2 "downloading" is not found after line 4 of the output`)
+
+	fake = runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:`+progressScript+`
+--stdout-in-order
+installing
+`, exectest.WithOutputSpool(t.TempDir()))
+	})
+
+	assertFailed(t, fake, `Failed to match stdout in order: line 1 "installing" is not found in the output`)
+}
@@ -0,0 +1,97 @@
+package exectest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// defaultInteractTimeout is the timeout of an expect step of the `--interact`
+// block without explicit timeout.
+const defaultInteractTimeout = 5 * time.Second
+
+// InteractionStep is a step of the `--interact` block: either waiting for the
+// Expect text on the stdout or writing the Send line to the stdin.
+type InteractionStep struct {
+	// Expect is the text to wait for, empty for send steps.
+	Expect string
+	// Send is the line written to the stdin without the line break.
+	Send string
+	// Timeout of waiting for the Expect text.
+	Timeout time.Duration
+}
+
+// parseInteraction parses the `--interact` block, the header is the text after
+// the `--interact:` with an optional default timeout.
+//
+//	--interact: 2s
+//	expect: Password:
+//	send: secret
+//	timeout: 10s
+//	expect: Logged in
+func parseInteraction(header, block string) ([]InteractionStep, error) {
+	timeout := defaultInteractTimeout
+	if header = strings.TrimSpace(header); header != "" {
+		var err error
+		if timeout, err = time.ParseDuration(header); err != nil {
+			return nil, fmt.Errorf("failed to parse --interact timeout %q: %w", header, err)
+		}
+	}
+
+	steps := []InteractionStep{}
+	for _, line := range toLines(block) {
+		line = strings.TrimSuffix(line, "\n")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		kind, value, ok := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch {
+		case ok && kind == "expect" && value != "":
+			steps = append(steps, InteractionStep{Expect: value, Timeout: timeout})
+		case ok && kind == "send":
+			steps = append(steps, InteractionStep{Send: value})
+		case ok && kind == "timeout":
+			var err error
+			if timeout, err = time.ParseDuration(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("failed to parse --interact timeout %q: %w", value, err)
+			}
+		default:
+			return nil, fmt.Errorf("malformed --interact step %q, expected expect:, send: or timeout:", line)
+		}
+	}
+	return steps, nil
+}
+
+// interact runs the interaction steps against the running process, it returns
+// the failures of the expect steps. The interaction stops on the first one.
+func interact(steps []InteractionStep) feeder {
+	return func(stdin io.Writer, stdout *outputBuffer, exited <-chan struct{}) []string {
+		var offset int
+		for _, step := range steps {
+			if step.Expect == "" {
+				_, _ = io.WriteString(stdin, step.Send+"\n")
+				continue
+			}
+			var ok bool
+			if offset, ok = stdout.waitFor(step.Expect, offset, step.Timeout, exited); !ok {
+				return []string{fmt.Sprintf("Failed to interact: %q did not appear on stdout within %s", step.Expect, step.Timeout)}
+			}
+		}
+		return nil
+	}
+}
+
+// feeder writes the input to the stdin of the running process. The process
+// output is captured to the stdout and the exited is closed once the process
+// is finished. Unlike the writing errors, the returned failures are reported.
+type feeder func(stdin io.Writer, stdout *outputBuffer, exited <-chan struct{}) []string
+
+// feedString writes the text to the stdin.
+func feedString(text string) feeder {
+	return func(stdin io.Writer, _ *outputBuffer, _ <-chan struct{}) []string {
+		_, _ = io.WriteString(stdin, text)
+		return nil
+	}
+}
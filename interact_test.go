@@ -0,0 +1,52 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const promptScript = `printf "Name: "; read name; printf "Sure, %s? " "$name"; read answer; echo "$answer, $name"`
+
+func TestExecuteInteract(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+promptScript+`
+--interact: 2s
+expect: Name:
+send: gopher
+expect: Sure, gopher?
+send: yes
+--stdout
+Name: Sure, gopher? yes, gopher
+`)
+}
+
+func TestExecuteInteractTimeout(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:`+promptScript+`
+--interact
+timeout: 100ms
+expect: Password:
+send: secret
+`)
+	})
+
+	assertFailed(t, fake, `Failed to interact: "Password:" did not appear on stdout within 100ms`)
+}
+
+func TestExecuteInteractProcessExited(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `
+--arg:hello
+--interact
+expect: bye
+--stdout
+hello
+`)
+	})
+
+	assertFailed(t, fake, `Failed to interact: "bye" did not appear on stdout within 5s`)
+}
@@ -0,0 +1,122 @@
+// Package junit writes test results in the JUnit XML format.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Status is the outcome of a test case.
+type Status int
+
+const (
+	// Passed test case.
+	Passed Status = iota
+	// Failed test case.
+	Failed
+	// Skipped test case.
+	Skipped
+)
+
+// TestCase is the result of a single test.
+type TestCase struct {
+	Name     string
+	Status   Status
+	Duration time.Duration
+	// Message is the failure text (e.g. a diff) or the skip reason.
+	Message string
+}
+
+// Suite is a named group of test cases.
+type Suite struct {
+	Name  string
+	Cases []TestCase
+}
+
+// Writer writes suites to the underlying writer.
+//
+// Every suite is written as a separate `<testsuite>` document, so use a
+// writer (file) per suite.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter creates a [Writer] writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteSuite writes the suite as a `<testsuite>` document.
+func (w *Writer) WriteSuite(suite Suite) error {
+	doc := xmlSuite{Name: suite.Name, Tests: len(suite.Cases)}
+	var total time.Duration
+	for _, c := range suite.Cases {
+		total += c.Duration
+		xc := xmlCase{Name: c.Name, ClassName: suite.Name, Time: seconds(c.Duration)}
+		switch c.Status {
+		case Failed:
+			doc.Failures++
+			xc.Failure = &xmlMessage{Message: firstLine(c.Message), Text: c.Message}
+		case Skipped:
+			doc.Skipped++
+			xc.Skipped = &xmlMessage{Message: firstLine(c.Message)}
+		}
+		doc.Cases = append(doc.Cases, xc)
+	}
+	doc.Time = seconds(total)
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal suite %s: %w", suite.Name, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := io.WriteString(w.w, xml.Header); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(append(out, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+type xmlSuite struct {
+	XMLName  xml.Name  `xml:"testsuite"`
+	Name     string    `xml:"name,attr"`
+	Tests    int       `xml:"tests,attr"`
+	Failures int       `xml:"failures,attr"`
+	Skipped  int       `xml:"skipped,attr"`
+	Time     string    `xml:"time,attr"`
+	Cases    []xmlCase `xml:"testcase"`
+}
+
+type xmlCase struct {
+	Name      string      `xml:"name,attr"`
+	ClassName string      `xml:"classname,attr"`
+	Time      string      `xml:"time,attr"`
+	Failure   *xmlMessage `xml:"failure,omitempty"`
+	Skipped   *xmlMessage `xml:"skipped,omitempty"`
+}
+
+type xmlMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func firstLine(text string) string {
+	for i, r := range text {
+		if r == '\n' {
+			return text[:i]
+		}
+	}
+	return text
+}
@@ -0,0 +1,41 @@
+package junit_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IlyasYOY/exectest/junit"
+)
+
+func TestWriteSuite(t *testing.T) {
+	var out strings.Builder
+	w := junit.NewWriter(&out)
+
+	err := w.WriteSuite(junit.Suite{
+		Name: "TestSchemes",
+		Cases: []junit.TestCase{
+			{Name: "a.txt", Status: junit.Passed, Duration: 1500 * time.Millisecond},
+			{Name: "b.txt", Status: junit.Failed, Duration: 500 * time.Millisecond, Message: "Failed matching stdout:\n-a\n+b"},
+			{Name: "c.txt", Status: junit.Skipped, Message: "tags"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to write suite: %s", err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="TestSchemes" tests="3" failures="1" skipped="1" time="2.000">
+  <testcase name="a.txt" classname="TestSchemes" time="1.500"></testcase>
+  <testcase name="b.txt" classname="TestSchemes" time="0.500">
+    <failure message="Failed matching stdout:">Failed matching stdout:&#xA;-a&#xA;+b</failure>
+  </testcase>
+  <testcase name="c.txt" classname="TestSchemes" time="0.000">
+    <skipped message="tags"></skipped>
+  </testcase>
+</testsuite>
+`
+	if got := out.String(); got != want {
+		t.Errorf("Unexpected XML:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
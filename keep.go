@@ -0,0 +1,63 @@
+package exectest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// WithKeepDirOnFailure moves the scheme directory of the failed test to
+// `exectest-kept/<test name>` of the [os.TempDir] and logs its path instead of
+// letting it be removed, the same as EXECTEST_KEEP_DIR=1 does. The kept
+// directories of the previous runs are replaced.
+func WithKeepDirOnFailure() Option {
+	return func(c *config) {
+		c.keepDir = true
+	}
+}
+
+// schemeDir creates the temporary scheme directory, it's kept if the test
+// fails and it's configured.
+func schemeDir(t testing.TB, cfg *config) string {
+	t.Helper()
+	dir := t.TempDir()
+	if cfg.keepDir || os.Getenv("EXECTEST_KEEP_DIR") == "1" {
+		// the cleanup runs before the removal of the registered earlier dir.
+		t.Cleanup(func() {
+			if t.Failed() {
+				keepDir(t, dir)
+			}
+		})
+	}
+	return dir
+}
+
+// keepDir moves the dir to the stable location named after the test, the
+// base name of the dir tells apart the dirs of the same test.
+func keepDir(t testing.TB, dir string) {
+	kept := filepath.Join(os.TempDir(), "exectest-kept", testFileName(t.Name()), filepath.Base(dir))
+	if err := os.RemoveAll(kept); err != nil {
+		t.Logf("Failed to keep the scheme directory %s: %s", dir, err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(kept), 0o755); err != nil {
+		t.Logf("Failed to keep the scheme directory %s: %s", dir, err)
+		return
+	}
+	if err := os.Rename(dir, kept); err != nil {
+		t.Logf("Failed to keep the scheme directory %s: %s", dir, err)
+		return
+	}
+	t.Logf("Kept the scheme directory at %s", kept)
+}
+
+// testFileName returns the name of the test usable as the file name.
+func testFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,41 @@
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteKeepDirOnFailure(t *testing.T) {
+	kept := filepath.Join(os.TempDir(), "exectest-kept", strings.ReplaceAll(t.Name(), "/", "_"), "001")
+	t.Cleanup(func() {
+		// the dir is kept by the cleanup registered later.
+		assertFileContent(t, filepath.Join(kept, "out.txt"), "produced\n")
+		os.RemoveAll(filepath.Dir(kept))
+	})
+
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:echo produced > out.txt; exit 1
+`, exectest.WithKeepDirOnFailure())
+	})
+
+	assertFailed(t, fake, "Failed to match return code: want 0, got 1")
+}
+
+func TestExecuteKeepDirEnv(t *testing.T) {
+	t.Setenv("EXECTEST_KEEP_DIR", "1")
+	kept := filepath.Join(os.TempDir(), "exectest-kept", t.Name())
+	os.RemoveAll(kept)
+	t.Cleanup(func() {
+		if _, err := os.Stat(kept); !os.IsNotExist(err) {
+			t.Errorf("Expected the passed scheme directory not to be kept, got %v", err)
+		}
+	})
+
+	exectest.Execute(t, "true", "")
+}
@@ -0,0 +1,119 @@
+package exectest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errLimitsUnsupported is returned by limitCommand on platforms without
+// rlimits.
+var errLimitsUnsupported = errors.New("resource limits are not supported on this platform")
+
+// Limit is the `--limit:<resource>=<value>` directive, the rlimit applied to
+// the binary before the exec. The resources are:
+//
+//   - `nofile`: the number of open file descriptors;
+//   - `fsize`: the size of the written files, rounded up to 512 bytes;
+//   - `as`: the size of the address space, rounded up to 1KB;
+//   - `cpu`: the CPU time, a duration rounded up to seconds.
+//
+// The sizes are bytes with optional `KB`, `MB` or `GB` suffixes of the
+// powers of 1024. Both the soft and the hard limits are lowered, so the
+// binary can't raise them back.
+//
+//	--limit: nofile=16
+//	--limit: fsize=1MB
+type Limit struct {
+	// Resource is the name of the limited resource.
+	Resource string
+	// Value is the count of the `nofile`, the bytes of the `fsize` and `as`
+	// and the seconds of the `cpu`.
+	Value uint64
+}
+
+// limitFlags are the `ulimit` flags of the resources with the sizes of their
+// units in the values.
+var limitFlags = map[string]struct {
+	flag string
+	unit uint64
+}{
+	"nofile": {flag: "-n", unit: 1},
+	"fsize":  {flag: "-f", unit: 512},
+	"as":     {flag: "-v", unit: 1024},
+	"cpu":    {flag: "-t", unit: 1},
+}
+
+func parseLimit(text string) (Limit, error) {
+	resource, value, ok := strings.Cut(strings.TrimSpace(text), "=")
+	if !ok {
+		return Limit{}, fmt.Errorf("malformed --limit %q, expected <resource>=<value>", strings.TrimSpace(text))
+	}
+	limit := Limit{Resource: strings.TrimSpace(resource)}
+	value = strings.TrimSpace(value)
+	var err error
+	switch limit.Resource {
+	case "nofile":
+		limit.Value, err = strconv.ParseUint(value, 10, 64)
+	case "fsize", "as":
+		limit.Value, err = parseBytes(value)
+	case "cpu":
+		var d time.Duration
+		if d, err = time.ParseDuration(value); err == nil && d <= 0 {
+			err = errors.New("duration must be positive")
+		}
+		limit.Value = uint64(math.Ceil(d.Seconds()))
+	default:
+		return Limit{}, fmt.Errorf("unsupported limit resource %q", limit.Resource)
+	}
+	if err != nil {
+		return Limit{}, fmt.Errorf("failed to parse %s limit %q: %w", limit.Resource, value, err)
+	}
+	return limit, nil
+}
+
+// byteUnits are the size suffixes, the longer ones go first.
+var byteUnits = []struct {
+	suffix string
+	size   uint64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseBytes parses the size of bytes with an optional unit suffix.
+func parseBytes(text string) (uint64, error) {
+	unit := uint64(1)
+	for _, u := range byteUnits {
+		if number, ok := strings.CutSuffix(text, u.suffix); ok {
+			text, unit = strings.TrimSpace(number), u.size
+			break
+		}
+	}
+	value, err := strconv.ParseUint(text, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value > math.MaxUint64/unit {
+		return 0, fmt.Errorf("size %d overflows", value)
+	}
+	return value * unit, nil
+}
+
+// ulimitScript returns the shell script applying the limits and executing its
+// arguments.
+func ulimitScript(limits []Limit) string {
+	var script strings.Builder
+	for _, limit := range limits {
+		flag := limitFlags[limit.Resource]
+		blocks := (limit.Value + flag.unit - 1) / flag.unit
+		fmt.Fprintf(&script, "ulimit %s %d || exit 125\n", flag.flag, blocks)
+	}
+	script.WriteString(`exec "$0" "$@"`)
+	return script.String()
+}
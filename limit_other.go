@@ -0,0 +1,11 @@
+//go:build !unix
+
+package exectest
+
+import "os/exec"
+
+// limitCommand fails, the Job Objects of Windows don't limit the open files
+// and the written sizes before the process starts.
+func limitCommand(*exec.Cmd, []Limit) error {
+	return errLimitsUnsupported
+}
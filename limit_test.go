@@ -0,0 +1,71 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSchemeLimits(t *testing.T) {
+	scheme, err := exectest.ParseScheme(`--limit: nofile=16
+--limit: fsize=1MB
+--limit: as = 512KB
+--limit: cpu=1500ms
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+
+	want := []exectest.Limit{
+		{Resource: "nofile", Value: 16},
+		{Resource: "fsize", Value: 1 << 20},
+		{Resource: "as", Value: 512 << 10},
+		{Resource: "cpu", Value: 2},
+	}
+	if diff := cmp.Diff(want, scheme.Limits); diff != "" {
+		t.Errorf("Unexpected limits (-want, +got):\n%s", diff)
+	}
+}
+
+func TestExecuteLimit(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--limit: nofile=16
+--limit: fsize=1KB
+--arg:-c
+--arg:ulimit -n; ulimit -f
+--stdout
+16
+2
+`)
+}
+
+func TestExecuteLimitFileSize(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--limit: fsize=1KB
+--arg:-c
+--arg:trap '' XFSZ; head -c 4096 /dev/zero > out.bin || echo failed
+--stdout
+failed
+--expect-stat: out.bin size=1024
+--stderr
+...
+`)
+}
+
+func TestExecuteLimitSteps(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--limit: nofile=16
+--run
+--arg:-c
+--arg:ulimit -n
+--stdout
+16
+--run
+--limit: nofile=8
+--arg:-c
+--arg:ulimit -n
+--stdout
+8
+`)
+}
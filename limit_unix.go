@@ -0,0 +1,21 @@
+//go:build unix
+
+package exectest
+
+import "os/exec"
+
+// limitCommand runs the binary of the cmd through the shell applying the
+// limits, the shell replaces itself with the binary after `ulimit`. The cmd
+// failed to resolve the binary is kept as is to report the start error.
+func limitCommand(cmd *exec.Cmd, limits []Limit) error {
+	if cmd.Err != nil {
+		return nil
+	}
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		return err
+	}
+	cmd.Args = append([]string{"sh", "-c", ulimitScript(limits), cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = shell
+	return nil
+}
@@ -0,0 +1,67 @@
+package exectest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LineCount is the `--stdout-lines:` and `--stderr-lines:` directive, the
+// number of the lines of the output compared with `=`, `<`, `>`, `<=` or
+// `>=`, the bare number is the exact one.
+//
+//	--stdout-lines: >=100
+type LineCount struct {
+	Operator string
+	Count    int64
+}
+
+func parseLineCount(text string) (*LineCount, error) {
+	text = strings.TrimSpace(text)
+	count := &LineCount{Operator: "="}
+	for _, operator := range sizeOperators {
+		if rest, ok := strings.CutPrefix(text, operator); ok {
+			count.Operator, text = operator, strings.TrimSpace(rest)
+			break
+		}
+	}
+	var err error
+	if count.Count, err = strconv.ParseInt(text, 10, 64); err != nil || count.Count < 0 {
+		return nil, fmt.Errorf("failed to parse line count %q as non-negative number", text)
+	}
+	return count, nil
+}
+
+// checkLineCount compares the number of the lines of the output, split the
+// same way as [toLines] does, with the expected one.
+func checkLineCount(name string, want *LineCount, got, spool string) []mismatch {
+	if want == nil {
+		return nil
+	}
+	var output io.Reader = strings.NewReader(got)
+	if spool != "" {
+		file, err := os.Open(spool)
+		if err != nil {
+			return []mismatch{{message: fmt.Sprintf("Failed to read spooled %s: %s", name, err)}}
+		}
+		defer file.Close()
+		output = file
+	}
+	var lines int64
+	reader := bufio.NewReader(output)
+	for {
+		if _, ok := readSpoolLine(reader); !ok {
+			break
+		}
+		lines++
+	}
+	if compareSize(lines, want.Operator, want.Count) {
+		return nil
+	}
+	return []mismatch{{
+		message: fmt.Sprintf("Failed to match line count of %s: want %s%d, got %d", name, want.Operator, want.Count, lines),
+	}}
+}
@@ -0,0 +1,41 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteStdoutLines(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:seq 1 100; printf 'a\nb' >&2
+--stdout-lines: 100
+--stderr-lines: >=2
+`)
+}
+
+func TestExecuteStdoutLinesFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:seq 1 3; echo err >&2
+--stdout-lines: <3
+--stderr-lines: 0
+`, exectest.WithOutputSpool(t.TempDir()))
+	})
+
+	assertFailed(t, fake,
+		"Failed to match line count of stdout: want <3, got 3",
+		"Failed to match line count of stderr: want =0, got 1",
+	)
+}
+
+func TestExecuteStdoutLinesWithStdout(t *testing.T) {
+	exectest.Execute(t, "echo", `
+--arg:one
+--stdout-lines: 1
+--stdout
+one
+`)
+}
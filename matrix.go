@@ -0,0 +1,108 @@
+package exectest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Binary is a named binary for [ExecuteMatrix].
+type Binary struct {
+	// Name of the subtest, the Path is used if empty.
+	Name string
+	// Path to the binary.
+	Path string
+}
+
+// ExecuteMatrix runs the same scheme against every binary as a subtest.
+//
+// Example:
+//
+//	exectest.ExecuteMatrix(t, []exectest.Binary{
+//		{Name: "v1", Path: "./bin/tool-v1"},
+//		{Name: "v2", Path: "./bin/tool-v2"},
+//	}, scheme)
+func ExecuteMatrix(t *testing.T, binaries []Binary, scheme string, opts ...Option) {
+	t.Helper()
+	for _, binary := range binaries {
+		name := binary.Name
+		if name == "" {
+			name = binary.Path
+		}
+		path := binary.Path
+		t.Run(name, func(t *testing.T) {
+			Execute(t, path, scheme, opts...)
+		})
+	}
+}
+
+// matrixPrefix defines the values of the placeholder the scheme is run with,
+// the scheme is run for every combination of the values of the matrices.
+const matrixPrefix = "--matrix:"
+
+// matrixAxisPattern matches the `NAME=value,...` value of the `--matrix:`
+// directive.
+var matrixAxisPattern = regexp.MustCompile(`^(\w+)=(.*)$`)
+
+// matrixAxis is the `--matrix:NAME=value,...` directive.
+type matrixAxis struct {
+	name   string
+	values []string
+}
+
+// matrixScheme is the scheme of a combination of the matrix values.
+type matrixScheme struct {
+	name string
+	text string
+}
+
+// splitMatrix returns the scheme without the `--matrix:` lines and the axes
+// of the lines.
+func splitMatrix(scheme string) (string, []matrixAxis, error) {
+	var rest strings.Builder
+	var axes []matrixAxis
+	names := make(map[string]bool)
+	var heredoc heredocBody
+	for i, line := range toLines(scheme) {
+		axisText, ok := strings.CutPrefix(line, matrixPrefix)
+		if heredoc.inside(line) || !ok {
+			rest.WriteString(line)
+			continue
+		}
+		match := matrixAxisPattern.FindStringSubmatch(strings.TrimSpace(axisText))
+		if match == nil || len(splitList(match[2])) == 0 {
+			return "", nil, newParseError(i+1, line, fmt.Errorf("malformed --matrix %q, expected NAME=value,...", strings.TrimSpace(axisText)))
+		}
+		if names[match[1]] {
+			return "", nil, newParseError(i+1, line, fmt.Errorf("matrix %q is defined twice", match[1]))
+		}
+		names[match[1]] = true
+		axes = append(axes, matrixAxis{name: match[1], values: splitList(match[2])})
+	}
+	return rest.String(), axes, nil
+}
+
+// expandMatrix returns the schemes of every combination of the values of the
+// axes with the `{NAME}` placeholders replaced, the names of the schemes are
+// the `NAME=value` pairs joined with commas.
+func expandMatrix(scheme string, axes []matrixAxis) []matrixScheme {
+	schemes := []matrixScheme{{text: scheme}}
+	for _, axis := range axes {
+		var expanded []matrixScheme
+		for _, s := range schemes {
+			for _, value := range axis.values {
+				name := axis.name + "=" + value
+				if s.name != "" {
+					name = s.name + "," + name
+				}
+				expanded = append(expanded, matrixScheme{
+					name: name,
+					text: strings.ReplaceAll(s.text, "{"+axis.name+"}", value),
+				})
+			}
+		}
+		schemes = expanded
+	}
+	return schemes
+}
@@ -0,0 +1,89 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteMatrix(t *testing.T) {
+	exectest.ExecuteMatrix(t, []exectest.Binary{
+		{Name: "cat", Path: "cat"},
+		{Name: "tee", Path: "tee"},
+		{Path: "head"},
+	}, `
+--stdin
+hello
+--stdout
+hello
+`)
+}
+
+func TestExecuteSchemeMatrix(t *testing.T) {
+	exectest.Execute(t, "sh", `--matrix:FORMAT=json,yaml
+--matrix:LEVEL=1, 2
+--file:config.{FORMAT}
+level {LEVEL}
+--arg:-c
+--arg:cat config.*; echo {FORMAT}
+--stdout
+level {LEVEL}
+{FORMAT}
+`)
+}
+
+func TestExecuteSchemeMatrixWithoutSubtests(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `--matrix:FORMAT=json,yaml
+--arg:{FORMAT}
+--stdout
+json
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching stdout")
+	if !strings.Contains(strings.Join(fake.logs, "\n"), "Matrix FORMAT=yaml:") {
+		t.Errorf("Want the matrix values logged, got %q", fake.logs)
+	}
+}
+
+func TestExecuteSchemeMatrixErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		scheme string
+		want   string
+	}{
+		"malformed": {"--matrix:FORMAT\n", `Failed to parse scheme: line 1 "--matrix:FORMAT": malformed --matrix "FORMAT", expected NAME=value,...`},
+		"no values": {"--matrix:FORMAT=\n", `malformed --matrix "FORMAT="`},
+		"twice":     {"--matrix:A=1\n--matrix:A=2\n", `line 2 "--matrix:A=2": matrix "A" is defined twice`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake := runFake(t, func(tb testing.TB) {
+				exectest.Execute(tb, "echo", tc.scheme)
+			})
+
+			assertFailed(t, fake, tc.want)
+		})
+	}
+}
+
+func TestExecuteDirMatrixSubtests(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "matrix.txt"), `--matrix:FORMAT=json,yaml
+--arg:{FORMAT}
+--stdout
+{FORMAT}
+`)
+
+	var names []string
+	exectest.ExecuteDir(t, "echo", dir, exectest.WithAfterRun(func(tb testing.TB, _ exectest.Run) {
+		names = append(names, tb.Name())
+	}))
+
+	want := []string{t.Name() + "/matrix.txt/FORMAT=json", t.Name() + "/matrix.txt/FORMAT=yaml"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Want the matrix values run as subtests %q, got %q", want, names)
+	}
+}
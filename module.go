@@ -0,0 +1,46 @@
+package exectest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// FromModuleRoot resolves the slash separated path relative to the root of
+// the Go module of the test, the nearest parent directory of the working
+// directory containing go.mod, so the tests of any package of the module
+// refer to the binary the same way.
+//
+// Example:
+//
+//	exectest.Execute(t, exectest.FromModuleRoot(t, "bin/mytool"), scheme)
+func FromModuleRoot(t testing.TB, path string) string {
+	t.Helper()
+	root, err := moduleRoot()
+	if err != nil {
+		t.Fatalf("Failed to find module root: %s", err)
+	}
+	return filepath.Join(root, filepath.FromSlash(path))
+}
+
+// moduleRoot returns the nearest parent directory of the working directory
+// containing go.mod.
+func moduleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("go.mod is not found in the working directory and its parents")
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,51 @@
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestFromModuleRoot(t *testing.T) {
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %s", err)
+	}
+	if err := os.Chdir(filepath.Join("testdata", "golden", "sub")); err != nil {
+		t.Fatalf("Failed to change directory: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(root); err != nil {
+			t.Errorf("Failed to restore directory: %s", err)
+		}
+	})
+
+	got := exectest.FromModuleRoot(t, "testdata/golden/a.txt")
+
+	if want := filepath.Join(root, "testdata", "golden", "a.txt"); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestFromModuleRootMissing(t *testing.T) {
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %s", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change directory: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(root); err != nil {
+			t.Errorf("Failed to restore directory: %s", err)
+		}
+	})
+
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.FromModuleRoot(tb, "bin/mytool")
+	})
+
+	assertFailed(t, fake, "Failed to find module root: go.mod is not found")
+}
@@ -0,0 +1,34 @@
+package exectest
+
+import (
+	"strings"
+	"testing"
+)
+
+// reportSchemeName reports the `--name:` of the failed scheme before its
+// mismatches, so the failures read as the specification of the binary.
+func reportSchemeName(t testing.TB, scheme *Scheme) {
+	t.Helper()
+	if scheme.Name != "" {
+		t.Errorf("Failed scheme %q:", scheme.Name)
+	}
+}
+
+// schemeName returns the `--name:` of the scheme shared by its cases, if
+// any. The scheme isn't validated, the directive is found the same way as
+// the cases are.
+func schemeName(scheme string) string {
+	var heredoc heredocBody
+	for _, line := range toLines(scheme) {
+		if heredoc.inside(line) {
+			continue
+		}
+		if strings.HasPrefix(line, casePrefix) {
+			break
+		}
+		if name, ok := strings.CutPrefix(line, namePrefix); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return ""
+}
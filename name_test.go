@@ -0,0 +1,64 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteDirSchemeName(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "empty.txt"), `--name: rejects empty config
+--arg:-c
+--arg:echo "empty config" >&2; exit 1
+--stderr
+empty config
+--return-code: 1
+`)
+	writeFile(t, filepath.Join(dir, "plain.txt"), `--arg:-c
+--arg:true
+`)
+
+	var out strings.Builder
+	t.Run("dir", func(t *testing.T) {
+		exectest.ExecuteDir(t, "sh", dir, exectest.WithTAP(&out))
+	})
+
+	want := `TAP version 13
+1..2
+ok 1 - rejects empty config
+ok 2 - plain.txt
+`
+	if got := out.String(); got != want {
+		t.Errorf("Unexpected TAP output:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestExecuteSchemeNameFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `--name: greets the gopher
+--arg:hello
+--stdout
+hello, gopher
+`)
+	})
+
+	assertFailed(t, fake, `Failed scheme "greets the gopher":`, "Failed matching stdout")
+}
+
+func TestExecuteSchemeNameStepFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `--name: fails the second step
+--run
+--arg:-c
+--arg:true
+--run
+--arg:-c
+--arg:exit 3
+`)
+	})
+
+	assertFailed(t, fake, `Failed scheme "fails the second step":`, "Failed to match return code")
+}
@@ -0,0 +1,20 @@
+package exectest
+
+import "errors"
+
+// errNetworkIsolationUnsupported is returned by isolateNetwork on platforms
+// without network namespaces.
+var errNetworkIsolationUnsupported = errors.New("network isolation is not supported on this platform")
+
+// WithNetworkIsolation runs the binary without network access, not even the
+// loopback interface is up, to assert the binary works offline or fails
+// cleanly without the network.
+//
+// It uses a new network namespace on Linux, in a new user namespace for
+// unprivileged users. The scheme is skipped if the namespaces are not
+// available.
+func WithNetworkIsolation() Option {
+	return func(c *config) {
+		c.networkIsolation = true
+	}
+}
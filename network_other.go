@@ -0,0 +1,13 @@
+//go:build !linux
+
+package exectest
+
+import "os/exec"
+
+func isolateNetwork(*exec.Cmd) error {
+	return errNetworkIsolationUnsupported
+}
+
+func networkIsolationFailed(error) bool {
+	return false
+}
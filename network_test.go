@@ -0,0 +1,41 @@
+package exectest_test
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteNetworkIsolation(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			fmt.Fprintln(conn, "pong")
+			conn.Close()
+		}
+	}()
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	exectest.Execute(t, exectest.Self("ping"), `
+--arg:`+port+`
+--stdout
+pong
+`)
+	exectest.Execute(t, exectest.Self("ping"), `
+--arg:`+port+`
+--return-code: 1
+--stderr
+[...]network is unreachable
+`, exectest.WithNetworkIsolation())
+}
@@ -0,0 +1,85 @@
+package exectest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	finalNewlineRequired  = "required"
+	finalNewlineForbidden = "forbidden"
+	finalNewlineIgnore    = "ignore"
+)
+
+// parseFinalNewline parses the `--final-newline:` directive, the `ignore`
+// one is the default empty one.
+func parseFinalNewline(text string) (string, error) {
+	switch text = strings.TrimSpace(text); text {
+	case finalNewlineRequired, finalNewlineForbidden:
+		return text, nil
+	case finalNewlineIgnore:
+		return "", nil
+	}
+	return "", fmt.Errorf("unknown final newline %q, expected %s, %s or %s",
+		text, finalNewlineRequired, finalNewlineForbidden, finalNewlineIgnore)
+}
+
+// checkFinalNewline checks the final newline of the non-empty outputs, the
+// combined one replaces the stdout and the stderr.
+func checkFinalNewline(want schemeResult, got executionResult) []mismatch {
+	if want.FinalNewline == "" {
+		return nil
+	}
+	type output struct{ name, data, spool string }
+	outputs := []output{{"stdout", got.Stdout, got.StdoutSpool}, {"stderr", got.Stderr, got.StderrSpool}}
+	if want.CombinedOutput {
+		outputs = []output{{"output", got.Output, got.OutputSpool}}
+	}
+	var mismatches []mismatch
+	for _, o := range outputs {
+		last, ok, err := lastByte(o.data, o.spool)
+		if err != nil {
+			mismatches = append(mismatches, mismatch{message: fmt.Sprintf("Failed to read spooled %s: %s", o.name, err)})
+			continue
+		}
+		switch {
+		case !ok:
+		case want.FinalNewline == finalNewlineRequired && last != '\n':
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match final newline of %s: want required, got %q", o.name, last),
+			})
+		case want.FinalNewline == finalNewlineForbidden && last == '\n':
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match final newline of %s: want forbidden, got %q", o.name, last),
+			})
+		}
+	}
+	return mismatches
+}
+
+// lastByte returns the last byte of the output or of the spool file if set,
+// it's not ok for the empty output.
+func lastByte(data, spool string) (byte, bool, error) {
+	if spool == "" {
+		if data == "" {
+			return 0, false, nil
+		}
+		return data[len(data)-1], true, nil
+	}
+	file, err := os.Open(spool)
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return 0, false, err
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil && err != io.EOF {
+		return 0, false, err
+	}
+	return last[0], true, nil
+}
@@ -0,0 +1,67 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteFinalNewlineRequired(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo out; echo err >&2
+--final-newline: required
+--stdout
+out
+--stderr
+err
+`)
+}
+
+func TestExecuteFinalNewlineForbidden(t *testing.T) {
+	exectest.Execute(t, "printf", `
+--arg:out
+--final-newline: forbidden
+--stdout
+out
+`)
+}
+
+func TestExecuteFinalNewlineFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:printf out; printf 'err\n' >&2
+--final-newline: required
+--stdout
+out
+--stderr
+err
+`)
+	})
+
+	assertFailed(t, fake, `Failed to match final newline of stdout: want required, got 't'`)
+	if len(fake.errors) != 1 {
+		t.Errorf("Expected only stdout to fail, got:\n%q", fake.errors)
+	}
+
+	fake = runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `
+--arg:out
+--final-newline: forbidden
+--output
+out
+`, exectest.WithOutputSpool(t.TempDir()))
+	})
+
+	assertFailed(t, fake, `Failed to match final newline of output: want forbidden, got '\n'`)
+}
+
+func TestExecuteFinalNewlineIgnore(t *testing.T) {
+	exectest.Execute(t, "printf", `
+--arg:out
+--final-newline: ignore
+--stdout
+out
+`)
+}
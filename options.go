@@ -0,0 +1,162 @@
+package exectest
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Option configures the execution of a scheme: it's either one of the With
+// options or a func(*exec.Cmd) modifying the command right before the
+// execution, the same as [WithCmd] does.
+type Option interface{}
+
+type config struct {
+	cmdOpts    []func(*exec.Cmd)
+	variables  map[string]func(dir string) string
+	tagFilter  tagFilter
+	parallel   int
+	coverage   bool
+	coverDir   string
+	reporters  []reporter
+	pty        *TerminalSize
+	spoolDir   string
+	diff       DiffOption
+	diffFormat DiffFormat
+	cmpOptions []cmp.Option
+	stripANSI  bool
+	concurrent int
+	// hermeticEnv pins the environment, see [WithHermeticEnv].
+	hermeticEnv bool
+	// fakeHome isolates the HOME, see [WithFakeHome].
+	fakeHome bool
+	// networkIsolation runs the binary offline, see [WithNetworkIsolation].
+	networkIsolation bool
+	// runner runs the binary instead of the local exec, see [WithRunner].
+	runner Runner
+	// keepDir keeps the directories of failed tests, see [WithKeepDirOnFailure].
+	keepDir bool
+	// strictScheme rejects unknown directives, see [WithStrictScheme].
+	strictScheme bool
+	// processGroup is enabled by default, see [WithProcessGroup].
+	processGroup bool
+	// cassette records or replays the external commands, see [WithCassette].
+	cassette *cassette
+	// path is the PATH of the binary if hasPath, see [WithPath].
+	path    []string
+	hasPath bool
+	// fifos serve the named pipes, see [WithFIFOFeed] and [WithFIFODrain].
+	fifos []fifoHandler
+	// afterRun hooks are called once the binary exits, see [WithAfterRun].
+	afterRun []func(t testing.TB, run Run)
+	// resultCache skips the passed schemes, see [WithResultCache].
+	resultCache bool
+	// trace logs the executions, see [WithTrace].
+	trace bool
+	// dryRun logs the executions instead of running them, see [WithDryRun].
+	dryRun bool
+	// maxDiffLines limits the diffs of the failures, see [WithMaxDiffLines].
+	maxDiffLines int
+	// colorDiff colors the diffs on the terminals, see [WithColorDiff].
+	colorDiff bool
+	// seed of the random placeholders if hasSeed, see [WithSeed].
+	seed    int64
+	hasSeed bool
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		variables:    make(map[string]func(dir string) string),
+		processGroup: true,
+	}
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case func(*config):
+			opt(cfg)
+		case func(*exec.Cmd):
+			cfg.cmdOpts = append(cfg.cmdOpts, opt)
+		default:
+			panic(fmt.Sprintf("exectest: unsupported option %T", opt))
+		}
+	}
+	return cfg
+}
+
+// WithCmd modifies the [exec.Cmd] right before the execution.
+//
+// Example:
+//
+//	exectest.WithCmd(func(c *exec.Cmd) {
+//		c.Env = append(os.Environ(), "TEST_VAR=value123")
+//	})
+func WithCmd(opt func(*exec.Cmd)) Option {
+	return func(c *config) {
+		c.cmdOpts = append(c.cmdOpts, opt)
+	}
+}
+
+// WithVariable registers a custom placeholder `{name}`.
+//
+// The placeholder is expanded everywhere the `{dir}` one is: arguments,
+// environment, file contents and expected outputs. The resolve function
+// receives the scheme directory.
+//
+// Example:
+//
+//	exectest.WithVariable("fixture", func(dir string) string {
+//		return filepath.Join(dir, "fixture.json")
+//	})
+func WithVariable(name string, resolve func(dir string) string) Option {
+	return func(c *config) {
+		c.variables[name] = resolve
+	}
+}
+
+// WithTagFilter runs only the schemes with tags matching the filter, others
+// are skipped.
+//
+// The filter is a comma separated list of tags, the tags prefixed with `!`
+// are excluded. A scheme matches if it has none of the excluded tags and at
+// least one of the included ones, if any.
+//
+// The filter from the EXECTEST_TAGS environment variable is applied as well.
+//
+// Example:
+//
+//	exectest.WithTagFilter("!network,!slow")
+func WithTagFilter(filter string) Option {
+	return func(c *config) {
+		c.tagFilter = parseTagFilter(filter)
+	}
+}
+
+// WithParallel runs the schemes of [ExecuteDir] as parallel subtests with at
+// most n binaries executed at the same time.
+func WithParallel(n int) Option {
+	return func(c *config) {
+		c.parallel = n
+	}
+}
+
+// WithCmpOptions passes the options to [cmp.Diff] comparing the outputs with
+// the expected ones, e.g. transformers or comparers. The outputs are compared
+// as slices of lines, every line ends with the newline.
+//
+// Example:
+//
+//	exectest.WithCmpOptions(cmp.Transformer("trim", strings.TrimSpace))
+func WithCmpOptions(opts ...cmp.Option) Option {
+	return func(c *config) {
+		c.cmpOptions = append(c.cmpOptions, opts...)
+	}
+}
+
+// WithPTY runs the binary attached to a pseudo-terminal of the size, the same
+// as the `--pty` directive does.
+func WithPTY(rows, cols int) Option {
+	return func(c *config) {
+		c.pty = &TerminalSize{Rows: rows, Cols: cols}
+	}
+}
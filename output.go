@@ -0,0 +1,108 @@
+package exectest
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// outputBuffer captures the output of a running process and lets to wait for
+// the content to appear.
+type outputBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+	// spool is the file the output is written to instead of the buf, if set.
+	spool *os.File
+	size  int
+	// changed is closed and replaced on every write.
+	changed chan struct{}
+}
+
+func newOutputBuffer() *outputBuffer {
+	return &outputBuffer{changed: make(chan struct{})}
+}
+
+// newSpooledOutput captures the output to the file keeping nothing in memory.
+func newSpooledOutput(spool *os.File) *outputBuffer {
+	return &outputBuffer{spool: spool, changed: make(chan struct{})}
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(p)
+	if b.spool != nil {
+		var err error
+		n, err = b.spool.Write(p)
+		b.size += n
+		if err != nil {
+			return n, err
+		}
+	} else {
+		b.buf.Write(p)
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+	return n, nil
+}
+
+// String is the captured output, empty for the spooled one.
+func (b *outputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// spoolName is the name of the spool file, empty if the output isn't spooled.
+func (b *outputBuffer) spoolName() string {
+	if b.spool == nil {
+		return ""
+	}
+	return b.spool.Name()
+}
+
+// from returns the output written after the offset, must be called with the
+// mu locked.
+func (b *outputBuffer) from(offset int) string {
+	if b.spool == nil {
+		return b.buf.String()[offset:]
+	}
+	content := make([]byte, b.size-offset)
+	n, _ := b.spool.ReadAt(content, int64(offset))
+	return string(content[:n])
+}
+
+// waitFor waits for the text to appear in the output after the offset and
+// returns the offset right after the text. It gives up after the timeout, if
+// positive, or once the process is exited without the text written.
+func (b *outputBuffer) waitFor(text string, offset int, timeout time.Duration, exited <-chan struct{}) (int, bool) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	var isExited bool
+	for {
+		b.mu.Lock()
+		index := strings.Index(b.from(offset), text)
+		changed := b.changed
+		b.mu.Unlock()
+		if index >= 0 {
+			return offset + index + len(text), true
+		}
+		if isExited {
+			return offset, false
+		}
+
+		select {
+		case <-changed:
+		case <-exited:
+			// the output is captured completely, check it the last time.
+			isExited = true
+		case <-expired:
+			return offset, false
+		}
+	}
+}
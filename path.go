@@ -0,0 +1,62 @@
+package exectest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// SystemPath stands for the directories of the PATH of the test process in
+// the [WithPath] directories.
+const SystemPath = "$PATH"
+
+// WithPath sets the PATH of the binary to the dirs joined with the separator
+// of the OS, so the helper binaries resolved by the binary are the expected
+// ones. The relative dirs are resolved against the working directory of the
+// test. The [SystemPath] dir expands to the PATH of the test process:
+//
+//	exectest.Execute(t, binary, scheme, exectest.WithPath("testdata/bin", exectest.SystemPath))
+//
+// The `--env:PATH=` directive overrides it, the `--stub` blocks and
+// [WithCassette] are prepended to it.
+func WithPath(dirs ...string) Option {
+	return func(c *config) {
+		c.path = dirs
+		c.hasPath = true
+	}
+}
+
+// binaryPath returns the PATH of the binary before the stubs are prepended.
+func binaryPath(t testing.TB, cfg *config) string {
+	t.Helper()
+	if !cfg.hasPath {
+		return os.Getenv("PATH")
+	}
+	dirs := make([]string, 0, len(cfg.path))
+	for _, dir := range cfg.path {
+		switch {
+		case dir == SystemPath:
+			if system := os.Getenv("PATH"); system != "" {
+				dirs = append(dirs, system)
+			}
+			continue
+		case !filepath.IsAbs(dir):
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				t.Fatalf("Failed to resolve PATH directory %s: %s", dir, err)
+			}
+			dir = abs
+		}
+		dirs = append(dirs, dir)
+	}
+	return strings.Join(dirs, string(os.PathListSeparator))
+}
+
+// prependPath returns the PATH entry of the dir prepended to the path.
+func prependPath(dir, path string) string {
+	if path == "" {
+		return "PATH=" + dir
+	}
+	return "PATH=" + dir + string(os.PathListSeparator) + path
+}
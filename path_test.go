@@ -0,0 +1,57 @@
+//go:build unix
+
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func writeGreet(t *testing.T) string {
+	t.Helper()
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "greet"), []byte("#!/bin/sh\necho \"hello $1\"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write greet: %s", err)
+	}
+	return bin
+}
+
+func TestExecuteWithPath(t *testing.T) {
+	bin := writeGreet(t)
+
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:greet world; echo "$PATH"
+--stdout
+hello world
+{bin}
+`, exectest.WithPath(bin), exectest.WithVariable("bin", func(string) string { return bin }))
+}
+
+func TestExecuteWithPathSystemPath(t *testing.T) {
+	bin := writeGreet(t)
+
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:greet "$(basename /tmp/world)"
+--stdout
+hello world
+`, exectest.WithPath(bin, exectest.SystemPath))
+}
+
+func TestExecuteWithPathStub(t *testing.T) {
+	bin := writeGreet(t)
+
+	exectest.Execute(t, "sh", `
+--stub: git
+git version 2.0
+--arg:-c
+--arg:git; greet world
+--stdout
+git version 2.0
+hello world
+`, exectest.WithPath(bin))
+}
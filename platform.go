@@ -0,0 +1,145 @@
+package exectest
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// platformPattern matches the `--<directive>[<platform>,...]` qualifier
+// right after the name of the directive, e.g. `--stdout[linux]` or
+// `--return-code[darwin,windows]: 1`.
+var platformPattern = regexp.MustCompile(`^(--[A-Za-z0-9-]*[A-Za-z0-9])\[([^\]]*)\]`)
+
+// unixPlatforms are the GOOS values of the `unix` platform, the same as the
+// ones of the `unix` build constraint.
+var unixPlatforms = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "hurd": true, "illumos": true, "ios": true,
+	"linux": true, "netbsd": true, "openbsd": true, "solaris": true,
+}
+
+// otherPlatforms are the GOOS values not included in the `unix` platform.
+var otherPlatforms = map[string]bool{
+	"js": true, "plan9": true, "wasip1": true, "windows": true, "zos": true,
+}
+
+// cutPlatforms returns the line without the qualifier and the platforms of
+// the qualified directive line.
+func cutPlatforms(line string) (string, []string, bool) {
+	match := platformPattern.FindStringSubmatch(line)
+	if match == nil {
+		return line, nil, false
+	}
+	return match[1] + line[len(match[0]):], splitList(match[2]), true
+}
+
+// matchesPlatform reports whether the goos is one of the platforms.
+func matchesPlatform(platforms []string, goos string) bool {
+	for _, platform := range platforms {
+		if platform == goos || platform == "unix" && unixPlatforms[goos] {
+			return true
+		}
+	}
+	return false
+}
+
+// platformSelection selects the directives of the current platform: the
+// qualified ones of the other platforms are ignored, the unqualified ones
+// defined at most once are overridden by the qualified ones of the current
+// platform.
+type platformSelection struct {
+	goos string
+	// overridden are the definition names of the qualified directives of
+	// the platform.
+	overridden map[string]bool
+}
+
+// selectPlatform checks the qualifiers of the scheme section starting at
+// the firstLine and collects the directives of the current platform.
+func selectPlatform(scheme string, firstLine int) (*platformSelection, error) {
+	selection := &platformSelection{goos: runtime.GOOS, overridden: make(map[string]bool)}
+	var heredoc heredocBody
+	for i, line := range toLines(scheme) {
+		if heredoc.inside(line) {
+			continue
+		}
+		unqualified, platforms, ok := cutPlatforms(line)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(unqualified, heredocArgPrefix) {
+			return nil, newParseError(firstLine+i, line, fmt.Errorf("--arg<< can't be qualified with platforms"))
+		}
+		if len(platforms) == 0 {
+			return nil, newParseError(firstLine+i, line, fmt.Errorf("empty platform qualifier"))
+		}
+		for _, platform := range platforms {
+			if !unixPlatforms[platform] && !otherPlatforms[platform] && platform != "unix" {
+				return nil, newParseError(firstLine+i, line, fmt.Errorf("unknown platform %q", platform))
+			}
+		}
+		if name := definitionName(unqualified); name != "" && matchesPlatform(platforms, selection.goos) {
+			selection.overridden[name] = true
+		}
+	}
+	return selection, nil
+}
+
+// selected returns the line without the qualifier and whether the directive
+// of the line is used on the platform.
+func (s *platformSelection) selected(line string) (string, bool) {
+	if unqualified, platforms, ok := cutPlatforms(line); ok {
+		return unqualified, matchesPlatform(platforms, s.goos)
+	}
+	if name := definitionName(line); name != "" && s.overridden[name] {
+		return line, false
+	}
+	return line, true
+}
+
+// blockPrefixes are the directives starting the blocks, the lines of the
+// block of an ignored directive are ignored too.
+var blockPrefixes = []string{
+	stdoutPrefix, stderrPrefix, stdinPrefix, filePrefix, fifoPrefix, stubPrefix,
+	interactPrefix, outputPrefix, expectTreePrefix, expectChangesPrefix,
+}
+
+// nonBlockPrefixes are the directives sharing the prefixes of the blocks
+// without starting one.
+var nonBlockPrefixes = []string{
+	stdoutLinesPrefix, stderrLinesPrefix, stdoutEmptyPrefix, stderrEmptyPrefix,
+	stdoutAnyPrefix, stderrAnyPrefix, stdinGeneratePrefix,
+}
+
+// startsBlock reports whether the directive line starts a block.
+func startsBlock(line string) bool {
+	for _, prefix := range nonBlockPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range blockPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifyLike puts the qualifier of the original line into the directive
+// line formatted for it.
+func qualifyLike(formatted, original string) string {
+	match := platformPattern.FindStringSubmatch(original)
+	if match == nil {
+		return formatted
+	}
+	name, rest, _ := strings.Cut(formatted, " ")
+	name, colon := strings.CutSuffix(name, ":")
+	qualified := name + "[" + match[2] + "]"
+	if colon {
+		qualified += ":"
+	}
+	return qualified + " " + rest
+}
@@ -0,0 +1,97 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+// forPlatform replaces the `GOOS` of the scheme with the current platform.
+func forPlatform(scheme string) string {
+	return strings.ReplaceAll(scheme, "GOOS", runtime.GOOS)
+}
+
+func TestExecutePlatformBlocks(t *testing.T) {
+	exectest.Execute(t, "sh", forPlatform(`
+--arg:-c
+--arg:echo hello; exit 2
+--stdout
+generic
+--stdout[GOOS]
+hello
+--stdout[plan9,windows]
+other
+--return-code: 1
+--return-code[GOOS]: 2
+--return-code[plan9]: 3
+--stderr[plan9]
+ignored
+`))
+}
+
+func TestExecutePlatformBlocksOtherPlatform(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo hello
+--stdout
+hello
+--stdout[plan9]
+other
+--return-code[plan9]: 3
+`)
+}
+
+func TestExecutePlatformBlocksUnix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The unix platform doesn't match windows")
+	}
+	exectest.Execute(t, "echo", `
+--arg:hello
+--stdout
+generic
+--stdout[unix]
+hello
+`)
+}
+
+func TestExecutePlatformBlocksStrict(t *testing.T) {
+	exectest.Execute(t, "echo", forPlatform(`
+--arg:hello
+--stdout[GOOS]
+hello
+--return-code[plan9]: 3
+`), exectest.WithStrictScheme())
+}
+
+func TestExecuteForFileUpdatePlatformBlocks(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, forPlatform(`--arg:-c
+--arg:echo out; exit 3
+--stdout
+generic
+--stdout[GOOS]
+stale
+--stdout[plan9]
+other
+--return-code[GOOS]: 1
+--return-code[plan9]: 2
+`))
+
+	exectest.ExecuteForFile(t, "sh", file)
+
+	assertFileContent(t, file, forPlatform(`--arg:-c
+--arg:echo out; exit 3
+--stdout
+generic
+--stdout[GOOS]
+out
+--stdout[plan9]
+other
+--return-code[GOOS]: 3
+--return-code[plan9]: 2
+`))
+}
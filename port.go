@@ -0,0 +1,64 @@
+package exectest
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// portPattern matches the `{port}` and `{port:NAME}` placeholders.
+var portPattern = regexp.MustCompile(`\{port(?::([A-Za-z0-9_-]+))?\}`)
+
+var (
+	allocatedMu sync.Mutex
+	// allocated are the ports given out by the process, they aren't reused
+	// even if the system offers them again.
+	allocated = make(map[int]bool)
+)
+
+// ports are the `{port}` placeholders of a scheme, every name gets its own
+// free localhost port on the first use.
+type ports struct {
+	t      testing.TB
+	mu     sync.Mutex
+	byName map[string]string
+}
+
+func newPorts(t testing.TB) *ports {
+	return &ports{t: t, byName: make(map[string]string)}
+}
+
+func (p *ports) replace(data string) string {
+	return portPattern.ReplaceAllStringFunc(data, func(placeholder string) string {
+		name := portPattern.FindStringSubmatch(placeholder)[1]
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if port, ok := p.byName[name]; ok {
+			return port
+		}
+		port := strconv.Itoa(freePort(p.t))
+		p.byName[name] = port
+		return port
+	})
+}
+
+// freePort finds a localhost TCP port nobody listens on.
+func freePort(t testing.TB) int {
+	t.Helper()
+	allocatedMu.Lock()
+	defer allocatedMu.Unlock()
+	for {
+		listener, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatalf("Failed to allocate free port: %s", err)
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+		_ = listener.Close()
+		if !allocated[port] {
+			allocated[port] = true
+			return port
+		}
+	}
+}
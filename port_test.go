@@ -0,0 +1,35 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecutePortPlaceholder(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo "$PORT $1 $2"; cat config.txt
+--arg:sh
+--arg:{port}
+--arg:{port:other}
+--env:PORT={port}
+--file:config.txt
+listen={port:other}
+--stdout
+{port} {port} {port:other}
+listen={port:other}
+`)
+}
+
+func TestExecutePortPlaceholderDistinct(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `
+--arg:{port:a}
+--stdout
+{port:b}
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching stdout")
+}
@@ -0,0 +1,6 @@
+package exectest
+
+import "errors"
+
+// errPTYUnsupported is returned by runInPTY on platforms without PTY support.
+var errPTYUnsupported = errors.New("pseudo-terminal is not supported on this platform")
@@ -0,0 +1,140 @@
+package exectest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// runInPTY runs the cmd attached to a new pseudo-terminal of the size, the
+// stdin is typed to the terminal by the feed, followed by the end of file,
+// and the watchers are running along.
+// The combined output of the terminal is written to the cmd.Stdout, which ends
+// up in the output.
+func runInPTY(cmd *exec.Cmd, size TerminalSize, output *outputBuffer, feed feeder, watchers []watcher) ([]string, error) {
+	master, slave, err := openPTY(size)
+	if err != nil {
+		return nil, err
+	}
+	defer master.Close()
+
+	// the terminal output goes to the writer set up for the stdout.
+	sink := cmd.Stdout
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// the new session is a new process group as well.
+	cmd.SysProcAttr.Setpgid = false
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+
+	err = cmd.Start()
+	slave.Close()
+	if err != nil {
+		return nil, &startError{err}
+	}
+
+	exited := make(chan struct{})
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		// reading fails with EIO once all the terminal users are gone.
+		_, _ = io.Copy(sink, master)
+	}()
+	go func() {
+		defer close(exited)
+		// this is intentional, we will assert exit code manually
+		_ = cmd.Wait()
+		<-copied
+	}()
+	waitWatchers := startWatchers(watchers, cmd.Process, output, exited)
+
+	stdin := &lastByteWriter{w: master}
+	failures := feed(stdin, output, exited)
+	if len(failures) > 0 {
+		_ = cmd.Process.Kill()
+	}
+	// the end of file is recognized only at the beginning of a line.
+	if stdin.last != 0 && stdin.last != '\n' {
+		_, _ = io.WriteString(master, "\x04")
+	}
+	_, _ = io.WriteString(master, "\x04")
+
+	<-exited
+	return append(failures, waitWatchers()...), nil
+}
+
+// lastByteWriter remembers the last written byte.
+type lastByteWriter struct {
+	w    io.Writer
+	last byte
+}
+
+func (w *lastByteWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.last = p[len(p)-1]
+	}
+	return w.w.Write(p)
+}
+
+func openPTY(size TerminalSize) (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			master.Close()
+		}
+	}()
+
+	var unlock int32
+	if err := ioctl(master, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		return nil, nil, fmt.Errorf("failed to unlock pty: %w", err)
+	}
+	var number uint32
+	if err := ioctl(master, syscall.TIOCGPTN, unsafe.Pointer(&number)); err != nil {
+		return nil, nil, fmt.Errorf("failed to get pty number: %w", err)
+	}
+	slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(number)), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			slave.Close()
+		}
+	}()
+
+	winsize := struct{ rows, cols, x, y uint16 }{rows: uint16(size.Rows), cols: uint16(size.Cols)}
+	if err := ioctl(slave, syscall.TIOCSWINSZ, unsafe.Pointer(&winsize)); err != nil {
+		return nil, nil, fmt.Errorf("failed to set pty size: %w", err)
+	}
+	// the typed stdin is not echoed, so the output contains only what the
+	// binary has written.
+	var termios syscall.Termios
+	if err := ioctl(slave, syscall.TCGETS, unsafe.Pointer(&termios)); err != nil {
+		return nil, nil, fmt.Errorf("failed to get pty attributes: %w", err)
+	}
+	termios.Lflag &^= syscall.ECHO
+	if err := ioctl(slave, syscall.TCSETS, unsafe.Pointer(&termios)); err != nil {
+		return nil, nil, fmt.Errorf("failed to set pty attributes: %w", err)
+	}
+	return master, slave, nil
+}
+
+func ioctl(f *os.File, request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), request, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package exectest
+
+import "os/exec"
+
+func runInPTY(*exec.Cmd, TerminalSize, *outputBuffer, feeder, []watcher) ([]string, error) {
+	return nil, errPTYUnsupported
+}
@@ -0,0 +1,74 @@
+//go:build linux
+
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecutePTYIsTerminal(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--pty
+--arg:-c
+--arg:test -t 0 && test -t 1 && echo out && echo err >&2; exit 3
+--stdout
+out
+err
+--return-code: 3
+`)
+}
+
+func TestExecutePTYSize(t *testing.T) {
+	exectest.Execute(t, "stty", `
+--pty: 40x120
+--arg:size
+--stdout
+40 120
+`)
+}
+
+func TestExecutePTYStdin(t *testing.T) {
+	exectest.Execute(t, "cat", `
+--pty
+--stdin
+typed
+no newline
+--stdout
+typed
+no newline
+`)
+}
+
+func TestExecuteWithPTY(t *testing.T) {
+	exectest.Execute(t, "stty", `
+--arg:size
+--stdout
+10 20
+`, exectest.WithPTY(10, 20))
+}
+
+func TestExecutePTYInteract(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--pty
+--arg:-c
+--arg:test -t 0 && printf "Password: " && read password && echo "got $password"
+--interact
+expect: Password:
+send: secret
+--stdout
+Password: got secret
+`)
+}
+
+func TestExecutePTYStripANSI(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--pty
+--strip-ansi
+--arg:-c
+--arg:printf '\033[32mgreen\033[0m\n'
+--stdout
+green
+`)
+}
@@ -0,0 +1,120 @@
+package exectest
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+var seedFlag = flag.Int64("exectest.seed", 0, "seed of the {rand} and {uuid} placeholders")
+
+// randomPattern matches the `{rand}`, `{rand:NAME}`, `{uuid}` and
+// `{uuid:NAME}` placeholders.
+var randomPattern = regexp.MustCompile(`\{(rand|uuid)(?::([A-Za-z0-9_-]+))?\}`)
+
+// randomAlphabet are the characters of the `{rand}` tokens, they are safe
+// for the file, host and resource names.
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// randomTokenSize is the length of the `{rand}` tokens.
+const randomTokenSize = 12
+
+// WithSeed makes the `{rand}` and `{uuid}` placeholders reproducible, the
+// same as the -exectest.seed flag does. The values are derived from the seed
+// and the name of the test, so the tests still get the different ones.
+// Without the seed a random one is used and logged by the tests using the
+// placeholders.
+func WithSeed(seed int64) Option {
+	return func(c *config) {
+		c.seed = seed
+		c.hasSeed = true
+	}
+}
+
+// randoms are the `{rand}` and `{uuid}` placeholders of a scheme, every
+// name gets its own value on the first use.
+type randoms struct {
+	t       testing.TB
+	seed    int64
+	hasSeed bool
+	mu      sync.Mutex
+	rng     *rand.Rand
+	byName  map[string]string
+}
+
+func newRandoms(t testing.TB, cfg *config) *randoms {
+	r := &randoms{t: t, seed: cfg.seed, hasSeed: cfg.hasSeed, byName: make(map[string]string)}
+	if !r.hasSeed && seedFlagSet() {
+		r.seed, r.hasSeed = *seedFlag, true
+	}
+	return r
+}
+
+// seedFlagSet reports whether the -exectest.seed flag is passed.
+func seedFlagSet() bool {
+	var set bool
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == "exectest.seed"
+	})
+	return set
+}
+
+func (r *randoms) replace(data string) string {
+	return randomPattern.ReplaceAllStringFunc(data, func(placeholder string) string {
+		match := randomPattern.FindStringSubmatch(placeholder)
+		key := match[1] + ":" + match[2]
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if value, ok := r.byName[key]; ok {
+			return value
+		}
+		var value string
+		if match[1] == "uuid" {
+			value = r.uuid()
+		} else {
+			value = r.token()
+		}
+		r.byName[key] = value
+		return value
+	})
+}
+
+// source returns the generator seeded on the first use, the seed is logged
+// to reproduce the values with the -exectest.seed flag.
+func (r *randoms) source() *rand.Rand {
+	if r.rng != nil {
+		return r.rng
+	}
+	if !r.hasSeed {
+		r.seed = time.Now().UnixNano()
+		r.t.Logf("Random placeholders seed %d, rerun with -exectest.seed=%d to reproduce", r.seed, r.seed)
+	}
+	h := fnv.New64a()
+	h.Write([]byte(r.t.Name()))
+	r.rng = rand.New(rand.NewSource(r.seed ^ int64(h.Sum64())))
+	return r.rng
+}
+
+// token returns the `{rand}` value of the lowercase letters and digits.
+func (r *randoms) token() string {
+	rng := r.source()
+	token := make([]byte, randomTokenSize)
+	for i := range token {
+		token[i] = randomAlphabet[rng.Intn(len(randomAlphabet))]
+	}
+	return string(token)
+}
+
+// uuid returns the `{uuid}` value formatted as the version 4 UUID.
+func (r *randoms) uuid() string {
+	var b [16]byte
+	r.source().Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
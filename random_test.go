@@ -0,0 +1,78 @@
+package exectest_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteRandomPlaceholders(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo "$NAME $1"; cat config.txt
+--arg:sh
+--arg:{uuid}
+--env:NAME=bucket-{rand}
+--file:config.txt
+other={rand:other}
+--stdout
+bucket-{rand} {uuid}
+other={rand:other}
+`)
+}
+
+func TestExecuteRandomPlaceholdersFormat(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `
+--arg:{rand} {uuid}
+--stdout
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching stdout")
+	pattern := regexp.MustCompile(`[a-z0-9]{12} [0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}`)
+	if all := strings.Join(fake.errors, "\n"); !pattern.MatchString(all) {
+		t.Errorf("Want the random token and UUID in the diff, got:\n%s", all)
+	}
+	if logs := strings.Join(fake.logs, "\n"); !strings.Contains(logs, "rerun with -exectest.seed=") {
+		t.Errorf("Want the seed logged, got:\n%s", logs)
+	}
+}
+
+func TestExecuteRandomPlaceholdersDistinct(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `
+--arg:{rand:a}
+--stdout
+{rand:b}
+`)
+	})
+
+	assertFailed(t, fake, "Failed matching stdout")
+}
+
+func TestExecuteRandomPlaceholdersSeed(t *testing.T) {
+	run := func(seed int64) []string {
+		fake := runFake(t, func(tb testing.TB) {
+			exectest.Execute(tb, "echo", `
+--arg:{rand} {uuid}
+--stdout
+`, exectest.WithSeed(seed))
+		})
+		assertFailed(t, fake, "Failed matching stdout")
+		if logs := strings.Join(fake.logs, "\n"); strings.Contains(logs, "seed") {
+			t.Errorf("Want no seed logged with WithSeed, got:\n%s", logs)
+		}
+		return fake.errors
+	}
+
+	first := strings.Join(run(42), "\n")
+	if again := strings.Join(run(42), "\n"); again != first {
+		t.Errorf("Want the same values with the same seed, got:\n%s\nand:\n%s", first, again)
+	}
+	if other := strings.Join(run(7), "\n"); other == first {
+		t.Errorf("Want the different values with the different seed, got:\n%s", other)
+	}
+}
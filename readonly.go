@@ -0,0 +1,88 @@
+package exectest
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// readOnlyDir is the scheme directory made read-only for the running
+// binaries.
+type readOnlyDir struct {
+	// binaries running in the directory, the modes are restored once the
+	// last one exits.
+	binaries int
+	// modes of the files and directories before the change.
+	modes map[string]fs.FileMode
+}
+
+// readOnlyDirs are the read-only directories by their paths, the binaries
+// of the `--concurrent:` and `--run-parallel` share them.
+var readOnlyDirs = struct {
+	sync.Mutex
+	dirs map[string]*readOnlyDir
+}{dirs: make(map[string]*readOnlyDir)}
+
+// makeReadOnly removes the write permissions of the tree of the dir for the
+// `--read-only` directive: the directories become 0555 and the files lose
+// the write bits, the skip directory is left writable. The returned function
+// restores the modes.
+func makeReadOnly(dir, skip string) (func() error, error) {
+	readOnlyDirs.Lock()
+	defer readOnlyDirs.Unlock()
+	restore := func() error {
+		readOnlyDirs.Lock()
+		defer readOnlyDirs.Unlock()
+		return releaseReadOnly(dir)
+	}
+	if ro, ok := readOnlyDirs.dirs[dir]; ok {
+		ro.binaries++
+		return restore, nil
+	}
+
+	ro := &readOnlyDir{binaries: 1, modes: make(map[string]fs.FileMode)}
+	readOnlyDirs.dirs[dir] = ro
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == skip {
+			return fs.SkipDir
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		ro.modes[path] = info.Mode().Perm()
+		mode := info.Mode().Perm() &^ 0o222
+		if entry.IsDir() {
+			mode = 0o555
+		}
+		return os.Chmod(path, mode)
+	})
+	if err != nil {
+		_ = releaseReadOnly(dir)
+		return nil, err
+	}
+	return restore, nil
+}
+
+// releaseReadOnly restores the modes of the dir once no binary runs in it.
+func releaseReadOnly(dir string) error {
+	ro := readOnlyDirs.dirs[dir]
+	if ro.binaries--; ro.binaries > 0 {
+		return nil
+	}
+	delete(readOnlyDirs.dirs, dir)
+	var firstErr error
+	for path, mode := range ro.modes {
+		if err := os.Chmod(path, mode); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,55 @@
+package exectest_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteReadOnly(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--file:a.txt
+--file:sub/b.txt
+--read-only
+--arg:-c
+--arg:stat -c %a . a.txt sub sub/b.txt
+--stdout
+555
+444
+555
+444
+--expect-stat: a.txt mode=0644
+`)
+}
+
+func TestExecuteReadOnlyWrite(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Root ignores the permissions")
+	}
+	exectest.Execute(t, "sh", `
+--read-only
+--arg:-c
+--arg:echo out > out.txt 2>/dev/null || echo read-only
+--stdout
+read-only
+--expect-tree
+`)
+}
+
+func TestExecuteReadOnlySteps(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--file:a.txt
+--run
+--arg:-c
+--arg:stat -c %a a.txt
+--stdout
+644
+--run
+--read-only
+--arg:-c
+--arg:stat -c %a a.txt
+--stdout
+444
+`)
+}
@@ -0,0 +1,71 @@
+package exectest
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Record runs the binary with args and stdin in the dir and returns a scheme
+// describing the invocation: its arguments, stdin, stdout, stderr and return
+// code. Occurrences of the dir are replaced with the `{dir}` placeholder.
+//
+// The environment is inherited from the current process and is not recorded,
+// neither are files of the dir: add them to the scheme manually with the
+// `--env:` and `--file:` directives.
+//
+// The error is returned if the binary fails to start or the results contain
+// lines that would be interpreted as scheme directives.
+func Record(binary string, args []string, stdin string, dir string) (string, error) {
+	cmd := exec.Command(binary, args...)
+	var stdoutBuilder strings.Builder
+	cmd.Stdout = &stdoutBuilder
+	var stderrBuilder strings.Builder
+	cmd.Stderr = &stderrBuilder
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var exitErr *exec.ExitError
+	if err := cmd.Run(); err != nil && !errors.As(err, &exitErr) {
+		return "", fmt.Errorf("failed to run %s: %w", binary, err)
+	}
+
+	var scheme strings.Builder
+	for _, arg := range args {
+		if strings.Contains(arg, "\n") {
+			return "", fmt.Errorf("multiline argument %q can't be represented in a scheme", arg)
+		}
+		if dir != "" {
+			arg = strings.ReplaceAll(arg, dir, "{dir}")
+		}
+		scheme.WriteString(argPrefix + arg + "\n")
+	}
+	if stdin != "" {
+		block, err := formatBlock(stdin, "")
+		if err != nil {
+			return "", err
+		}
+		scheme.WriteString(stdinPrefix + "\n" + block)
+	}
+	if code := cmd.ProcessState.ExitCode(); code != 0 {
+		scheme.WriteString(formatReturnCode(code))
+	}
+	for _, output := range []struct {
+		prefix string
+		text   string
+	}{
+		{stdoutPrefix, stdoutBuilder.String()},
+		{stderrPrefix, stderrBuilder.String()},
+	} {
+		if output.text == "" {
+			continue
+		}
+		block, err := formatBlock(output.text, dir)
+		if err != nil {
+			return "", err
+		}
+		scheme.WriteString(output.prefix + "\n" + block)
+	}
+	return scheme.String(), nil
+}
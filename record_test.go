@@ -0,0 +1,50 @@
+package exectest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestRecordCapturesInvocation(t *testing.T) {
+	dir := t.TempDir()
+
+	scheme, err := exectest.Record("sh", []string{"-c", `cat; echo "$1"; echo err >&2; exit 3`, "sh", dir}, "input\n", dir)
+	if err != nil {
+		t.Fatalf("Failed to record: %s", err)
+	}
+
+	want := `--arg:-c
+--arg:cat; echo "$1"; echo err >&2; exit 3
+--arg:sh
+--arg:{dir}
+--stdin
+input
+--return-code: 3
+--stdout
+input
+{dir}
+--stderr
+err
+`
+	if scheme != want {
+		t.Errorf("Unexpected scheme:\nwant:\n%s\ngot:\n%s", want, scheme)
+	}
+
+	exectest.Execute(t, "sh", scheme)
+}
+
+func TestRecordFailsForMissingBinary(t *testing.T) {
+	_, err := exectest.Record("exectest-missing-binary", nil, "", "")
+	if err == nil {
+		t.Fatal("Expected error for missing binary")
+	}
+}
+
+func TestRecordFailsForDirectiveLikeOutput(t *testing.T) {
+	_, err := exectest.Record("echo", []string{"--stdout"}, "", "")
+	if err == nil || !strings.Contains(err.Error(), "can't be represented") {
+		t.Fatalf("Expected representation error, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package exectest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IlyasYOY/exectest/junit"
+)
+
+// caseStatus is the outcome of a scheme run by [ExecuteDir].
+type caseStatus int
+
+const (
+	casePassed caseStatus = iota
+	caseFailed
+	caseSkipped
+)
+
+// caseResult is the result of a scheme run by [ExecuteDir].
+type caseResult struct {
+	// index of the scheme in the directory.
+	index    int
+	name     string
+	status   caseStatus
+	duration time.Duration
+	// messages are failures and the skip reason reported by the scheme.
+	messages []string
+}
+
+// reporter receives results of the schemes run by [ExecuteDir].
+type reporter interface {
+	// report is called with the results of all the schemes ordered by
+	// index once they are finished, suite is the name of the parent test.
+	report(suite string, results []caseResult) error
+}
+
+// WithReport writes results of the schemes run by [ExecuteDir] to the w in the
+// JUnit XML format once all of them are finished. The suite is named after
+// the test calling [ExecuteDir].
+func WithReport(w *junit.Writer) Option {
+	return func(c *config) {
+		c.reporters = append(c.reporters, &junitReporter{w: w})
+	}
+}
+
+type junitReporter struct {
+	w *junit.Writer
+}
+
+func (r *junitReporter) report(suite string, results []caseResult) error {
+	cases := make([]junit.TestCase, 0, len(results))
+	for _, result := range results {
+		status := junit.Passed
+		switch result.status {
+		case caseFailed:
+			status = junit.Failed
+		case caseSkipped:
+			status = junit.Skipped
+		}
+		cases = append(cases, junit.TestCase{
+			Name:     result.name,
+			Status:   status,
+			Duration: result.duration,
+			Message:  strings.Join(result.messages, "\n"),
+		})
+	}
+	return r.w.WriteSuite(junit.Suite{Name: suite, Cases: cases})
+}
+
+// caseResults collects results of the schemes run concurrently.
+type caseResults struct {
+	mu      sync.Mutex
+	results []caseResult
+}
+
+func (r *caseResults) add(result caseResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+}
+
+func (r *caseResults) sorted() []caseResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := append([]caseResult(nil), r.results...)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].index < results[j].index
+	})
+	return results
+}
+
+// recordingTB records messages reported to the wrapped TB, the ones of the
+// subtests are recorded by the parent too.
+type recordingTB struct {
+	testing.TB
+	parent *recordingTB
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (r *recordingTB) record(format string, args ...any) {
+	r.mu.Lock()
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+	r.mu.Unlock()
+	if r.parent != nil {
+		r.parent.record(format, args...)
+	}
+}
+
+func (r *recordingTB) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.messages...)
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.TB.Helper()
+	r.record(format, args...)
+	r.TB.Errorf(format, args...)
+}
+
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.TB.Helper()
+	r.record(format, args...)
+	r.TB.Fatalf(format, args...)
+}
+
+func (r *recordingTB) Skipf(format string, args ...any) {
+	r.TB.Helper()
+	r.record(format, args...)
+	r.TB.Skipf(format, args...)
+}
+
+// runCase runs the scheme with the results recorded in the results.
+func runCase(t *testing.T, results *caseResults, index int, name string, fn func(t testing.TB)) {
+	t.Helper()
+	recorder := &recordingTB{TB: t}
+	start := time.Now()
+	defer func() {
+		status := casePassed
+		if t.Failed() {
+			status = caseFailed
+		} else if t.Skipped() {
+			status = caseSkipped
+		}
+		results.add(caseResult{
+			index:    index,
+			name:     name,
+			status:   status,
+			duration: time.Since(start),
+			messages: recorder.recorded(),
+		})
+	}()
+	fn(recorder)
+}
+
+// unwrapTB returns the TB wrapped by the [recordingTB] or the [goroutineTB],
+// so the methods of the [*testing.T] missing in the [testing.TB], e.g.
+// Deadline, are found.
+func unwrapTB(t testing.TB) testing.TB {
+	for {
+		switch w := t.(type) {
+		case *recordingTB:
+			t = w.TB
+		case *goroutineTB:
+			t = w.TB
+		default:
+			return t
+		}
+	}
+}
+
+// runSubtest runs the fn as the subtest of the t if it's a [*testing.T],
+// the recorded one too, and reports whether it's run.
+func runSubtest(t testing.TB, name string, fn func(t testing.TB)) bool {
+	t.Helper()
+	switch t := t.(type) {
+	case *testing.T:
+		t.Run(name, func(t *testing.T) {
+			fn(t)
+		})
+		return true
+	case *recordingTB:
+		tt, ok := t.TB.(*testing.T)
+		if !ok {
+			return false
+		}
+		tt.Run(name, func(st *testing.T) {
+			fn(&recordingTB{TB: st, parent: t})
+		})
+		return true
+	}
+	return false
+}
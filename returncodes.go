@@ -0,0 +1,31 @@
+package exectest
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseReturnCodes returns the codes of the `--return-code-any:` directive
+// separated with spaces or commas.
+func parseReturnCodes(text string) ([]int, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("--return-code-any must have return codes")
+	}
+	codes := make([]int, 0, len(fields))
+	for _, field := range fields {
+		code, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert return code %q to int: %w", field, err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// matchesReturnCodes reports whether the binary exited with any of the codes.
+func matchesReturnCodes(codes []int, got executionResult) bool {
+	return got.KilledBy == "" && slices.Contains(codes, got.ReturnCode)
+}
@@ -0,0 +1,73 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteReturnCodeAny(t *testing.T) {
+	for _, code := range []string{"0", "1"} {
+		exectest.Execute(t, "sh", `
+--arg:-c
+--arg:exit `+code+`
+--return-code-any: 0 1
+`)
+	}
+}
+
+func TestExecuteReturnCodeAnyMismatch(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:exit 2
+--return-code-any: 0, 1
+`)
+	})
+
+	assertFailed(t, fake, "Failed to match return code: want any of [0 1], got 2")
+}
+
+func TestExecuteReturnCodeAnyKilled(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:kill -9 $$
+--return-code-any: -1 0
+`)
+	})
+
+	assertFailed(t, fake, "Failed to match return code: want any of [-1 0], got -1 (killed by")
+}
+
+func TestExecuteForFileUpdateReturnCodeAny(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	dir := t.TempDir()
+	matching := filepath.Join(dir, "matching.txt")
+	writeFile(t, matching, `--arg:-c
+--arg:echo out; exit 1
+--return-code-any: 0 1
+--stdout
+stale
+`)
+	mismatching := filepath.Join(dir, "mismatching.txt")
+	writeFile(t, mismatching, `--arg:-c
+--arg:exit 3
+--return-code-any: 0 1
+`)
+
+	exectest.ExecuteForFile(t, "sh", matching)
+	exectest.ExecuteForFile(t, "sh", mismatching)
+
+	assertFileContent(t, matching, `--arg:-c
+--arg:echo out; exit 1
+--return-code-any: 0 1
+--stdout
+out
+`)
+	assertFileContent(t, mismatching, `--arg:-c
+--arg:exit 3
+--return-code: 3
+`)
+}
@@ -0,0 +1,28 @@
+package exectest
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// checkMaxRSS compares the peak resident set size of the binary with the
+// `--max-rss:` limit.
+func checkMaxRSS(want schemeResult, got executionResult) (mismatch, bool) {
+	if want.MaxRSS == 0 || got.MaxRSS <= want.MaxRSS {
+		return mismatch{}, true
+	}
+	return mismatch{
+		message: fmt.Sprintf("Failed to fit max RSS: want at most %s, used %s", formatBytes(want.MaxRSS), formatBytes(got.MaxRSS)),
+	}, false
+}
+
+// formatBytes renders the size with the largest unit of [parseBytes] it's a
+// multiple of, the bytes are added otherwise.
+func formatBytes(size uint64) string {
+	for _, u := range byteUnits {
+		if size >= u.size && size%u.size == 0 {
+			return strconv.FormatUint(size/u.size, 10) + u.suffix
+		}
+	}
+	return strconv.FormatUint(size, 10) + "B"
+}
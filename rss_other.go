@@ -0,0 +1,12 @@
+//go:build !unix
+
+package exectest
+
+import "os"
+
+// rssSupported tells the peak resident set size is measured.
+const rssSupported = false
+
+func maxRSS(*os.ProcessState) uint64 {
+	return 0
+}
@@ -0,0 +1,30 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestParseSchemeMaxRSS(t *testing.T) {
+	scheme, err := exectest.ParseScheme("--max-rss: 50MB\n")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+
+	if want := uint64(50 << 20); scheme.MaxRSS != want {
+		t.Errorf("Expected max RSS %d, got %d", want, scheme.MaxRSS)
+	}
+}
+
+func TestExecuteMaxRSS(t *testing.T) {
+	exectest.Execute(t, "true", "--max-rss: 1GB\n")
+}
+
+func TestExecuteMaxRSSExceeded(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "true", "--max-rss: 1KB\n")
+	})
+
+	assertFailed(t, fake, "Failed to fit max RSS: want at most 1KB, used")
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package exectest
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// rssSupported tells the peak resident set size is measured.
+const rssSupported = true
+
+// maxRSS returns the peak resident set size of the exited process in bytes
+// from its rusage, the Maxrss is in kilobytes except for Darwin.
+func maxRSS(state *os.ProcessState) uint64 {
+	if state == nil {
+		return 0
+	}
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || usage.Maxrss <= 0 {
+		return 0
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		return uint64(usage.Maxrss)
+	}
+	return uint64(usage.Maxrss) * 1024
+}
@@ -0,0 +1,147 @@
+package exectest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// RunFile executes the scheme file against the binary outside of `go test`,
+// e.g. by the `exectest run` command. The result line of the scheme is
+// written to the w, followed by the failures and the logs if the scheme
+// failed. It reports whether the scheme passed or is skipped.
+//
+// Example:
+//
+//	ok := exectest.RunFile(os.Stdout, "./mytool", "testdata/help.scheme")
+func RunFile(w io.Writer, binary, file string, opts ...Option) bool {
+	t := &standaloneTB{name: file}
+	start := time.Now()
+	t.run(func() {
+		ExecuteForFile(t, binary, file, opts...)
+	})
+	elapsed := time.Since(start).Seconds()
+
+	switch {
+	case t.Failed():
+		fmt.Fprintf(w, "--- FAIL: %s (%.2fs)\n", file, elapsed)
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(t.log.String(), "\n"), "\n") {
+			fmt.Fprintf(w, "    %s", line)
+		}
+		fmt.Fprintln(w)
+		return false
+	case t.Skipped():
+		fmt.Fprintf(w, "--- SKIP: %s (%.2fs)\n", file, elapsed)
+	default:
+		fmt.Fprintf(w, "--- PASS: %s (%.2fs)\n", file, elapsed)
+	}
+	return true
+}
+
+// standaloneTB is the [testing.TB] of the [RunFile], the methods not used by
+// the package are not implemented.
+type standaloneTB struct {
+	testing.TB
+
+	name string
+
+	mu       sync.Mutex
+	log      strings.Builder
+	failed   bool
+	skipped  bool
+	cleanups []func()
+}
+
+// run runs the fn and the cleanups in their own goroutines, so the Fatalf and
+// the Skipf stop them.
+func (t *standaloneTB) run(fn func()) {
+	t.goroutine(fn)
+	for {
+		t.mu.Lock()
+		if len(t.cleanups) == 0 {
+			t.mu.Unlock()
+			return
+		}
+		cleanup := t.cleanups[len(t.cleanups)-1]
+		t.cleanups = t.cleanups[:len(t.cleanups)-1]
+		t.mu.Unlock()
+		t.goroutine(cleanup)
+	}
+}
+
+func (t *standaloneTB) goroutine(fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	<-done
+}
+
+func (t *standaloneTB) Helper() {}
+
+func (t *standaloneTB) Name() string {
+	return t.name
+}
+
+func (t *standaloneTB) Logf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.log.WriteString(fmt.Sprintf(format, args...) + "\n")
+}
+
+func (t *standaloneTB) Errorf(format string, args ...any) {
+	t.Logf(format, args...)
+	t.mu.Lock()
+	t.failed = true
+	t.mu.Unlock()
+}
+
+func (t *standaloneTB) Fatalf(format string, args ...any) {
+	t.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func (t *standaloneTB) Skipf(format string, args ...any) {
+	t.Logf(format, args...)
+	t.mu.Lock()
+	t.skipped = true
+	t.mu.Unlock()
+	runtime.Goexit()
+}
+
+func (t *standaloneTB) Failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failed
+}
+
+func (t *standaloneTB) Skipped() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.skipped
+}
+
+func (t *standaloneTB) Cleanup(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cleanups = append(t.cleanups, fn)
+}
+
+func (t *standaloneTB) TempDir() string {
+	dir, err := os.MkdirTemp("", "exectest-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Errorf("Failed to remove temporary directory %s: %s", dir, err)
+		}
+	})
+	return dir
+}
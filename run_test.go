@@ -0,0 +1,59 @@
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func writeScheme(t *testing.T, scheme string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "echo.scheme")
+	if err := os.WriteFile(file, []byte(scheme), 0o644); err != nil {
+		t.Fatalf("Failed to write scheme: %s", err)
+	}
+	return file
+}
+
+func TestRunFile(t *testing.T) {
+	file := writeScheme(t, "--arg:hello\n--stdout\nhello\n")
+	var out strings.Builder
+
+	if !exectest.RunFile(&out, "echo", file) {
+		t.Fatalf("Expected the scheme to pass, got:\n%s", out.String())
+	}
+
+	if want := "--- PASS: " + file; !strings.HasPrefix(out.String(), want) {
+		t.Errorf("Expected output starting with %q, got:\n%s", want, out.String())
+	}
+}
+
+func TestRunFileFailure(t *testing.T) {
+	file := writeScheme(t, "--arg:hello\n--stdout\nbye\n")
+	var out strings.Builder
+
+	if exectest.RunFile(&out, "echo", file) {
+		t.Fatalf("Expected the scheme to fail, got:\n%s", out.String())
+	}
+
+	for _, part := range []string{"--- FAIL: " + file, "    Failed matching stdout", `"bye\n"`} {
+		if !strings.Contains(out.String(), part) {
+			t.Errorf("Expected output containing %q, got:\n%s", part, out.String())
+		}
+	}
+}
+
+func TestRunFileFatal(t *testing.T) {
+	var out strings.Builder
+
+	if exectest.RunFile(&out, "echo", filepath.Join(t.TempDir(), "missing.scheme")) {
+		t.Fatalf("Expected the scheme to fail, got:\n%s", out.String())
+	}
+
+	if !strings.Contains(out.String(), "Failed to read test file") {
+		t.Errorf("Expected the read failure, got:\n%s", out.String())
+	}
+}
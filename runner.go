@@ -0,0 +1,34 @@
+package exectest
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// Invocation is the run of the binary handed to the [Runner].
+type Invocation struct {
+	Binary string
+	Args   []string
+	// Env of the scheme as KEY=VALUE, without the environment of the test.
+	Env []string
+	// Dir is the scheme directory with the files of the scheme.
+	Dir string
+}
+
+// Runner runs the binary somewhere else than the local machine, see
+// [WithRunner].
+type Runner interface {
+	// Command returns the cmd running the invocation, it gets the stdin and
+	// the output of the scheme. The finish function is called once the cmd
+	// exits to bring the produced files back to the scheme directory.
+	Command(t testing.TB, invocation Invocation) (cmd *exec.Cmd, finish func() error, err error)
+}
+
+// WithRunner runs the binary with the runner, e.g. [SSHRunner], instead of
+// starting it locally. The output, the return code and the scheme directory
+// brought back by the runner are asserted as usual.
+func WithRunner(runner Runner) Option {
+	return func(c *config) {
+		c.runner = runner
+	}
+}
@@ -0,0 +1,1036 @@
+package exectest
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// This is the comment start in Lua, so there might be problems.
+	filePrefix        = "--file:"
+	stdoutPrefix      = "--stdout"
+	stderrPrefix      = "--stderr"
+	stdinPrefix       = "--stdin"
+	envPrefix         = "--env:"
+	argPrefix         = "--arg:"
+	returnCodePrefix  = "--return-code:"
+	tagsPrefix        = "--tags:"
+	retriesPrefix     = "--retries:"
+	maxDurationPrefix = "--max-duration:"
+	ptyPrefix         = "--pty"
+	interactPrefix    = "--interact"
+	signalPrefix      = "--signal:"
+	killedByPrefix    = "--killed-by:"
+	outputPrefix      = "--output"
+	timeoutPrefix     = "--timeout:"
+	runHeader         = "--run"
+	runParallelHeader = "--run-parallel"
+	daemonHeader      = "--daemon"
+	readyPrefix       = "--ready:"
+	// The excludes, bytes, hex, lines, empty, any, in-order, prefix and
+	// suffix prefixes must be checked before the stdout and stderr ones.
+	stdoutExcludesPrefix = "--stdout-excludes"
+	stderrExcludesPrefix = "--stderr-excludes"
+	stdoutBytesPrefix    = "--stdout-bytes"
+	stderrBytesPrefix    = "--stderr-bytes"
+	stdoutHexPrefix      = "--stdout-hex"
+	stderrHexPrefix      = "--stderr-hex"
+	stdoutLinesPrefix    = "--stdout-lines:"
+	stderrLinesPrefix    = "--stderr-lines:"
+	stdoutEmptyPrefix    = "--stdout-empty"
+	stderrEmptyPrefix    = "--stderr-empty"
+	stdoutAnyPrefix      = "--stdout-any"
+	stderrAnyPrefix      = "--stderr-any"
+	stdoutInOrderPrefix  = "--stdout-in-order"
+	stderrInOrderPrefix  = "--stderr-in-order"
+	stdoutPrefixPrefix   = "--stdout-prefix"
+	stderrPrefixPrefix   = "--stderr-prefix"
+	stdoutSuffixPrefix   = "--stdout-suffix"
+	stderrSuffixPrefix   = "--stderr-suffix"
+	stripANSIPrefix      = "--strip-ansi"
+	expectTreePrefix     = "--expect-tree"
+	expectSHA256Prefix   = "--expect-sha256:"
+	// The stdin-generate prefix must be checked before the stdin one.
+	stdinGeneratePrefix = "--stdin-generate:"
+	repeatPrefix        = "--repeat:"
+	concurrentPrefix    = "--concurrent:"
+	envFilePrefix       = "--env-file:"
+	startErrorPrefix    = "--expect-start-error:"
+	pipePrefix          = "--pipe"
+	runIfPrefix         = "--run-if:"
+	expectChangesPrefix = "--expect-changes"
+	goldenDirPrefix     = "--golden-dir:"
+	expectStatPrefix    = "--expect-stat:"
+	idleTimeoutPrefix   = "--idle-timeout:"
+	stopOnPrefix        = "--stop-on:"
+	stubPrefix          = "--stub:"
+	readOnlyPrefix      = "--read-only"
+	limitPrefix         = "--limit:"
+	maxRSSPrefix        = "--max-rss:"
+	shellPrefix         = "--shell:"
+	expectSymlinkPrefix = "--expect-symlink:"
+	fifoPrefix          = "--fifo:"
+	expectModePrefix    = "--expect-mode:"
+	finalNewlinePrefix  = "--final-newline:"
+	capturePrefix       = "--capture:"
+	namePrefix          = "--name:"
+	skipShortPrefix     = "--skip-short"
+	onlyShortPrefix     = "--only-short"
+	inheritEnvPrefix    = "--inherit-env:"
+	returnCodeAnyPrefix = "--return-code-any:"
+)
+
+// directivePrefixes are all the prefixes interpreted by the parser.
+var directivePrefixes = []string{
+	filePrefix, stdoutPrefix, stderrPrefix, stdinGeneratePrefix, stdinPrefix,
+	envPrefix, argPrefix, returnCodePrefix, tagsPrefix, retriesPrefix,
+	maxDurationPrefix, ptyPrefix, interactPrefix, signalPrefix,
+	killedByPrefix, outputPrefix, timeoutPrefix, runHeader, daemonHeader,
+	readyPrefix, stdoutExcludesPrefix, stderrExcludesPrefix, stripANSIPrefix,
+	casePrefix, expectTreePrefix, expectSHA256Prefix, repeatPrefix,
+	concurrentPrefix, envFilePrefix, startErrorPrefix, heredocArgPrefix,
+	pipePrefix, runIfPrefix, runParallelHeader, expectChangesPrefix,
+	goldenDirPrefix, expectStatPrefix, idleTimeoutPrefix, stopOnPrefix,
+	stubPrefix, readOnlyPrefix, limitPrefix, maxRSSPrefix, shellPrefix,
+	expectSymlinkPrefix, fifoPrefix, expectModePrefix, stdoutBytesPrefix,
+	stderrBytesPrefix, stdoutHexPrefix, stderrHexPrefix, finalNewlinePrefix,
+	stdoutLinesPrefix, stderrLinesPrefix, stdoutEmptyPrefix, stderrEmptyPrefix,
+	stdoutAnyPrefix, stderrAnyPrefix, stdoutInOrderPrefix, stderrInOrderPrefix,
+	capturePrefix, matrixPrefix, namePrefix, skipShortPrefix, onlyShortPrefix,
+	inheritEnvPrefix, returnCodeAnyPrefix, stdoutPrefixPrefix, stderrPrefixPrefix,
+	stdoutSuffixPrefix, stderrSuffixPrefix,
+}
+
+// Scheme is a parsed scheme, see [Execute] for the format.
+//
+// Placeholders like `{dir}` are kept as is, they are expanded when the
+// scheme is executed.
+type Scheme struct {
+	// Files to create in the scheme directory, `--file:` directives.
+	Files []File
+	// FIFOs are the named pipes to create in the scheme directory, `--fifo:`
+	// blocks.
+	FIFOs []FIFO
+	// Stubs are the fake executables on the PATH of the binary, `--stub:`
+	// blocks.
+	Stubs []Stub
+	// Args passed to the binary, `--arg:` directives.
+	Args []string
+	// Shell is the `--shell:` directive, the script run with the [Shell] of
+	// the OS instead of the binary, it can't be used with the Args.
+	Shell string
+	// Env is a list of KEY=VALUE entries added to the binary environment,
+	// `--env:` directives.
+	Env []string
+	// CleanEnv is set by the `--inherit-env:` directives, the binary gets
+	// only the InheritEnv variables of the test process then.
+	CleanEnv   bool
+	InheritEnv []string
+	// EnvFiles are the `--env-file:` directives, the files of the KEY=VALUE
+	// lines loaded into the environment before the Env.
+	EnvFiles []string
+	// Stdin fed to the binary, `--stdin` block.
+	Stdin string
+	// StdinGenerator synthesizes the Stdin, the `--stdin-generate:` directive.
+	StdinGenerator *StdinGenerator
+	// ExpectedStdout is the `--stdout` block.
+	ExpectedStdout string
+	// ExpectedStderr is the `--stderr` block.
+	ExpectedStderr string
+	// StdoutExcludes and StderrExcludes are the `--stdout-excludes` and
+	// `--stderr-excludes` blocks, the texts, or regular expressions prefixed
+	// with `re:`, that must not appear on any line of the output.
+	StdoutExcludes []string
+	StderrExcludes []string
+	// StdoutDiff, StderrDiff and OutputDiff are the options of the blocks
+	// from their headers, e.g. `--stdout: ignore-case`.
+	StdoutDiff DiffOption
+	StderrDiff DiffOption
+	OutputDiff DiffOption
+	// ExpectedOutput is the `--output` block, the interleaved stdout and stderr.
+	ExpectedOutput string
+	// CombinedOutput tells the `--output` block is defined, it's asserted
+	// instead of the ExpectedStdout and ExpectedStderr.
+	CombinedOutput bool
+	// ExpectedReturnCode is the `--return-code:` directive, 0 by default.
+	ExpectedReturnCode int
+	// ExpectedReturnCodes is the `--return-code-any:` directive, any of the
+	// return codes is expected instead of the ExpectedReturnCode.
+	ExpectedReturnCodes []int
+	// ExpectedStartError is the `--expect-start-error:` directive, the
+	// pattern of the expected failure to start the binary: a substring or a
+	// `re:` prefixed regular expression.
+	ExpectedStartError string
+	// ExpectedKilledBy is the `--killed-by:` directive, the name of the signal
+	// expected to terminate the process instead of the return code.
+	ExpectedKilledBy string
+	// Name is the `--name:` directive, the description of the scheme used as
+	// the subtest name by [ExecuteDir] and reported along with the failures.
+	Name string
+	// Tags of the scheme, `--tags:` directives with comma separated values.
+	Tags []string
+	// SkipShort and OnlyShort are the `--skip-short` and `--only-short`
+	// directives, the scheme is skipped with or without `go test -short`.
+	SkipShort bool
+	OnlyShort bool
+	// Retries is the number of re-runs of the failed scheme, the
+	// `--retries: <count> [backoff]` directive.
+	Retries int
+	// Repeat is the number of runs of the scheme, each in a fresh directory,
+	// the `--repeat:` directive. The first failed run fails the scheme.
+	Repeat int
+	// Concurrent is the number of the instances of the binary launched at the
+	// same time in the shared directory, the `--concurrent:` directive.
+	Concurrent int
+	// RetryBackoff is the delay before the first retry, it doubles after
+	// every attempt.
+	RetryBackoff time.Duration
+	// MaxDuration of the execution, the `--max-duration:` directive, 0 means
+	// no limit.
+	MaxDuration time.Duration
+	// MaxRSS is the `--max-rss:` directive, the bytes of the peak resident
+	// set size of the binary, 0 means no limit.
+	MaxRSS uint64
+	// StdoutLines and StderrLines are the `--stdout-lines:` and
+	// `--stderr-lines:` directives, the number of the lines of the outputs.
+	StdoutLines *LineCount
+	StderrLines *LineCount
+	// StdoutEmpty and StderrEmpty are the `--stdout-empty` and
+	// `--stderr-empty` directives, nothing is written to the outputs.
+	StdoutEmpty bool
+	StderrEmpty bool
+	// StdoutAny and StderrAny are the `--stdout-any` and `--stderr-any`
+	// directives, the outputs are not compared.
+	StdoutAny bool
+	StderrAny bool
+	// FinalNewline is the `--final-newline:` directive, `required` or
+	// `forbidden` final newline of the outputs, the empty one is ignored.
+	FinalNewline string
+	// Timeout is the `--timeout:` directive, the process group is killed once
+	// it's exceeded. Without it the group is killed a margin before the
+	// deadline of the test.
+	Timeout time.Duration
+	// IdleTimeout is the `--idle-timeout:` directive, the process group is
+	// killed once the binary writes no output for it.
+	IdleTimeout time.Duration
+	// PTY is the size of the pseudo-terminal the binary is attached to, the
+	// `--pty[: <rows>x<cols>]` directive. The stdout and stderr are combined
+	// into the stdout then.
+	PTY *TerminalSize
+	// Interaction is the `--interact[: <timeout>]` block, the stdin is written
+	// step by step waiting for the expected stdout in between.
+	Interaction []InteractionStep
+	// Signals sent to the running process, `--signal:` directives.
+	Signals []Signal
+	// StopOn is the `--stop-on:` directive, the signal sent once the pattern
+	// appears on the stdout. The process terminated by it succeeds unless
+	// the termination is expected explicitly.
+	StopOn *Signal
+	// ExpectedTree is the `--expect-tree` block, the files of the scheme
+	// directory after the execution.
+	ExpectedTree []TreeEntry
+	// ExpectTree tells the `--expect-tree` block is defined.
+	ExpectTree bool
+	// ExpectedChanges is the `--expect-changes` block, the files created,
+	// modified and deleted by the execution.
+	ExpectedChanges []Change
+	// ExpectChanges tells the `--expect-changes` block is defined.
+	ExpectChanges bool
+	// GoldenDir is the `--golden-dir:` directive, the directory the files of
+	// the scheme directory are compared with after the execution, it's
+	// rewritten in the update mode.
+	GoldenDir string
+	// ExpectedStats are the `--expect-stat:` and `--expect-mode:` directives,
+	// the metadata of the files of the scheme directory after the execution.
+	ExpectedStats []FileStat
+	// ExpectedSymlinks are the `--expect-symlink:` directives, the symbolic
+	// links of the scheme directory after the execution.
+	ExpectedSymlinks []Symlink
+	// ExpectedSHA256 are the `--expect-sha256:` directives, checksums of the
+	// files of the scheme directory after the execution.
+	ExpectedSHA256 []FileChecksum
+	// StripANSI is the `--strip-ansi` directive, the ANSI escape sequences are
+	// removed from the output before the comparison.
+	StripANSI bool
+	// ReadOnly is the `--read-only` directive, the write permissions of the
+	// scheme directory are removed while the binary runs.
+	ReadOnly bool
+	// Limits are the `--limit:` directives, the rlimits of the binary.
+	Limits []Limit
+	// Steps are the `--run` and `--daemon` commands run one by one in the
+	// scheme directory instead of the single binary execution.
+	Steps []Step
+}
+
+// TerminalSize is a size of the terminal in characters.
+type TerminalSize struct {
+	Rows int
+	Cols int
+}
+
+// defaultTerminalSize is the size of the `--pty` without explicit size.
+var defaultTerminalSize = TerminalSize{Rows: 24, Cols: 80}
+
+// File is a file created in the scheme directory before the execution.
+type File struct {
+	// Path relative to the scheme directory.
+	Path string
+	// Content of the file.
+	Content string
+}
+
+type block int
+
+const (
+	noBlock block = iota
+	stdoutBlock
+	stderrBlock
+	stdinBlock
+	fileBlock
+	fifoBlock
+	stubBlock
+	interactBlock
+	outputBlock
+	stdoutExcludesBlock
+	stderrExcludesBlock
+	expectTreeBlock
+	expectChangesBlock
+)
+
+// ParseScheme parses the scheme text, see [Execute] for the format. The
+// errors of the scheme lines are [*ParseError].
+func ParseScheme(scheme string) (*Scheme, error) {
+	head, sections := splitSteps(scheme)
+	result, err := parseSection(head, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		return result, nil
+	}
+	if definesCommand(result) {
+		return nil, fmt.Errorf("arguments, input and expectations must be defined inside --run and --daemon steps")
+	}
+	for _, section := range sections {
+		step, err := parseStep(section)
+		if err != nil {
+			return nil, err
+		}
+		if step.Pipe && (len(result.Steps) == 0 || result.Steps[len(result.Steps)-1].Daemon) {
+			return nil, newParseError(section.line, section.header, fmt.Errorf("--pipe step must follow a --run step"))
+		}
+		if step.RunIf != "" && !slices.ContainsFunc(result.Steps, func(step Step) bool { return !step.Daemon }) {
+			return nil, newParseError(section.line, section.header, fmt.Errorf("--run-if step must follow a --run step"))
+		}
+		result.Steps = append(result.Steps, step)
+	}
+	return result, nil
+}
+
+// parseSection parses the directives of the scheme or its step starting at
+// the firstLine of the whole scheme.
+func parseSection(scheme string, firstLine int) (*Scheme, error) {
+	var result Scheme
+	var stdout strings.Builder
+	var stderr strings.Builder
+	var stdin strings.Builder
+	var file strings.Builder
+	var stub strings.Builder
+	var fifo strings.Builder
+	var interaction strings.Builder
+	var interactionHeader string
+	var hasInteraction bool
+	var interactionLine int
+	var interactionText string
+	var output strings.Builder
+	var hasStdout, hasStderr bool
+	// the headers of the block variants, the bytes and hex blocks are decoded
+	// once parsed.
+	var stdoutHeader, stderrHeader string
+	current := noBlock
+
+	switchBlock := func(next block) {
+		if current == fileBlock {
+			result.Files[len(result.Files)-1].Content = file.String()
+			file.Reset()
+		}
+		if current == stubBlock {
+			result.Stubs[len(result.Stubs)-1].Content = stub.String()
+			stub.Reset()
+		}
+		if current == fifoBlock {
+			result.FIFOs[len(result.FIFOs)-1].Content = fifo.String()
+			fifo.Reset()
+		}
+		current = next
+	}
+
+	platform, err := selectPlatform(scheme, firstLine)
+	if err != nil {
+		return nil, err
+	}
+	var heredoc strings.Builder
+	var heredocDelimiter string
+	var heredocLine int
+	defined := make(map[string]int)
+	for i, line := range toLines(scheme) {
+		number := firstLine + i
+		text := line
+		lineError := func(err error) error {
+			return newParseError(number, text, err)
+		}
+		if heredocDelimiter != "" {
+			if strings.TrimSpace(line) == heredocDelimiter {
+				result.Args = append(result.Args, strings.TrimSuffix(heredoc.String(), "\n"))
+				heredoc.Reset()
+				heredocDelimiter = ""
+			} else {
+				heredoc.WriteString(line)
+			}
+			continue
+		}
+		if delimiter, ok := strings.CutPrefix(line, heredocArgPrefix); ok {
+			heredocDelimiter, heredocLine = strings.TrimSpace(delimiter), number
+			if heredocDelimiter == "" {
+				return nil, lineError(fmt.Errorf("--arg<< must have a delimiter"))
+			}
+			continue
+		}
+		line, ok := platform.selected(line)
+		if !ok {
+			if startsBlock(line) {
+				// the lines of the block are ignored too.
+				switchBlock(noBlock)
+			}
+			continue
+		}
+		if name := definitionName(line); name != "" {
+			if first, ok := defined[name]; ok {
+				return nil, lineError(fmt.Errorf("%s is already defined at line %d", name, first))
+			}
+			defined[name] = number
+		}
+		if strings.HasPrefix(line, readyPrefix) || strings.HasPrefix(line, pipePrefix) ||
+			strings.HasPrefix(line, runIfPrefix) {
+			// parsed by parseStep.
+			continue
+		}
+		if strings.HasPrefix(line, expectTreePrefix) {
+			switchBlock(expectTreeBlock)
+			result.ExpectTree = true
+			continue
+		}
+		if strings.HasPrefix(line, expectChangesPrefix) {
+			switchBlock(expectChangesBlock)
+			result.ExpectChanges = true
+			continue
+		}
+		if strings.HasPrefix(line, stdoutExcludesPrefix) {
+			switchBlock(stdoutExcludesBlock)
+			continue
+		}
+		if strings.HasPrefix(line, stderrExcludesPrefix) {
+			switchBlock(stderrExcludesBlock)
+			continue
+		}
+		if strings.HasPrefix(line, stdoutEmptyPrefix) || strings.HasPrefix(line, stdoutAnyPrefix) {
+			// the stdout block of the same name can't be defined then.
+			hasStdout = true
+			result.StdoutEmpty = strings.HasPrefix(line, stdoutEmptyPrefix)
+			result.StdoutAny = !result.StdoutEmpty
+			continue
+		}
+		if strings.HasPrefix(line, stderrEmptyPrefix) || strings.HasPrefix(line, stderrAnyPrefix) {
+			hasStderr = true
+			result.StderrEmpty = strings.HasPrefix(line, stderrEmptyPrefix)
+			result.StderrAny = !result.StderrEmpty
+			continue
+		}
+		if count, ok := strings.CutPrefix(line, stdoutLinesPrefix); ok {
+			var err error
+			if result.StdoutLines, err = parseLineCount(count); err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if count, ok := strings.CutPrefix(line, stderrLinesPrefix); ok {
+			var err error
+			if result.StderrLines, err = parseLineCount(count); err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if header, rest, ok := cutBlockPrefix(line, stderrBytesPrefix, stderrHexPrefix, stderrInOrderPrefix, stderrPrefixPrefix, stderrSuffixPrefix); ok {
+			switchBlock(stderrBlock)
+			hasStderr, stderrHeader = true, header
+			var err error
+			if result.StderrDiff, err = parseBlockOptions(rest); err != nil {
+				return nil, lineError(err)
+			}
+			result.StderrDiff |= headerOptions[header]
+			continue
+		}
+		if header, rest, ok := cutBlockPrefix(line, stdoutBytesPrefix, stdoutHexPrefix, stdoutInOrderPrefix, stdoutPrefixPrefix, stdoutSuffixPrefix); ok {
+			switchBlock(stdoutBlock)
+			hasStdout, stdoutHeader = true, header
+			var err error
+			if result.StdoutDiff, err = parseBlockOptions(rest); err != nil {
+				return nil, lineError(err)
+			}
+			result.StdoutDiff |= headerOptions[header]
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, stderrPrefix); ok {
+			switchBlock(stderrBlock)
+			hasStderr = true
+			var err error
+			if result.StderrDiff, err = parseBlockOptions(rest); err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, stdoutPrefix); ok {
+			switchBlock(stdoutBlock)
+			hasStdout = true
+			var err error
+			if result.StdoutDiff, err = parseBlockOptions(rest); err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, outputPrefix); ok {
+			switchBlock(outputBlock)
+			result.CombinedOutput = true
+			var err error
+			if result.OutputDiff, err = parseBlockOptions(rest); err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if fileName, ok := strings.CutPrefix(line, filePrefix); ok {
+			fileName = slashPath(fileName)
+			if !filepath.IsLocal(fileName) {
+				return nil, lineError(fmt.Errorf("file path %q must be local to the scheme directory", fileName))
+			}
+			switchBlock(fileBlock)
+			result.Files = append(result.Files, File{Path: fileName})
+			continue
+		}
+		if fifoText, ok := strings.CutPrefix(line, fifoPrefix); ok {
+			path, err := parseFIFOPath(fifoText)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			switchBlock(fifoBlock)
+			result.FIFOs = append(result.FIFOs, FIFO{Path: path})
+			continue
+		}
+		if stubText, ok := strings.CutPrefix(line, stubPrefix); ok {
+			parsed, err := parseStub(stubText)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			switchBlock(stubBlock)
+			result.Stubs = append(result.Stubs, parsed)
+			continue
+		}
+		if generator, ok := strings.CutPrefix(line, stdinGeneratePrefix); ok {
+			var err error
+			if result.StdinGenerator, err = parseStdinGenerator(generator); err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, stdinPrefix) {
+			switchBlock(stdinBlock)
+			continue
+		}
+		if header, ok := strings.CutPrefix(line, interactPrefix); ok {
+			switchBlock(interactBlock)
+			interactionLine, interactionText = number, line
+			interactionHeader = strings.TrimPrefix(header, ":")
+			hasInteraction = true
+			continue
+		}
+
+		if codes, ok := strings.CutPrefix(line, returnCodeAnyPrefix); ok {
+			var err error
+			if result.ExpectedReturnCodes, err = parseReturnCodes(codes); err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if rtCodeText, ok := strings.CutPrefix(line, returnCodePrefix); ok {
+			rtCodeText = strings.TrimSpace(rtCodeText)
+			returnCode, err := strconv.Atoi(rtCodeText)
+			if err != nil {
+				return nil, lineError(fmt.Errorf("failed to convert return code %q to int: %w", rtCodeText, err))
+			}
+			result.ExpectedReturnCode = returnCode
+			continue
+		}
+		if script, ok := strings.CutPrefix(line, shellPrefix); ok {
+			if result.Shell = strings.TrimSpace(script); result.Shell == "" {
+				return nil, lineError(fmt.Errorf("--shell must have a script"))
+			}
+			continue
+		}
+		if arg, ok := strings.CutPrefix(line, argPrefix); ok {
+			result.Args = append(result.Args, strings.TrimSpace(arg))
+			continue
+		}
+		if goldenDir, ok := strings.CutPrefix(line, goldenDirPrefix); ok {
+			if result.GoldenDir = strings.TrimSpace(goldenDir); result.GoldenDir == "" {
+				return nil, lineError(fmt.Errorf("--golden-dir must have a path"))
+			}
+			continue
+		}
+		if inheritEnv, ok := strings.CutPrefix(line, inheritEnvPrefix); ok {
+			names, err := parseInheritEnv(inheritEnv)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.CleanEnv = true
+			result.InheritEnv = append(result.InheritEnv, names...)
+			continue
+		}
+		if envFile, ok := strings.CutPrefix(line, envFilePrefix); ok {
+			envFile = strings.TrimSpace(envFile)
+			if envFile == "" {
+				return nil, lineError(fmt.Errorf("--env-file must have a path"))
+			}
+			result.EnvFiles = append(result.EnvFiles, envFile)
+			continue
+		}
+		if kv, ok := strings.CutPrefix(line, envPrefix); ok {
+			kv = strings.TrimSpace(kv)
+			if !strings.Contains(kv, "=") {
+				return nil, lineError(fmt.Errorf("malformed --env entry %q, expected KEY=VALUE", kv))
+			}
+			result.Env = append(result.Env, kv)
+			continue
+		}
+		if retries, ok := strings.CutPrefix(line, retriesPrefix); ok {
+			var err error
+			result.Retries, result.RetryBackoff, err = parseRetries(retries)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if checksum, ok := strings.CutPrefix(line, expectSHA256Prefix); ok {
+			fileChecksum, err := parseFileChecksum(checksum)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.ExpectedSHA256 = append(result.ExpectedSHA256, fileChecksum)
+			continue
+		}
+		if stat, ok := strings.CutPrefix(line, expectStatPrefix); ok {
+			fileStat, err := parseFileStat(stat)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.ExpectedStats = append(result.ExpectedStats, fileStat)
+			continue
+		}
+		if mode, ok := strings.CutPrefix(line, expectModePrefix); ok {
+			fileStat, err := parseFileMode(mode)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.ExpectedStats = append(result.ExpectedStats, fileStat)
+			continue
+		}
+		if limitText, ok := strings.CutPrefix(line, limitPrefix); ok {
+			limit, err := parseLimit(limitText)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.Limits = append(result.Limits, limit)
+			continue
+		}
+		if symlink, ok := strings.CutPrefix(line, expectSymlinkPrefix); ok {
+			link, err := parseSymlink(symlink)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.ExpectedSymlinks = append(result.ExpectedSymlinks, link)
+			continue
+		}
+		if repeatText, ok := strings.CutPrefix(line, repeatPrefix); ok {
+			repeatText = strings.TrimSpace(repeatText)
+			repeat, err := strconv.Atoi(repeatText)
+			if err != nil || repeat < 1 {
+				return nil, lineError(fmt.Errorf("failed to convert repeat %q to positive int", repeatText))
+			}
+			result.Repeat = repeat
+			continue
+		}
+		if concurrentText, ok := strings.CutPrefix(line, concurrentPrefix); ok {
+			concurrentText = strings.TrimSpace(concurrentText)
+			concurrent, err := strconv.Atoi(concurrentText)
+			if err != nil || concurrent < 1 {
+				return nil, lineError(fmt.Errorf("failed to convert concurrent %q to positive int", concurrentText))
+			}
+			result.Concurrent = concurrent
+			continue
+		}
+		if maxDuration, ok := strings.CutPrefix(line, maxDurationPrefix); ok {
+			maxDuration = strings.TrimSpace(maxDuration)
+			var err error
+			result.MaxDuration, err = time.ParseDuration(maxDuration)
+			if err != nil {
+				return nil, lineError(fmt.Errorf("failed to parse max duration %q: %w", maxDuration, err))
+			}
+			continue
+		}
+		if maxRSS, ok := strings.CutPrefix(line, maxRSSPrefix); ok {
+			maxRSS = strings.TrimSpace(maxRSS)
+			var err error
+			if result.MaxRSS, err = parseBytes(maxRSS); err != nil || result.MaxRSS == 0 {
+				return nil, lineError(fmt.Errorf("failed to parse max RSS %q as positive size", maxRSS))
+			}
+			continue
+		}
+		if finalNewline, ok := strings.CutPrefix(line, finalNewlinePrefix); ok {
+			var err error
+			if result.FinalNewline, err = parseFinalNewline(finalNewline); err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if idleTimeout, ok := strings.CutPrefix(line, idleTimeoutPrefix); ok {
+			idleTimeout = strings.TrimSpace(idleTimeout)
+			var err error
+			result.IdleTimeout, err = time.ParseDuration(idleTimeout)
+			if err != nil || result.IdleTimeout <= 0 {
+				return nil, lineError(fmt.Errorf("failed to parse idle timeout %q as positive duration", idleTimeout))
+			}
+			continue
+		}
+		if timeout, ok := strings.CutPrefix(line, timeoutPrefix); ok {
+			timeout = strings.TrimSpace(timeout)
+			var err error
+			result.Timeout, err = time.ParseDuration(timeout)
+			if err != nil {
+				return nil, lineError(fmt.Errorf("failed to parse timeout %q: %w", timeout, err))
+			}
+			continue
+		}
+		if size, ok := strings.CutPrefix(line, ptyPrefix); ok {
+			var err error
+			result.PTY, err = parseTerminalSize(size)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			continue
+		}
+		if startError, ok := strings.CutPrefix(line, startErrorPrefix); ok {
+			startError = strings.TrimSpace(startError)
+			if _, err := compileExclude(startError); err != nil {
+				return nil, lineError(err)
+			}
+			result.ExpectedStartError = startError
+			continue
+		}
+		if killedBy, ok := strings.CutPrefix(line, killedByPrefix); ok {
+			killedBy = strings.TrimSpace(killedBy)
+			if _, ok := signals[killedBy]; !ok {
+				return nil, lineError(fmt.Errorf("unsupported signal %q", killedBy))
+			}
+			result.ExpectedKilledBy = killedBy
+			continue
+		}
+		if signal, ok := strings.CutPrefix(line, signalPrefix); ok {
+			parsed, err := parseSignal(signal)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.Signals = append(result.Signals, parsed)
+			continue
+		}
+		if stopOn, ok := strings.CutPrefix(line, stopOnPrefix); ok {
+			parsed, err := parseStopOn(stopOn)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.StopOn = &parsed
+			continue
+		}
+		if strings.HasPrefix(line, stripANSIPrefix) {
+			result.StripANSI = true
+			continue
+		}
+		if strings.HasPrefix(line, readOnlyPrefix) {
+			result.ReadOnly = true
+			continue
+		}
+		if tags, ok := strings.CutPrefix(line, tagsPrefix); ok {
+			result.Tags = append(result.Tags, splitList(tags)...)
+			continue
+		}
+		if strings.HasPrefix(line, skipShortPrefix) {
+			result.SkipShort = true
+			continue
+		}
+		if strings.HasPrefix(line, onlyShortPrefix) {
+			result.OnlyShort = true
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, namePrefix); ok {
+			if result.Name = strings.TrimSpace(name); result.Name == "" {
+				return nil, lineError(fmt.Errorf("empty --name"))
+			}
+			continue
+		}
+
+		switch current {
+		case stdoutBlock:
+			stdout.WriteString(line)
+		case stderrBlock:
+			stderr.WriteString(line)
+		case stdinBlock:
+			stdin.WriteString(line)
+		case fileBlock:
+			file.WriteString(line)
+		case stubBlock:
+			stub.WriteString(line)
+		case fifoBlock:
+			fifo.WriteString(line)
+		case interactBlock:
+			interaction.WriteString(line)
+		case outputBlock:
+			output.WriteString(line)
+		case expectTreeBlock:
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			entry, err := parseTreeEntry(line)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.ExpectedTree = append(result.ExpectedTree, entry)
+		case expectChangesBlock:
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			change, err := parseChange(line)
+			if err != nil {
+				return nil, lineError(err)
+			}
+			result.ExpectedChanges = append(result.ExpectedChanges, change)
+		case stdoutExcludesBlock, stderrExcludesBlock:
+			exclude := strings.TrimSpace(line)
+			if exclude == "" {
+				continue
+			}
+			if _, err := compileExclude(exclude); err != nil {
+				return nil, lineError(err)
+			}
+			if current == stdoutExcludesBlock {
+				result.StdoutExcludes = append(result.StdoutExcludes, exclude)
+			} else {
+				result.StderrExcludes = append(result.StderrExcludes, exclude)
+			}
+		}
+	}
+	switchBlock(noBlock)
+	if heredocDelimiter != "" {
+		return nil, newParseError(heredocLine, heredocArgPrefix+heredocDelimiter, fmt.Errorf("--arg<< is not terminated with %s", heredocDelimiter))
+	}
+
+	if result.Shell != "" && len(result.Args) > 0 {
+		return nil, fmt.Errorf("--shell can't be used together with --arg")
+	}
+	if _, ok := defined[strings.TrimSuffix(returnCodePrefix, ":")]; ok && result.ExpectedReturnCodes != nil {
+		return nil, fmt.Errorf("--return-code-any can't be used together with --return-code")
+	}
+	if result.ExpectedKilledBy != "" && result.ExpectedReturnCodes != nil {
+		return nil, fmt.Errorf("--return-code-any can't be used together with --killed-by")
+	}
+	if result.SkipShort && result.OnlyShort {
+		return nil, fmt.Errorf("--skip-short and --only-short can't be used together")
+	}
+	if result.CombinedOutput && (hasStdout || hasStderr) {
+		return nil, fmt.Errorf("--output can't be used together with --stdout or --stderr")
+	}
+	if result.StdinGenerator != nil && (stdin.Len() > 0 || hasInteraction) {
+		return nil, fmt.Errorf("--stdin-generate can't be used together with --stdin or --interact")
+	}
+	if hasInteraction {
+		if stdin.Len() > 0 {
+			return nil, fmt.Errorf("--stdin and --interact can't be used together")
+		}
+		var err error
+		result.Interaction, err = parseInteraction(interactionHeader, interaction.String())
+		if err != nil {
+			return nil, newParseError(interactionLine, interactionText, err)
+		}
+	}
+
+	result.Stdin = stdin.String()
+	result.ExpectedStdout = stdout.String()
+	result.ExpectedStderr = stderr.String()
+	if result.ExpectedStdout, err = decodeBlock(stdoutHeader, result.ExpectedStdout); err != nil {
+		return nil, fmt.Errorf("malformed %s: %w", stdoutHeader, err)
+	}
+	if result.ExpectedStderr, err = decodeBlock(stderrHeader, result.ExpectedStderr); err != nil {
+		return nil, fmt.Errorf("malformed %s: %w", stderrHeader, err)
+	}
+	result.ExpectedOutput = output.String()
+	return &result, nil
+}
+
+// ParseError is the error of the scheme line.
+type ParseError struct {
+	// Line is the 1-based number of the line in the scheme.
+	Line int
+	// Text of the line.
+	Text string
+	Err  error
+}
+
+func newParseError(line int, text string, err error) *ParseError {
+	return &ParseError{Line: line, Text: strings.TrimSuffix(text, "\n"), Err: err}
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d %q: %s", e.Line, e.Text, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// numberLines renders the scheme with the line numbers for the logs.
+func numberLines(scheme string) string {
+	var result strings.Builder
+	for i, line := range toLines(scheme) {
+		fmt.Fprintf(&result, "%4d | %s", i+1, line)
+	}
+	return result.String()
+}
+
+// singlePrefixes are the directives defined at most once in the scheme, the
+// longer prefixes go first.
+var singlePrefixes = []string{
+	stdoutExcludesPrefix, stderrExcludesPrefix, stdoutLinesPrefix,
+	stderrLinesPrefix, stdoutPrefix, stderrPrefix,
+	outputPrefix, stdinGeneratePrefix, stdinPrefix, interactPrefix,
+	expectTreePrefix, returnCodePrefix, killedByPrefix, retriesPrefix,
+	repeatPrefix, concurrentPrefix, maxDurationPrefix, timeoutPrefix,
+	ptyPrefix, stripANSIPrefix, startErrorPrefix, pipePrefix, runIfPrefix,
+	expectChangesPrefix, goldenDirPrefix, idleTimeoutPrefix, stopOnPrefix,
+	readOnlyPrefix, maxRSSPrefix, shellPrefix, finalNewlinePrefix, namePrefix,
+	skipShortPrefix, onlyShortPrefix, returnCodeAnyPrefix,
+}
+
+// definitionName returns the name of the directive of the line defined at
+// most once, the files and the checksums are defined once per path and the
+// limits once per resource.
+func definitionName(line string) string {
+	if path, ok := strings.CutPrefix(line, filePrefix); ok {
+		return filePrefix + filepath.Clean(slashPath(path))
+	}
+	if path, ok := strings.CutPrefix(line, fifoPrefix); ok {
+		return filePrefix + filepath.Clean(slashPath(path))
+	}
+	if checksum, ok := strings.CutPrefix(line, expectSHA256Prefix); ok {
+		if parsed, err := parseFileChecksum(checksum); err == nil {
+			return expectSHA256Prefix + filepath.Clean(parsed.Path)
+		}
+		return ""
+	}
+	if stub, ok := strings.CutPrefix(line, stubPrefix); ok {
+		if parsed, err := parseStub(stub); err == nil {
+			return stubPrefix + parsed.Name
+		}
+		return ""
+	}
+	if stat, ok := strings.CutPrefix(line, expectStatPrefix); ok {
+		if parsed, err := parseFileStat(stat); err == nil {
+			return expectStatPrefix + filepath.Clean(parsed.Path)
+		}
+		return ""
+	}
+	if mode, ok := strings.CutPrefix(line, expectModePrefix); ok {
+		if parsed, err := parseFileMode(mode); err == nil {
+			return expectModePrefix + filepath.Clean(parsed.Path)
+		}
+		return ""
+	}
+	if symlink, ok := strings.CutPrefix(line, expectSymlinkPrefix); ok {
+		if parsed, err := parseSymlink(symlink); err == nil {
+			return expectSymlinkPrefix + filepath.Clean(parsed.Path)
+		}
+		return ""
+	}
+	if limit, ok := strings.CutPrefix(line, limitPrefix); ok {
+		if parsed, err := parseLimit(limit); err == nil {
+			return limitPrefix + parsed.Resource
+		}
+		return ""
+	}
+	for _, prefix := range singlePrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSuffix(prefix, ":")
+		}
+	}
+	return ""
+}
+
+func parseRetries(text string) (int, time.Duration, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, 0, fmt.Errorf("malformed --retries %q, expected count and optional backoff", text)
+	}
+	retries, err := strconv.Atoi(fields[0])
+	if err != nil || retries < 0 {
+		return 0, 0, fmt.Errorf("failed to convert retries %q to non-negative int", fields[0])
+	}
+	var backoff time.Duration
+	if len(fields) == 2 {
+		backoff, err = time.ParseDuration(fields[1])
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse retries backoff %q: %w", fields[1], err)
+		}
+	}
+	return retries, backoff, nil
+}
+
+func parseTerminalSize(text string) (*TerminalSize, error) {
+	text = strings.TrimSpace(strings.TrimPrefix(text, ":"))
+	if text == "" {
+		size := defaultTerminalSize
+		return &size, nil
+	}
+	rowsText, colsText, ok := strings.Cut(text, "x")
+	rows, rowsErr := strconv.Atoi(rowsText)
+	cols, colsErr := strconv.Atoi(colsText)
+	if !ok || rowsErr != nil || colsErr != nil || rows <= 0 || cols <= 0 {
+		return nil, fmt.Errorf("malformed --pty size %q, expected <rows>x<cols>", text)
+	}
+	return &TerminalSize{Rows: rows, Cols: cols}, nil
+}
+
+// slashPath returns the path of the scheme directory with the backslashes
+// taken as the separators, so the paths are the same on every OS.
+func slashPath(path string) string {
+	return strings.ReplaceAll(strings.TrimSpace(path), `\`, "/")
+}
+
+// splitList splits comma separated values dropping the empty ones.
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,275 @@
+package exectest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IlyasYOY/exectest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseScheme(t *testing.T) {
+	scheme, err := exectest.ParseScheme(`Description is ignored.
+--file:a.txt
+content of a
+  {dir}
+--file:sub/b.txt
+--arg:-n
+--arg: {dir}/a.txt
+--env:KEY=VALUE
+--stdin
+input
+--stdout
+out
+--stderr
+err
+--return-code: 2
+--tags: slow,, network
+--retries: 3 10ms
+--max-duration: 1s
+--pty: 30x100
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+
+	want := &exectest.Scheme{
+		Files: []exectest.File{
+			{Path: "a.txt", Content: "content of a\n  {dir}\n"},
+			{Path: "sub/b.txt", Content: ""},
+		},
+		Args:               []string{"-n", "{dir}/a.txt"},
+		Env:                []string{"KEY=VALUE"},
+		Stdin:              "input\n",
+		ExpectedStdout:     "out\n",
+		ExpectedStderr:     "err\n",
+		ExpectedReturnCode: 2,
+		Tags:               []string{"slow", "network"},
+		Retries:            3,
+		RetryBackoff:       10 * time.Millisecond,
+		MaxDuration:        time.Second,
+		PTY:                &exectest.TerminalSize{Rows: 30, Cols: 100},
+	}
+	if diff := cmp.Diff(want, scheme); diff != "" {
+		t.Errorf("Unexpected scheme (-want, +got):\n%s", diff)
+	}
+}
+
+func TestParseSchemeErrors(t *testing.T) {
+	for name, scheme := range map[string]string{
+		"return code":        "--return-code: zero",
+		"env":                "--env:NOVALUE",
+		"absolute file":      "--file:/etc/passwd",
+		"escaping file":      "--file:../a.txt",
+		"retries":            "--retries: many",
+		"empty name":         "--name:  ",
+		"name twice":         "--name: a\n--name: b",
+		"name in step":       "--run\n--name: a",
+		"short twice":        "--skip-short\n--only-short",
+		"short in step":      "--run\n--skip-short",
+		"unknown platform":   "--stdout[linx]\na",
+		"inherit env":        "--inherit-env:PATH=/bin",
+		"return code any":    "--return-code-any: 0 x",
+		"prefix and stdout":  "--stdout-prefix\na\n--stdout\nb",
+		"no return codes":    "--return-code-any:",
+		"return codes twice": "--return-code: 1\n--return-code-any: 0 1",
+		"any and killed by":  "--killed-by: SIGKILL\n--return-code-any: 0",
+		"empty platform":     "--stdout[]\na",
+		"platform heredoc":   "--arg[linux]<<EOF\na\nEOF",
+		"platform twice":     "--stdout[unix,windows]\na\n--stdout[linux,windows]\nb",
+		"repeat":             "--repeat: 0",
+		"concurrent":         "--concurrent: many",
+		"stdout twice":       "--stdout\na\n--stdout\nb",
+		"heredoc delimiter":  "--arg<<\nx",
+		"heredoc unfinished": "--arg<<EOF\nx",
+		"escaping fifo":      "--fifo:../a.pipe",
+		"fifo and file":      "--file:a.pipe\n--fifo:a.pipe",
+		"file twice":         "--file:a.txt\n--file:./a.txt",
+		"return code twice":  "--return-code: 1\n--return-code: 2",
+		"backoff":            "--retries: 1 soon",
+		"max duration":       "--max-duration: long",
+		"pty size":           "--pty: big",
+		"interact step":      "--interact\nwait: prompt",
+		"interact stdin":     "--stdin\ninput\n--interact\nsend: x",
+		"interact timeout":   "--interact: soon",
+		"signal name":        "--signal: SIGNOPE after 1s",
+		"signal delay":       "--signal: SIGINT after soon",
+		"signal trigger":     "--signal: SIGINT when ready",
+		"killed by":          "--killed-by: SIGNOPE",
+		"stop on pattern":    "--stop-on: ",
+		"stub name":          "--stub: ",
+		"stub path":          "--stub: bin/git",
+		"stub exit":          "--stub: git exit=bad",
+		"stub twice":         "--stub: git\n--stub: git exit=1",
+		"stop on signal":     "--stop-on: \"ready\" SIGNOPE",
+		"stop on quote":      "--stop-on: \"ready",
+		"timeout":            "--timeout: soon",
+		"idle timeout":       "--idle-timeout: 0s",
+		"shell":              "--shell: ",
+		"shell and args":     "--shell: echo\n--arg:x",
+		"shell in program":   "--run: ls\n--shell: echo",
+		"max rss":            "--max-rss: 0MB",
+		"max rss unit":       "--max-rss: 50MiB",
+		"limit resource":     "--limit: nproc=1",
+		"limit value":        "--limit: fsize=1TB",
+		"limit format":       "--limit: nofile 16",
+		"limit twice":        "--limit: nofile=16\n--limit: nofile=8",
+		"diff option":        "--stdout: ignore-nothing",
+		"exclude regexp":     "--stdout-excludes\nre:(",
+		"tree attribute":     "--expect-tree\na.txt owner=root",
+		"change kind":        "--expect-changes\nrenamed a.txt",
+		"change path":        "--expect-changes\ncreated ../a.txt",
+		"golden dir":         "--golden-dir: ",
+		"stat condition":     "--expect-stat: a.txt owner=root",
+		"stat size":          "--expect-stat: a.txt size~1",
+		"stat no condition":  "--expect-stat: a.txt",
+		"stat twice":         "--expect-stat: a.txt fresh\n--expect-stat: ./a.txt size>0",
+		"bytes escape":       "--stdout-bytes\na\\q",
+		"bytes hex":          "--stderr-bytes\n\\x1",
+		"bytes twice":        "--stdout\n--stdout-bytes",
+		"hex digits":         "--stdout-hex\n0g",
+		"hex odd":            "--stderr-hex\n0 1 2",
+		"final newline":      "--final-newline: sometimes",
+		"newline twice":      "--final-newline: required\n--final-newline: forbidden",
+		"lines count":        "--stdout-lines: many",
+		"lines negative":     "--stderr-lines: -1",
+		"lines twice":        "--stdout-lines: 1\n--stdout-lines: 2",
+		"empty and block":    "--stdout-empty\n--stdout\nout",
+		"any and empty":      "--stderr-any\n--stderr-empty",
+		"empty and output":   "--stdout-empty\n--output\nout",
+		"in order twice":     "--stdout-in-order\na\n--stdout\na",
+		"capture delimiters": "--run\n--capture:ID created (\\w+)",
+		"capture regexp":     "--run\n--capture:ID /(/",
+		"capture daemon":     "--daemon\n--ready: stdout ok\n--capture:ID /x/",
+		"mode octal":         "--expect-mode: a.txt 0o600",
+		"mode missing":       "--expect-mode: a.txt",
+		"mode twice":         "--expect-mode: a.txt 0600\n--expect-mode: ./a.txt 0644",
+		"symlink target":     "--expect-symlink: current ->",
+		"symlink arrow":      "--expect-symlink: current releases/v2",
+		"symlink path":       "--expect-symlink: ../current -> releases/v2",
+		"symlink twice":      "--expect-symlink: a -> b\n--expect-symlink: ./a -> c",
+		"sha256 checksum":    "--expect-sha256:a.txt 5891b5",
+		"generate lines":     "--stdin-generate: lines=many",
+		"generate no lines":  "--stdin-generate: pattern=x",
+		"generate and stdin": "--stdin-generate: lines=1\n--stdin\nx",
+		"sha256 path":        "--expect-sha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"args before steps":  "--arg:x\n--run\n--arg:y",
+		"ready in run":       "--run\n--ready: port 80",
+		"ready":              "--daemon\n--ready: soon",
+		"output and stdout":  "--stdout\nout\n--output\nout",
+		"pipe first step":    "--run\n--pipe",
+		"pipe daemon":        "--run\n--daemon\n--pipe",
+		"pipe after daemon":  "--daemon\n--run\n--pipe",
+		"pipe and stdin":     "--run\n--run\n--pipe\n--stdin\nx",
+		"run if condition":   "--run\n--run\n--run-if: maybe",
+		"run if first step":  "--run\n--run-if: failed",
+		"run if daemon":      "--run\n--daemon\n--run-if: failed",
+		"run if parallel":    "--run\n--run-parallel\n--run-if: failed",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := exectest.ParseScheme(scheme); err == nil {
+				t.Errorf("Expected error for scheme %q", scheme)
+			}
+		})
+	}
+}
+
+func TestParseSchemeSignals(t *testing.T) {
+	scheme, err := exectest.ParseScheme(`--signal: SIGINT after 2s
+--signal: SIGKILL on server is listening
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+
+	want := []exectest.Signal{
+		{Name: "SIGINT", After: 2 * time.Second},
+		{Name: "SIGKILL", On: "server is listening"},
+	}
+	if diff := cmp.Diff(want, scheme.Signals); diff != "" {
+		t.Errorf("Unexpected signals (-want, +got):\n%s", diff)
+	}
+}
+
+func TestParseSchemeSteps(t *testing.T) {
+	scheme, err := exectest.ParseScheme(`--env:COMMON=1
+--daemon: server
+--arg:--port=8080
+--ready: port 8080
+--run
+--arg:ping
+--stdout
+pong
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+
+	if diff := cmp.Diff([]string{"COMMON=1"}, scheme.Env); diff != "" {
+		t.Errorf("Unexpected env (-want, +got):\n%s", diff)
+	}
+	want := []exectest.Step{
+		{Daemon: true, Program: "server", Ready: exectest.Readiness{Port: "8080"}, Scheme: &exectest.Scheme{Args: []string{"--port=8080"}}},
+		{Scheme: &exectest.Scheme{Args: []string{"ping"}, ExpectedStdout: "pong\n"}},
+	}
+	if diff := cmp.Diff(want, scheme.Steps); diff != "" {
+		t.Errorf("Unexpected steps (-want, +got):\n%s", diff)
+	}
+}
+
+func TestParseSchemeInteraction(t *testing.T) {
+	scheme, err := exectest.ParseScheme(`--interact: 1s
+expect: Name:
+send: gopher
+
+timeout: 3s
+expect: Bye
+send:
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+
+	want := []exectest.InteractionStep{
+		{Expect: "Name:", Timeout: time.Second},
+		{Send: "gopher"},
+		{Expect: "Bye", Timeout: 3 * time.Second},
+		{Send: ""},
+	}
+	if diff := cmp.Diff(want, scheme.Interaction); diff != "" {
+		t.Errorf("Unexpected interaction (-want, +got):\n%s", diff)
+	}
+}
+
+func TestParseSchemeDuplicateNamesOccurrences(t *testing.T) {
+	_, err := exectest.ParseScheme("--file:a.txt\na\n--stdout-excludes\nx\n--file:a.txt\nb\n")
+	if err == nil || err.Error() != `line 5 "--file:a.txt": --file:a.txt is already defined at line 1` {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestParseSchemeErrorLine(t *testing.T) {
+	for name, tc := range map[string]struct {
+		scheme string
+		line   int
+		text   string
+	}{
+		"return code": {"--arg:a\n--return-code: zero\n", 2, "--return-code: zero"},
+		"step":        {"--run\n--arg:a\n--run\n--env:NOVALUE\n", 4, "--env:NOVALUE"},
+		"ready":       {"--daemon\n--arg:a\n--ready: soon\n", 3, "--ready: soon"},
+		"interact":    {"--interact\nwait: prompt\n", 1, "--interact"},
+		"pipe":        {"--daemon\n--run: cat\n--pipe\n", 2, "--run: cat"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := exectest.ParseScheme(tc.scheme)
+			var parseErr *exectest.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a parse error, got %v", err)
+			}
+			if parseErr.Line != tc.line || parseErr.Text != tc.text {
+				t.Errorf("Unexpected error line: want %d %q, got %d %q", tc.line, tc.text, parseErr.Line, parseErr.Text)
+			}
+		})
+	}
+}
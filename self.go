@@ -0,0 +1,126 @@
+package exectest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// selfEnv is the environment variable with the name of the main function the
+// re-executed test binary dispatches to.
+const selfEnv = "EXECTEST_MAIN"
+
+var selfMains = struct {
+	mu    sync.Mutex
+	mains map[string]func() int
+	dir   string
+	// binaries maps paths returned by [Self] to main function names.
+	binaries map[string]string
+}{binaries: make(map[string]string)}
+
+// RunMain lets the test binary impersonate the commands under test, so they
+// are tested without `go build`. It must be called from the TestMain before
+// running tests:
+//
+//	func TestMain(m *testing.M) {
+//		exectest.RunMain(map[string]func() int{
+//			"mytool": mytool.Main,
+//		})
+//		os.Exit(m.Run())
+//	}
+//
+// When the test binary is re-executed by [Execute] for the [Self] binary,
+// RunMain calls the registered main function and exits with its return code.
+// Otherwise it registers the functions and returns.
+func RunMain(mains map[string]func() int) {
+	name, ok := os.LookupEnv(selfEnv)
+	if !ok {
+		selfMains.mu.Lock()
+		defer selfMains.mu.Unlock()
+		selfMains.mains = mains
+		return
+	}
+
+	main, ok := mains[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "exectest: main %q is not registered with RunMain\n", name)
+		os.Exit(2)
+	}
+	os.Unsetenv(selfEnv)
+	os.Exit(main())
+}
+
+// Self returns the binary running the main function registered with
+// [RunMain] as name. The binary is the test binary itself linked under
+// the name, so it has to be executed with [Execute].
+//
+// The link is put to the temporary directory of the process removed by
+// [RemoveTempDirs]. Self panics if the name is not registered or the link
+// can't be created.
+func Self(name string) string {
+	selfMains.mu.Lock()
+	defer selfMains.mu.Unlock()
+	if _, ok := selfMains.mains[name]; !ok {
+		panic(fmt.Sprintf("exectest: main %q is not registered with RunMain", name))
+	}
+	for path, registered := range selfMains.binaries {
+		if registered == name {
+			return path
+		}
+	}
+
+	path, err := linkSelf(name)
+	if err != nil {
+		panic(fmt.Sprintf("exectest: failed to link test binary as %q: %s", name, err))
+	}
+	selfMains.binaries[path] = name
+	return path
+}
+
+func linkSelf(name string) (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if selfMains.dir == "" {
+		dir, err := os.MkdirTemp("", "exectest-self-")
+		if err != nil {
+			return "", err
+		}
+		selfMains.dir = dir
+	}
+	path := filepath.Join(selfMains.dir, name)
+	if err := os.Symlink(executable, path); err != nil {
+		if err := os.Link(executable, path); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// removeSelfLinks removes the directory of the links, the binaries are linked
+// again on the next use.
+func removeSelfLinks() error {
+	selfMains.mu.Lock()
+	defer selfMains.mu.Unlock()
+	if selfMains.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(selfMains.dir)
+	selfMains.dir = ""
+	selfMains.binaries = make(map[string]string)
+	return err
+}
+
+// selfEnvFor returns the environment entry dispatching the binary to its
+// main function if the binary is returned by [Self].
+func selfEnvFor(binary string) (string, bool) {
+	selfMains.mu.Lock()
+	defer selfMains.mu.Unlock()
+	name, ok := selfMains.binaries[binary]
+	if !ok {
+		return "", false
+	}
+	return selfEnv + "=" + name, true
+}
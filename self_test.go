@@ -0,0 +1,76 @@
+package exectest_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestMain(m *testing.M) {
+	exectest.RunMain(map[string]func() int{
+		"greet": greetMain,
+		"serve": serveMain,
+		"ping":  pingMain,
+		"sockd": sockdMain,
+	})
+	code := m.Run()
+	if err := exectest.RemoveTempDirs(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(code)
+}
+
+func greetMain() int {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: greet NAME")
+		return 2
+	}
+	fmt.Printf("hello, %s\n", strings.Join(os.Args[1:], " "))
+	return 0
+}
+
+func TestSelf(t *testing.T) {
+	exectest.Execute(t, exectest.Self("greet"), `
+--arg:gopher
+--stdout
+hello, gopher
+`)
+}
+
+func TestSelfReturnCode(t *testing.T) {
+	exectest.Execute(t, exectest.Self("greet"), `
+--stderr
+usage: greet NAME
+--return-code: 2
+`)
+}
+
+func TestRemoveTempDirsSelf(t *testing.T) {
+	binary := exectest.Self("greet")
+
+	if err := exectest.RemoveTempDirs(); err != nil {
+		t.Fatalf("Failed to remove temporary directories: %s", err)
+	}
+	if _, err := os.Lstat(binary); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the link %s to be removed, got %v", binary, err)
+	}
+	exectest.Execute(t, exectest.Self("greet"), `
+--arg:gopher
+--stdout
+hello, gopher
+`)
+}
+
+func TestSelfUnregisteredPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for unregistered main")
+		}
+	}()
+	exectest.Self("unregistered")
+}
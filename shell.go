@@ -0,0 +1,15 @@
+package exectest
+
+// Shell returns the command line of the shell of the OS running the script
+// passed as the next argument: `sh -c` on Unix and `cmd /c` on Windows. The
+// PowerShell scripts are run with the `--run: powershell` steps explicitly.
+//
+// Example:
+//
+//	shell := exectest.Shell()
+//	cmd := exec.Command(shell[0], append(shell[1:], "echo hello")...)
+//
+// The `--shell:` directive runs the script with it instead of the binary.
+func Shell() []string {
+	return append([]string(nil), shellCommand...)
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package exectest
+
+import "os/exec"
+
+var shellCommand = []string{"sh", "-c"}
+
+func configureShell(*exec.Cmd, string) {}
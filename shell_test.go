@@ -0,0 +1,47 @@
+package exectest_test
+
+import (
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestShell(t *testing.T) {
+	shell := exectest.Shell()
+	out, err := exec.Command(shell[0], append(shell[1:], "echo hello")...).Output()
+	if err != nil {
+		t.Fatalf("Failed to run the shell: %s", err)
+	}
+
+	want := "hello\n"
+	if runtime.GOOS == "windows" {
+		want = "hello\r\n"
+	}
+	if string(out) != want {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+}
+
+func TestExecuteShell(t *testing.T) {
+	exectest.Execute(t, "false", `
+--file:a.txt
+content
+--shell: echo hello && exit 3
+--stdout
+hello
+--return-code: 3
+`)
+}
+
+func TestExecuteShellSteps(t *testing.T) {
+	exectest.Execute(t, "cat", `
+--run
+--shell: echo produced > out.txt
+--run
+--arg:out.txt
+--stdout
+produced
+`)
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package exectest
+
+import (
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+var shellCommand = []string{"cmd", "/c"}
+
+// configureShell passes the script to the cmd.exe as is, it doesn't follow
+// the quoting of the arguments of the other programs.
+func configureShell(cmd *exec.Cmd, script string) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CmdLine = strings.Join(append(shellCommand[:len(shellCommand):len(shellCommand)], script), " ")
+}
@@ -0,0 +1,15 @@
+package exectest
+
+import "testing"
+
+// skipShort skips the scheme of the `--skip-short` directive in the short
+// mode of `go test` and the one of the `--only-short` directive otherwise.
+func skipShort(t testing.TB, scheme *Scheme) {
+	t.Helper()
+	if scheme.SkipShort && testing.Short() {
+		t.Skipf("Scheme is skipped in the short mode")
+	}
+	if scheme.OnlyShort && !testing.Short() {
+		t.Skipf("Scheme is run in the short mode only")
+	}
+}
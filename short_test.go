@@ -0,0 +1,44 @@
+package exectest_test
+
+import (
+	"flag"
+	"strconv"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+// setShort switches the short mode of `go test` for the test.
+func setShort(t *testing.T, short bool) {
+	t.Helper()
+	was := testing.Short()
+	if err := flag.Set("test.short", strconv.FormatBool(short)); err != nil {
+		t.Fatalf("Failed to set the short mode: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = flag.Set("test.short", strconv.FormatBool(was))
+	})
+}
+
+func TestExecuteSkipShort(t *testing.T) {
+	for _, tc := range []struct {
+		short   bool
+		scheme  string
+		skipped bool
+	}{
+		{short: true, scheme: "--skip-short\n", skipped: true},
+		{short: false, scheme: "--skip-short\n"},
+		{short: true, scheme: "--only-short\n"},
+		{short: false, scheme: "--only-short\n", skipped: true},
+	} {
+		setShort(t, tc.short)
+		fake := runFake(t, func(tb testing.TB) {
+			exectest.Execute(tb, "true", tc.scheme)
+		})
+
+		assertPassed(t, fake)
+		if fake.skipped != tc.skipped {
+			t.Errorf("Want skipped %v with the short mode %v for %q, got %v", tc.skipped, tc.short, tc.scheme, fake.skipped)
+		}
+	}
+}
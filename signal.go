@@ -0,0 +1,125 @@
+package exectest
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Signal is the `--signal:` directive: the signal sent to the running
+// process after the delay or once the pattern appears on the stdout.
+//
+//	--signal: SIGINT after 2s
+//	--signal: SIGTERM on server listening
+type Signal struct {
+	// Name of the signal, e.g. SIGINT.
+	Name string
+	// After is the delay since the start of the process.
+	After time.Duration
+	// On is the stdout text the signal is sent on, the After is ignored then.
+	On string
+}
+
+func parseSignal(text string) (Signal, error) {
+	text = strings.TrimSpace(text)
+	malformed := fmt.Errorf("malformed --signal %q, expected <name> after <duration> or <name> on <pattern>", text)
+	name, rest, ok := strings.Cut(text, " ")
+	if !ok {
+		return Signal{}, malformed
+	}
+	if _, ok := signals[name]; !ok {
+		return Signal{}, fmt.Errorf("unsupported signal %q", name)
+	}
+	kind, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	value = strings.TrimSpace(value)
+	switch {
+	case kind == "after":
+		after, err := time.ParseDuration(value)
+		if err != nil {
+			return Signal{}, fmt.Errorf("failed to parse --signal delay %q: %w", value, err)
+		}
+		return Signal{Name: name, After: after}, nil
+	case kind == "on" && value != "":
+		return Signal{Name: name, On: value}, nil
+	}
+	return Signal{}, malformed
+}
+
+// defaultStopSignal is the signal of the `--stop-on:` without explicit one.
+const defaultStopSignal = "SIGTERM"
+
+// parseStopOn parses the `--stop-on: <pattern>` or the
+// `--stop-on: "<pattern>" <name>` directive.
+func parseStopOn(text string) (Signal, error) {
+	text = strings.TrimSpace(text)
+	stop := Signal{Name: defaultStopSignal, On: text}
+	if strings.HasPrefix(text, `"`) {
+		quoted, err := strconv.QuotedPrefix(text)
+		if err != nil {
+			return Signal{}, fmt.Errorf("malformed --stop-on %q, expected <pattern> or \"<pattern>\" <signal>", text)
+		}
+		stop.On, _ = strconv.Unquote(quoted)
+		if name := strings.TrimSpace(text[len(quoted):]); name != "" {
+			stop.Name = name
+		}
+	}
+	if stop.On == "" {
+		return Signal{}, fmt.Errorf("--stop-on must have a pattern")
+	}
+	if _, ok := signals[stop.Name]; !ok {
+		return Signal{}, fmt.Errorf("unsupported signal %q", stop.Name)
+	}
+	return stop, nil
+}
+
+// watcher runs along with the process until it exits and returns failures.
+type watcher func(process *os.Process, stdout *outputBuffer, exited <-chan struct{}) []string
+
+// sendSignal is the watcher delivering the signal, it's recorded to the group.
+func sendSignal(signal Signal, group *processGroup) watcher {
+	return func(process *os.Process, stdout *outputBuffer, exited <-chan struct{}) []string {
+		if signal.On != "" {
+			if _, ok := stdout.waitFor(signal.On, 0, 0, exited); !ok {
+				return []string{fmt.Sprintf("Failed to send %s: %q did not appear on stdout", signal.Name, signal.On)}
+			}
+		} else {
+			timer := time.NewTimer(signal.After)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-exited:
+				return []string{fmt.Sprintf("Failed to send %s: the process exited before %s", signal.Name, signal.After)}
+			}
+		}
+		if err := process.Signal(signals[signal.Name]); err != nil {
+			return []string{fmt.Sprintf("Failed to send %s: %s", signal.Name, err)}
+		}
+		group.sent(signal.Name)
+		return nil
+	}
+}
+
+// startWatchers runs the watchers concurrently, the returned function waits
+// for them to finish and returns their failures.
+func startWatchers(watchers []watcher, process *os.Process, stdout *outputBuffer, exited <-chan struct{}) func() []string {
+	var mu sync.Mutex
+	var failures []string
+	var wg sync.WaitGroup
+	for _, w := range watchers {
+		wg.Add(1)
+		go func(w watcher) {
+			defer wg.Done()
+			result := w(process, stdout, exited)
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, result...)
+		}(w)
+	}
+	return func() []string {
+		wg.Wait()
+		return failures
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !unix
+
+package exectest
+
+import "os"
+
+// signals are supported by the `--signal:` directive, only the killing is
+// portable among the non-Unix platforms, the SIGTERM kills the process as
+// well.
+var signals = map[string]os.Signal{
+	"SIGKILL": os.Kill,
+	"SIGTERM": os.Kill,
+	"SIGINT":  os.Interrupt,
+}
+
+// terminatedExitCode is the exit code of the process killed by [os.Process.Kill]
+// and the Job Object.
+const terminatedExitCode = 1
+
+// termination returns the exit code of the process and the name of the
+// signal terminated it. The process state doesn't tell the signals, so the
+// process exited with the code of the killed ones after the signal is
+// delivered is taken as terminated by it with the -1 exit code, the same as
+// on Unix.
+func termination(state *os.ProcessState, delivered string) (int, string) {
+	code := state.ExitCode()
+	if delivered != "" && code == terminatedExitCode {
+		return -1, delivered
+	}
+	return code, ""
+}
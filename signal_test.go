@@ -0,0 +1,101 @@
+//go:build unix
+
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const trapScript = `trap 'echo stopping; exit 3' INT; echo ready; while :; do sleep 0.01; done`
+
+func TestExecuteSignalOn(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+trapScript+`
+--signal: SIGINT on ready
+--return-code: 3
+--stdout
+ready
+stopping
+`)
+}
+
+func TestExecuteSignalAfter(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+trapScript+`
+--signal: SIGINT after 100ms
+--return-code: 3
+--stdout
+ready
+stopping
+`)
+}
+
+func TestExecuteStopOn(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo server listening; while :; do sleep 0.01; done
+--stop-on: server listening
+--stdout
+server listening
+`)
+}
+
+func TestExecuteStopOnSignal(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+trapScript+`
+--stop-on: "ready" SIGINT
+--return-code: 3
+--stdout
+ready
+stopping
+`)
+}
+
+func TestExecuteSignalProcessExited(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "echo", `
+--arg:hello
+--signal: SIGTERM on bye
+--stdout
+hello
+`)
+	})
+
+	assertFailed(t, fake, `Failed to send SIGTERM: "bye" did not appear on stdout`)
+}
+
+func TestExecuteKilledBy(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:kill -KILL $$
+--killed-by: SIGKILL
+`)
+}
+
+func TestExecuteKilledByExited(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:exit 137
+--killed-by: SIGKILL
+`)
+	})
+
+	assertFailed(t, fake, "Failed to match killing signal: want SIGKILL, got 137")
+}
+
+func TestExecuteReturnCodeKilled(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:kill -TERM $$
+`)
+	})
+
+	assertFailed(t, fake, "Failed to match return code: want 0, got -1 (killed by SIGTERM)")
+}
@@ -0,0 +1,60 @@
+//go:build unix
+
+package exectest
+
+import (
+	"os"
+	"syscall"
+)
+
+// signals are supported by the `--signal:` directive.
+var signals = map[string]os.Signal{
+	"SIGABRT": syscall.SIGABRT,
+	"SIGALRM": syscall.SIGALRM,
+	"SIGBUS":  syscall.SIGBUS,
+	"SIGCHLD": syscall.SIGCHLD,
+	"SIGCONT": syscall.SIGCONT,
+	"SIGFPE":  syscall.SIGFPE,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGILL":  syscall.SIGILL,
+	"SIGINT":  syscall.SIGINT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGPIPE": syscall.SIGPIPE,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGSEGV": syscall.SIGSEGV,
+	"SIGSTOP": syscall.SIGSTOP,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGTRAP": syscall.SIGTRAP,
+	"SIGTSTP": syscall.SIGTSTP,
+	"SIGTTIN": syscall.SIGTTIN,
+	"SIGTTOU": syscall.SIGTTOU,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+	"SIGXCPU": syscall.SIGXCPU,
+	"SIGXFSZ": syscall.SIGXFSZ,
+}
+
+// termination returns the exit code of the process and the name of the
+// signal terminated it, empty if it exited normally. The process state tells
+// the signal, so the delivered one is ignored.
+func termination(state *os.ProcessState, _ string) (int, string) {
+	return state.ExitCode(), terminationSignal(state)
+}
+
+// terminationSignal returns the name of the signal terminated the process,
+// empty if it exited normally.
+func terminationSignal(state *os.ProcessState) string {
+	if state == nil {
+		return ""
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+	for name, signal := range signals {
+		if signal == status.Signal() {
+			return name
+		}
+	}
+	return status.Signal().String()
+}
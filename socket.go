@@ -0,0 +1,106 @@
+package exectest
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// socketPattern matches the `{socket}` and `{socket:NAME}` placeholders.
+var socketPattern = regexp.MustCompile(`\{socket(?::([A-Za-z0-9_-]+))?\}`)
+
+// maxSocketPath is the length of the longest Unix domain socket path
+// portable among the platforms, the sun_path is 104 bytes on macOS and BSDs
+// including the terminating zero.
+const maxSocketPath = 103
+
+// sockets are the `{socket}` placeholders of a scheme, every name gets its
+// own path in the scheme directory on the first use. The paths are put to a
+// short temporary directory instead if they don't fit the [maxSocketPath].
+type sockets struct {
+	t      testing.TB
+	dir    string
+	mu     sync.Mutex
+	byName map[string]string
+	short  string
+}
+
+func newSockets(t testing.TB, dir string) *sockets {
+	return &sockets{t: t, dir: dir, byName: make(map[string]string)}
+}
+
+func (s *sockets) replace(data string) string {
+	return socketPattern.ReplaceAllStringFunc(data, func(placeholder string) string {
+		name := socketPattern.FindStringSubmatch(placeholder)[1]
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if path, ok := s.byName[name]; ok {
+			return path
+		}
+		path := s.allocate(socketFile(name))
+		s.byName[name] = path
+		return path
+	})
+}
+
+// socketFile is the name of the socket file of the placeholder name.
+func socketFile(name string) string {
+	if name == "" {
+		return "s.sock"
+	}
+	return name + ".sock"
+}
+
+// allocate returns the path of the socket file, the short directory is
+// created on the first path too long for the scheme directory.
+func (s *sockets) allocate(file string) string {
+	s.t.Helper()
+	if path := filepath.Join(s.dir, file); len(path) <= maxSocketPath {
+		return path
+	}
+	if s.short == "" {
+		dir, err := os.MkdirTemp(shortTempDir(), "exectest-")
+		if err != nil {
+			s.t.Fatalf("Failed to create socket directory: %s", err)
+		}
+		s.t.Cleanup(func() { _ = os.RemoveAll(dir) })
+		s.short = dir
+	}
+	return filepath.Join(s.short, file)
+}
+
+// shortTempDir returns the /tmp on Unix if the [os.TempDir] is long, e.g.
+// the per-user one of macOS.
+func shortTempDir() string {
+	if dir := os.TempDir(); len(dir) <= 32 {
+		return dir
+	}
+	if info, err := os.Stat("/tmp"); err == nil && info.IsDir() {
+		return "/tmp"
+	}
+	return os.TempDir()
+}
+
+// AssertSocket fails the test unless the path is a Unix domain socket.
+func AssertSocket(t testing.TB, path string) {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Errorf("Failed to find socket %s: %s", path, err)
+		return
+	}
+	if info.Mode().Type() != os.ModeSocket {
+		t.Errorf("Failed to find socket %s: got %s", path, describeMode(info.Mode()))
+	}
+}
+
+// AssertNoSocket fails the test if the path exists, e.g. the socket isn't
+// removed by the binary on exit.
+func AssertNoSocket(t testing.TB, path string) {
+	t.Helper()
+	if info, err := os.Lstat(path); err == nil {
+		t.Errorf("Failed to match removed socket %s: got %s", path, describeMode(info.Mode()))
+	}
+}
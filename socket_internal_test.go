@@ -0,0 +1,20 @@
+package exectest
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSocketsLongDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), strings.Repeat("d", maxSocketPath))
+	s := newSockets(t, dir)
+
+	path := s.replace("{socket:ctl}")
+	if len(path) > maxSocketPath || strings.HasPrefix(path, dir) || filepath.Base(path) != "ctl.sock" {
+		t.Errorf("Expected the short socket path outside of %s, got %s", dir, path)
+	}
+	if again := s.replace("{socket:ctl}"); again != path {
+		t.Errorf("Expected the same socket path %s, got %s", path, again)
+	}
+}
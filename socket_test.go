@@ -0,0 +1,83 @@
+package exectest_test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+// sockdMain listens on the Unix domain socket of the first argument, the
+// socket file is left behind with the `keep` second argument.
+func sockdMain() int {
+	listener, err := net.Listen("unix", os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(os.Args) > 2 && os.Args[2] == "keep" {
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	}
+	fmt.Println("listening")
+	_ = listener.Close()
+	return 0
+}
+
+func TestExecuteSocketPlaceholder(t *testing.T) {
+	var sockets []string
+	exectest.Execute(t, exectest.Self("sockd"), `
+--arg:{socket:ctl}
+--arg:keep
+--stdout
+listening
+`, exectest.WithAfterRun(func(t testing.TB, run exectest.Run) {
+		socket := run.Expand("{socket:ctl}")
+		sockets = append(sockets, socket, run.Expand("{socket}"))
+		exectest.AssertSocket(t, socket)
+		if filepath.Dir(socket) != run.Dir && len(run.Dir) < 90 {
+			t.Errorf("Expected the socket in the scheme directory %s, got %s", run.Dir, socket)
+		}
+	}))
+
+	if len(sockets) != 2 || sockets[0] == sockets[1] || filepath.Base(sockets[0]) != "ctl.sock" {
+		t.Errorf("Expected distinct socket paths, got %v", sockets)
+	}
+}
+
+func TestExecuteSocketRemoved(t *testing.T) {
+	exectest.Execute(t, exectest.Self("sockd"), `
+--arg:{socket}
+--stdout
+listening
+`, exectest.WithAfterRun(func(t testing.TB, run exectest.Run) {
+		exectest.AssertNoSocket(t, run.Expand("{socket}"))
+	}))
+}
+
+func TestAssertSocketFailure(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.sock")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %s", err)
+	}
+
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.AssertSocket(tb, file)
+		exectest.AssertSocket(tb, filepath.Join(filepath.Dir(file), "missing.sock"))
+		exectest.AssertNoSocket(tb, file)
+	})
+
+	assertFailed(t, fake,
+		"Failed to find socket "+file+": got regular file",
+		"Failed to find socket",
+		"Failed to match removed socket "+file+": got regular file",
+	)
+}
+
+func TestRunExpandZeroValue(t *testing.T) {
+	if got := (exectest.Run{}).Expand("{socket:ctl}"); got != "{socket:ctl}" {
+		t.Errorf("Expected the text unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,167 @@
+package exectest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	// spoolHunks is the number of differing hunks reported for spooled output.
+	spoolHunks = 5
+	// spoolHunkLines is the number of lines kept per side of a hunk.
+	spoolHunkLines = 10
+)
+
+// WithOutputSpool spools stdout and stderr of the binary to temporary files in
+// the dir instead of keeping them in memory. The spooled output is compared
+// line by line reporting only the first differing hunks, so memory stays flat
+// for commands producing huge outputs.
+//
+// The files are removed after the test unless it fails.
+func WithOutputSpool(dir string) Option {
+	return func(c *config) {
+		c.spoolDir = dir
+	}
+}
+
+// newSpool creates a spooled output in the dir removed by the cleanup.
+func newSpool(t testing.TB, dir, name string, cleanup func(func())) *outputBuffer {
+	t.Helper()
+	file, err := os.CreateTemp(dir, name+"-*")
+	if err != nil {
+		t.Fatalf("Failed to create %s spool: %s", name, err)
+	}
+	cleanup(func() {
+		_ = file.Close()
+		if t.Failed() {
+			t.Logf("Spooled %s is kept in %s", name, file.Name())
+			return
+		}
+		_ = os.Remove(file.Name())
+	})
+	return newSpooledOutput(file)
+}
+
+// withSpooled returns the result with the spooled output read into memory.
+func (r executionResult) withSpooled() (executionResult, error) {
+	for _, output := range []struct {
+		spool string
+		to    *string
+	}{
+		{r.StdoutSpool, &r.Stdout},
+		{r.StderrSpool, &r.Stderr},
+	} {
+		if output.spool == "" {
+			continue
+		}
+		content, err := os.ReadFile(output.spool)
+		if err != nil {
+			return r, err
+		}
+		*output.to = string(content)
+	}
+	return r, nil
+}
+
+// spoolHunk is a differing range of lines.
+type spoolHunk struct {
+	line           int
+	missing, extra []string
+	dropped        int
+}
+
+func (h *spoolHunk) add(lines *[]string, line string) {
+	if len(*lines) == spoolHunkLines {
+		h.dropped++
+		return
+	}
+	*lines = append(*lines, strings.TrimSuffix(line, "\n"))
+}
+
+// checkNoSpoolDiff compares the spooled output with the expected one line by
+// line without reading the whole spool in memory.
+func checkNoSpoolDiff(name, want, spool string, opts []cmp.Option) (mismatch, bool) {
+	file, err := os.Open(spool)
+	if err != nil {
+		return mismatch{message: fmt.Sprintf("Failed to read spooled %s: %s", name, err)}, false
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+
+	wantLines := toLines(want)
+	var hunks []*spoolHunk
+	var current *spoolHunk
+	var truncated bool
+	for i := 0; ; i++ {
+		gotLine, gotOK := readSpoolLine(reader)
+		wantOK := i < len(wantLines)
+		if !gotOK && !wantOK {
+			break
+		}
+		if gotOK && wantOK && equalLines(wantLines[i], gotLine, opts) {
+			current = nil
+			continue
+		}
+		if current == nil {
+			if len(hunks) == spoolHunks {
+				truncated = true
+				break
+			}
+			current = &spoolHunk{line: i + 1}
+			hunks = append(hunks, current)
+		}
+		if wantOK {
+			current.add(&current.missing, wantLines[i])
+		}
+		if gotOK {
+			current.add(&current.extra, gotLine)
+		}
+	}
+	if len(hunks) == 0 {
+		return mismatch{}, true
+	}
+
+	var diff strings.Builder
+	for _, hunk := range hunks {
+		fmt.Fprintf(&diff, "@@ line %d @@\n", hunk.line)
+		for _, line := range hunk.missing {
+			fmt.Fprintf(&diff, "-%s\n", line)
+		}
+		for _, line := range hunk.extra {
+			fmt.Fprintf(&diff, "+%s\n", line)
+		}
+		if hunk.dropped > 0 {
+			fmt.Fprintf(&diff, "... %d more lines\n", hunk.dropped)
+		}
+	}
+	if truncated {
+		fmt.Fprintf(&diff, "... more hunks\n")
+	}
+	return mismatch{
+		message: fmt.Sprintf("Failed matching %s (-missing line, +extra line): \n%s", name, diff.String()),
+		output:  fmt.Sprintf("%s is spooled to %s", name, spool),
+	}, false
+}
+
+func equalLines(want, got string, opts []cmp.Option) bool {
+	if len(opts) == 0 {
+		return want == got
+	}
+	return cmp.Equal(want, got, opts...)
+}
+
+// readSpoolLine reads the next line the same way [toLines] splits them.
+func readSpoolLine(reader *bufio.Reader) (string, bool) {
+	line, err := reader.ReadString('\n')
+	if line == "" && err != nil {
+		return "", false
+	}
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return line + "\n", true
+}
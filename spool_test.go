@@ -0,0 +1,79 @@
+package exectest_test
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteOutputSpool(t *testing.T) {
+	dir := t.TempDir()
+	t.Run("spooled", func(t *testing.T) {
+		exectest.Execute(t, "sh", `
+--arg:-c
+--arg:echo out; echo err >&2
+--stdout
+out
+--stderr
+err
+`, exectest.WithOutputSpool(dir))
+	})
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read spool dir: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected spool files to be removed, got %d", len(entries))
+	}
+}
+
+func TestExecuteOutputSpoolMismatch(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "seq", `
+--arg:100
+--stdout
+`+strings.Repeat("x\n", 101), exectest.WithOutputSpool(t.TempDir()))
+	})
+
+	assertFailed(t, fake,
+		"Failed matching stdout (-missing line, +extra line): \n@@ line 1 @@\n-x\n",
+		"+1\n",
+		"... 181 more lines\n",
+	)
+}
+
+func TestExecuteOutputSpoolHunks(t *testing.T) {
+	var want strings.Builder
+	for i := 1; i <= 20; i++ {
+		if i%2 == 0 {
+			want.WriteString("x\n")
+			continue
+		}
+		want.WriteString(strconv.Itoa(i) + "\n")
+	}
+
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "seq", `
+--arg:20
+--stdout
+`+want.String(), exectest.WithOutputSpool(t.TempDir()))
+	})
+
+	assertFailed(t, fake, "@@ line 2 @@\n-x\n+2\n", "@@ line 10 @@\n-x\n+10\n", "... more hunks\n")
+}
+
+func TestExecuteOutputSpoolInteract(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:printf "Name: "; read name; echo "hi, $name"
+--interact: 2s
+expect: Name:
+send: gopher
+--stdout
+Name: hi, gopher
+`, exectest.WithOutputSpool(t.TempDir()))
+}
@@ -0,0 +1,209 @@
+package exectest
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// SSHRunner is the [Runner] executing the binary on a remote host with the
+// ssh command. The files of the scheme directory are uploaded to the same path
+// on the host, so the `{dir}` placeholder stays valid, and the directory is
+// downloaded back once the binary exits. The host needs sh and tar.
+type SSHRunner struct {
+	// Host is the destination of ssh, e.g. user@example.com.
+	Host string
+	// Options are passed to ssh before the host, e.g. -i and -p.
+	Options []string
+	// Program is the ssh client, "ssh" by default.
+	Program string
+}
+
+// Command uploads the scheme directory and returns the ssh cmd running the
+// binary in it, the remote directory is removed in the test cleanup.
+func (r SSHRunner) Command(t testing.TB, invocation Invocation) (*exec.Cmd, func() error, error) {
+	dir := invocation.Dir
+	if err := r.upload(dir); err != nil {
+		return nil, nil, fmt.Errorf("failed to upload %s to %s: %w", dir, r.Host, err)
+	}
+	t.Cleanup(func() {
+		// the directory is temporary anyway, the cleanup is best effort
+		_ = r.ssh("rm -rf " + shellQuote(dir)).Run()
+	})
+
+	script := "cd " + shellQuote(dir) + " && exec "
+	if len(invocation.Env) > 0 {
+		script += "env " + shellJoin(invocation.Env) + " "
+	}
+	script += shellJoin(append([]string{invocation.Binary}, invocation.Args...))
+	finish := func() error {
+		if err := r.download(dir); err != nil {
+			return fmt.Errorf("failed to download %s from %s: %w", dir, r.Host, err)
+		}
+		return nil
+	}
+	return r.ssh(script), finish, nil
+}
+
+func (r SSHRunner) ssh(script string) *exec.Cmd {
+	program := r.Program
+	if program == "" {
+		program = "ssh"
+	}
+	args := append(append([]string(nil), r.Options...), r.Host, script)
+	return exec.Command(program, args...)
+}
+
+// upload extracts the archive of the local dir into the same path on the host.
+func (r SSHRunner) upload(dir string) error {
+	var archive bytes.Buffer
+	if err := writeTar(&archive, dir); err != nil {
+		return err
+	}
+	cmd := r.ssh("mkdir -p " + shellQuote(dir) + " && tar -C " + shellQuote(dir) + " -xf -")
+	cmd.Stdin = &archive
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// download replaces the content of the local dir with the one of the host.
+func (r SSHRunner) download(dir string) error {
+	var archive, stderr bytes.Buffer
+	cmd := r.ssh("tar -C " + shellQuote(dir) + " -cf - .")
+	cmd.Stdout = &archive
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return readTar(&archive, dir)
+}
+
+// writeTar archives the content of the dir with the paths relative to it.
+func writeTar(w io.Writer, dir string) error {
+	archive := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// readTar extracts the directories, regular files and symlinks of the archive
+// into the dir, the other entries are skipped.
+func readTar(r io.Reader, dir string) error {
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if name == "." {
+			continue
+		}
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("archive entry %q is outside of the directory", header.Name)
+		}
+		path := filepath.Join(dir, name)
+		mode := header.FileInfo().Mode().Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, mode)
+		case tar.TypeReg:
+			err = extractFile(archive, path, mode)
+		case tar.TypeSymlink:
+			err = os.Symlink(header.Linkname, path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractFile(r io.Reader, path string, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// shellQuote quotes the word for the POSIX shell.
+func shellQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+func shellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = shellQuote(word)
+	}
+	return strings.Join(quoted, " ")
+}
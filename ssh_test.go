@@ -0,0 +1,46 @@
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+// fakeSSH is the ssh client running the remote command locally.
+const fakeSSH = `#!/bin/sh
+while [ $# -gt 2 ]; do shift; done
+[ "$1" = user@example.test ] || { echo "unexpected host $1" >&2; exit 255; }
+exec sh -c "$2"
+`
+
+func TestExecuteSSHRunner(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "ssh")
+	if err := os.WriteFile(program, []byte(fakeSSH), 0o755); err != nil {
+		t.Fatalf("Failed to write fake ssh: %s", err)
+	}
+	runner := exectest.SSHRunner{
+		Host:    "user@example.test",
+		Options: []string{"-p", "2222"},
+		Program: program,
+	}
+
+	exectest.Execute(t, "sh", `
+--file:sub/in.txt
+hello
+--arg:-c
+--arg:cat sub/in.txt; echo "$GREETING" > out.txt; read line; echo "$line it's {dir}"; exit 3
+--env:GREETING=hi
+--stdin
+from stdin
+--stdout
+hello
+from stdin it's {dir}
+--return-code:3
+--expect-tree
+out.txt
+sub/in.txt
+--expect-sha256:out.txt 98ea6e4f216f2fb4b69fff9b3a44842c38686ca685f3f55dc48c5d3fb1107be4
+`, exectest.WithRunner(runner))
+}
@@ -0,0 +1,39 @@
+package exectest
+
+import "fmt"
+
+// startError is the failure to start the binary, e.g. it doesn't exist or
+// isn't executable.
+type startError struct {
+	err error
+}
+
+func (e *startError) Error() string {
+	return e.err.Error()
+}
+
+func (e *startError) Unwrap() error {
+	return e.err
+}
+
+// checkStart checks the start error against the `--expect-start-error:`
+// pattern, matched the same way as the excludes are. It reports whether the
+// rest of the results are to be checked: the binary started as expected.
+func checkStart(want schemeResult, got executionResult) ([]mismatch, bool) {
+	switch {
+	case want.StartError == "" && got.StartError == "":
+		return nil, true
+	case want.StartError == "":
+		return []mismatch{{message: fmt.Sprintf("Failed to start the binary: %s", got.StartError)}}, false
+	case got.StartError == "":
+		return []mismatch{{message: fmt.Sprintf("Failed to match start error: want %q, the binary started", want.StartError)}}, false
+	}
+	match, err := compileExclude(want.StartError)
+	if err != nil {
+		return []mismatch{{message: err.Error()}}, false
+	}
+	if !match(got.StartError) {
+		return []mismatch{{message: fmt.Sprintf("Failed to match start error: want %q, got %q", want.StartError, got.StartError)}}, false
+	}
+	return nil, false
+}
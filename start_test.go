@@ -0,0 +1,55 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteExpectStartError(t *testing.T) {
+	exectest.Execute(t, "./missing-binary", `
+--expect-start-error: re:no such file|not found
+`)
+}
+
+func TestExecuteExpectStartErrorNotExecutable(t *testing.T) {
+	exectest.Execute(t, "./script.sh", `
+--file:script.sh
+echo never
+--expect-start-error: permission denied
+`)
+}
+
+func TestExecuteStartErrorIsReported(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "./missing-binary", `
+--stdout
+never
+`)
+	})
+
+	assertFailed(t, fake, "Failed to start the binary: fork/exec ./missing-binary")
+	if len(fake.errors) != 1 {
+		t.Errorf("Expected a single failure, got %q", fake.errors)
+	}
+}
+
+func TestExecuteExpectStartErrorStarted(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "true", `
+--expect-start-error: not found
+`)
+	})
+
+	assertFailed(t, fake, `Failed to match start error: want "not found", the binary started`)
+}
+
+func TestExecuteExpectStartErrorMismatch(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "./missing-binary", `
+--expect-start-error: permission denied
+`)
+	})
+
+	assertFailed(t, fake, `Failed to match start error: want "permission denied", got "fork/exec ./missing-binary`)
+}
@@ -0,0 +1,150 @@
+package exectest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeOperators are the comparisons of the size condition, the longer ones
+// go first.
+var sizeOperators = []string{">=", "<=", "=", ">", "<"}
+
+// FileStat is the `--expect-stat:<path> <condition>...` directive, the
+// metadata of the file in the scheme directory after the execution. The
+// conditions are `size` compared with `=`, `<`, `>`, `<=` or `>=`,
+// `mode=<octal>` permission bits and `fresh` telling the file is created or
+// modified by the execution.
+//
+//	--expect-stat: id_ed25519 size>0 mode=0600 fresh
+type FileStat struct {
+	// Path relative to the scheme directory.
+	Path string
+	// Size is compared with the SizeOperator if set.
+	Size         *int64
+	SizeOperator string
+	// Mode is the permission bits checked if set.
+	Mode *fs.FileMode
+	// Fresh requires the file to be created or modified by the execution.
+	Fresh bool
+}
+
+func parseFileStat(text string) (FileStat, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return FileStat{}, fmt.Errorf("malformed --expect-stat %q, expected <path> <condition>...", strings.TrimSpace(text))
+	}
+	stat := FileStat{Path: slashPath(fields[0])}
+	if !filepath.IsLocal(stat.Path) {
+		return FileStat{}, fmt.Errorf("file path %q must be local to the scheme directory", stat.Path)
+	}
+	for _, condition := range fields[1:] {
+		if condition == "fresh" {
+			stat.Fresh = true
+			continue
+		}
+		if mode, ok := strings.CutPrefix(condition, "mode="); ok {
+			parsed, err := strconv.ParseUint(mode, 8, 32)
+			if err != nil {
+				return FileStat{}, fmt.Errorf("failed to convert mode of %q to octal: %w", stat.Path, err)
+			}
+			perm := fs.FileMode(parsed).Perm()
+			stat.Mode = &perm
+			continue
+		}
+		if err := stat.parseSize(condition); err != nil {
+			return FileStat{}, err
+		}
+	}
+	return stat, nil
+}
+
+// parseFileMode parses the `--expect-mode:<path> <octal>` directive as the
+// stat of the permission bits.
+func parseFileMode(text string) (FileStat, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return FileStat{}, fmt.Errorf("malformed --expect-mode %q, expected <path> <octal>", strings.TrimSpace(text))
+	}
+	return parseFileStat(fields[0] + " mode=" + fields[1])
+}
+
+func (s *FileStat) parseSize(condition string) error {
+	rest, ok := strings.CutPrefix(condition, "size")
+	if !ok {
+		return fmt.Errorf("unknown stat condition %q of %q", condition, s.Path)
+	}
+	for _, operator := range sizeOperators {
+		if size, ok := strings.CutPrefix(rest, operator); ok {
+			parsed, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to convert size of %q to int: %w", s.Path, err)
+			}
+			s.Size, s.SizeOperator = &parsed, operator
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown stat condition %q of %q", condition, s.Path)
+}
+
+func compareSize(size int64, operator string, want int64) bool {
+	switch operator {
+	case ">=":
+		return size >= want
+	case "<=":
+		return size <= want
+	case ">":
+		return size > want
+	case "<":
+		return size < want
+	}
+	return size == want
+}
+
+// modTimes records the modification times of the existing fresh files, the
+// files not recorded are fresh once they exist.
+func modTimes(dir string, stats []FileStat) map[string]time.Time {
+	times := make(map[string]time.Time)
+	for _, stat := range stats {
+		if !stat.Fresh {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, stat.Path)); err == nil {
+			times[stat.Path] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// checkStats checks the metadata of the files of the dir, the freshness is
+// checked against the modification times recorded before the execution.
+func checkStats(dir string, want []FileStat, before map[string]time.Time) []mismatch {
+	var mismatches []mismatch
+	for _, stat := range want {
+		info, err := os.Stat(filepath.Join(dir, stat.Path))
+		if err != nil {
+			mismatches = append(mismatches, mismatch{message: fmt.Sprintf("Failed to stat %s: %s", stat.Path, err)})
+			continue
+		}
+		if stat.Size != nil && !compareSize(info.Size(), stat.SizeOperator, *stat.Size) {
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match size of %s: want %s%d, got %d", stat.Path, stat.SizeOperator, *stat.Size, info.Size()),
+			})
+		}
+		if got := info.Mode().Perm() & modeMask; stat.Mode != nil && got != *stat.Mode&modeMask {
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match mode of %s: want %04o, got %04o", stat.Path, uint32(*stat.Mode&modeMask), uint32(got)),
+			})
+		}
+		if modTime, ok := before[stat.Path]; stat.Fresh && ok && info.ModTime().Equal(modTime) {
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match freshness of %s: the file is not modified by the execution", stat.Path),
+			})
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,6 @@
+//go:build !windows
+
+package exectest
+
+// modeMask is the permission bits compared by the mode assertions.
+const modeMask = 0o777
@@ -0,0 +1,64 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteExpectStat(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--file:config.toml
+debug = false
+--arg:-c
+--arg:umask 077; echo secret > id_ed25519; sleep 0.05; echo 'debug = true' > config.toml
+--expect-stat: id_ed25519 size>0 size<=7 mode=0600 fresh
+--expect-stat: config.toml size=13 fresh
+`)
+}
+
+func TestExecuteExpectStatFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--file:config.toml
+debug = false
+--arg:-c
+--arg:umask 022; touch id_ed25519
+--expect-stat: id_ed25519 size>0 mode=0600
+--expect-stat: config.toml fresh
+--expect-stat: missing.txt size=0
+`)
+	})
+
+	assertFailed(t, fake,
+		"Failed to match size of id_ed25519: want >0, got 0",
+		"Failed to match mode of id_ed25519: want 0600, got 0644",
+		"Failed to match freshness of config.toml",
+		"Failed to stat missing.txt",
+	)
+}
+
+func TestExecuteExpectMode(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:umask 022; echo secret > secrets.txt; chmod 600 secrets.txt; mkdir bin; touch bin/run; chmod 755 bin/run
+--expect-mode: secrets.txt 0600
+--expect-mode: bin/run 755
+`)
+}
+
+func TestExecuteExpectModeFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:umask 022; touch secrets.txt
+--expect-mode: secrets.txt 0600
+--expect-mode: missing.txt 0600
+`)
+	})
+
+	assertFailed(t, fake,
+		"Failed to match mode of secrets.txt: want 0600, got 0644",
+		"Failed to stat missing.txt",
+	)
+}
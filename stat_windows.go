@@ -0,0 +1,7 @@
+//go:build windows
+
+package exectest
+
+// modeMask is the permission bits compared by the mode assertions, Windows
+// only tells the read-only files from the writable ones.
+const modeMask = 0o200
@@ -0,0 +1,365 @@
+package exectest
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// defaultReadyTimeout is the time the daemon is waited to become ready for.
+const defaultReadyTimeout = 10 * time.Second
+
+// Step is a `--run[: <program>]`, `--run-parallel[: <program>]` or
+// `--daemon[: <program>]` step of the scheme. The steps are run one by one
+// in the same scheme directory, the daemons are running in the background
+// until the end of the scheme. The retries and the update mode don't apply to
+// the schemes with steps.
+//
+//	--daemon: ./server
+//	--arg:--port=8080
+//	--ready: port 8080
+//	--run: ./client
+//	--arg:ping
+//	--stdout
+//	pong
+type Step struct {
+	// Daemon is set for the `--daemon` step.
+	Daemon bool
+	// Parallel is set for the `--run-parallel` step, the consecutive parallel
+	// steps are started together and asserted in order once all of them exit.
+	Parallel bool
+	// Program is the executable of the step, the binary under test if empty.
+	Program string
+	// Ready is the `--ready:` condition the daemon is waited for.
+	Ready Readiness
+	// RunIf is the `--run-if: failed` or `--run-if: succeeded` condition on
+	// the last executed `--run` step, the step is skipped if it's not met.
+	// The termination of the step before the conditional one is not asserted.
+	RunIf string
+	// Pipe is the `--pipe` directive, the stdout of the previous step is the
+	// stdin of the step. The stdout of the previous step is asserted only if
+	// it has the `--stdout` block.
+	Pipe bool
+	// Captures are the `--capture:` directives of the `--run` step, the
+	// values of the placeholders of the later steps.
+	Captures []Capture
+	// Scheme of the step: files, arguments, environment appended to the one
+	// of the scheme, input and expectations.
+	Scheme *Scheme
+}
+
+// Readiness is the `--ready: stdout <text>` or `--ready: port <port>`
+// condition of the daemon.
+type Readiness struct {
+	// Stdout is the text the daemon writes once ready.
+	Stdout string
+	// Port is the TCP port accepting connections once the daemon is ready,
+	// a number or a `{port}` placeholder.
+	Port string
+}
+
+// The conditions of the `--run-if:` steps.
+const (
+	runIfFailed    = "failed"
+	runIfSucceeded = "succeeded"
+)
+
+// stepRun is the executed `--run` step.
+type stepRun struct {
+	program  string
+	prepared schemeResult
+	got      executionResult
+}
+
+type stepSection struct {
+	header string
+	// line is the number of the header line in the scheme.
+	line int
+	body strings.Builder
+}
+
+// splitSteps splits the scheme into the head and the step sections.
+func splitSteps(scheme string) (string, []*stepSection) {
+	var head strings.Builder
+	var sections []*stepSection
+	var heredoc heredocBody
+	for i, line := range toLines(scheme) {
+		inHeredoc := heredoc.inside(line)
+		_, isRun := cutHeader(line, runHeader)
+		_, isDaemon := cutHeader(line, daemonHeader)
+		_, isParallel := cutHeader(line, runParallelHeader)
+		switch {
+		case (isRun || isDaemon || isParallel) && !inHeredoc:
+			sections = append(sections, &stepSection{header: line, line: i + 1})
+		case len(sections) == 0:
+			head.WriteString(line)
+		default:
+			sections[len(sections)-1].body.WriteString(line)
+		}
+	}
+	return head.String(), sections
+}
+
+// cutHeader returns the value of the `<name>[: <value>]` header line.
+func cutHeader(line, name string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), name)
+	if !ok || (rest != "" && rest[0] != ':') {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(rest, ":")), true
+}
+
+func parseStep(section *stepSection) (Step, error) {
+	var step Step
+	step.Program, step.Daemon = cutHeader(section.header, daemonHeader)
+	if !step.Daemon {
+		step.Program, step.Parallel = cutHeader(section.header, runParallelHeader)
+	}
+	if !step.Daemon && !step.Parallel {
+		step.Program, _ = cutHeader(section.header, runHeader)
+	}
+
+	body := section.body.String()
+	var heredoc heredocBody
+	pipeLine := 0
+	for i, line := range toLines(body) {
+		if heredoc.inside(line) {
+			continue
+		}
+		if strings.HasPrefix(line, namePrefix) || strings.HasPrefix(line, skipShortPrefix) || strings.HasPrefix(line, onlyShortPrefix) {
+			return Step{}, newParseError(section.line+i+1, line, fmt.Errorf("%s must be defined before the steps", definitionName(line)))
+		}
+		if condition, ok := strings.CutPrefix(line, runIfPrefix); ok {
+			condition = strings.TrimSpace(condition)
+			if step.Daemon {
+				return Step{}, newParseError(section.line+i+1, line, fmt.Errorf("--run-if can only be used in --run steps"))
+			}
+			if condition != runIfFailed && condition != runIfSucceeded {
+				return Step{}, newParseError(section.line+i+1, line, fmt.Errorf("malformed --run-if %q, expected %s or %s", condition, runIfFailed, runIfSucceeded))
+			}
+			step.RunIf = condition
+			continue
+		}
+		if captureText, ok := strings.CutPrefix(line, capturePrefix); ok {
+			if step.Daemon {
+				return Step{}, newParseError(section.line+i+1, line, fmt.Errorf("--capture can only be used in --run steps"))
+			}
+			capture, err := parseCapture(captureText)
+			if err != nil {
+				return Step{}, newParseError(section.line+i+1, line, err)
+			}
+			step.Captures = append(step.Captures, capture)
+			continue
+		}
+		if strings.HasPrefix(line, pipePrefix) {
+			if step.Daemon {
+				return Step{}, newParseError(section.line+i+1, line, fmt.Errorf("--pipe can only be used in --run steps"))
+			}
+			step.Pipe, pipeLine = true, section.line+i+1
+			continue
+		}
+		ready, ok := strings.CutPrefix(line, readyPrefix)
+		if !ok {
+			continue
+		}
+		if !step.Daemon {
+			return Step{}, newParseError(section.line+i+1, line, fmt.Errorf("--ready can only be used in --daemon steps"))
+		}
+		var err error
+		step.Ready, err = parseReadiness(ready)
+		if err != nil {
+			return Step{}, newParseError(section.line+i+1, line, err)
+		}
+	}
+
+	var err error
+	step.Scheme, err = parseSection(body, section.line+1)
+	if err != nil {
+		return Step{}, err
+	}
+	if step.Program != "" && step.Scheme.Shell != "" {
+		return Step{}, newParseError(section.line, section.header, fmt.Errorf("--shell can't be used in steps with a program"))
+	}
+	if step.Parallel && (step.Pipe || step.RunIf != "") {
+		return Step{}, newParseError(section.line, section.header, fmt.Errorf("--pipe and --run-if can't be used in --run-parallel steps"))
+	}
+	if step.Pipe && (step.Scheme.Stdin != "" || step.Scheme.StdinGenerator != nil || step.Scheme.Interaction != nil) {
+		return Step{}, newParseError(pipeLine, pipePrefix, fmt.Errorf("--pipe can't be used with --stdin, --stdin-generate or --interact"))
+	}
+	return step, nil
+}
+
+func parseReadiness(text string) (Readiness, error) {
+	kind, value, _ := strings.Cut(strings.TrimSpace(text), " ")
+	value = strings.TrimSpace(value)
+	switch {
+	case kind == "stdout" && value != "":
+		return Readiness{Stdout: value}, nil
+	case kind == "port" && value != "":
+		return Readiness{Port: value}, nil
+	}
+	return Readiness{}, fmt.Errorf("malformed --ready %q, expected stdout <text> or port <port>", text)
+}
+
+// definesCommand reports whether the scheme has directives of the binary
+// execution that belong to steps.
+func definesCommand(scheme *Scheme) bool {
+	return len(scheme.Args) > 0 || scheme.Shell != "" || scheme.Stdin != "" || scheme.ExpectedStdout != "" ||
+		scheme.ExpectedStderr != "" || scheme.CombinedOutput || scheme.ExpectedReturnCode != 0 || scheme.ExpectedReturnCodes != nil ||
+		scheme.ExpectedKilledBy != "" || scheme.Interaction != nil || scheme.Signals != nil ||
+		scheme.PTY != nil || scheme.ExpectedStartError != ""
+}
+
+// executeSteps runs the steps of the scheme in the same directory stopping at
+// the first failed one. The daemons are killed at the end.
+func executeSteps(t testing.TB, binary string, scheme *Scheme, cfg *config) {
+	t.Helper()
+	dir := schemeDir(t, cfg)
+	vars := resolveVariables(t, dir, cfg)
+	common := prepareIn(t, dir, vars, scheme, cfg)
+	common.Env = append(configEnv(t, dir, cfg), common.Env...)
+
+	var daemons []*daemon
+	defer func() {
+		for i := len(daemons) - 1; i >= 0; i-- {
+			daemons[i].stop(t)
+		}
+	}()
+
+	programOf := func(step Step) string {
+		if step.Program != "" {
+			return evaluateVariables(step.Program, vars)
+		}
+		return binary
+	}
+	var piped string
+	prepareStep := func(step Step) schemeResult {
+		prepared := prepareIn(t, dir, vars, step.Scheme, cfg)
+		if step.Pipe {
+			prepared.Stdin = piped
+		}
+		prepared.Env = append(append([]string(nil), common.Env...), prepared.Env...)
+		prepared.ReadOnly = prepared.ReadOnly || common.ReadOnly
+		prepared.CleanEnv = prepared.CleanEnv || common.CleanEnv
+		prepared.InheritEnv = append(append([]string(nil), common.InheritEnv...), prepared.InheritEnv...)
+		if prepared.FinalNewline == "" {
+			prepared.FinalNewline = common.FinalNewline
+		}
+		for _, limit := range common.Limits {
+			// the limits of the step override the common ones.
+			if !slices.ContainsFunc(prepared.Limits, func(l Limit) bool { return l.Resource == limit.Resource }) {
+				prepared.Limits = append(prepared.Limits, limit)
+			}
+		}
+		if cfg.coverage {
+			prepared.Env = append(prepared.Env, "GOCOVERDIR="+coverDir(t, cfg))
+		}
+		return prepared
+	}
+
+	var failed bool
+	// runs of the parallel steps started together with the previous ones.
+	runs := make(map[int]stepRun)
+	for i, step := range scheme.Steps {
+		program := programOf(step)
+		name := fmt.Sprintf("step %d (%s)", i+1, filepath.Base(program))
+		if step.RunIf != "" && (step.RunIf == runIfFailed) != failed {
+			t.Logf("Skipped %s, the previous step is not %s", name, step.RunIf)
+			continue
+		}
+		if cfg.dryRun {
+			logInvocation(t, name, program, prepareStep(step), cfg.cmdOpts)
+			continue
+		}
+
+		if step.Daemon {
+			prepared := prepareStep(step)
+			d := startDaemon(t, name, program, prepared, cfg.cmdOpts)
+			daemons = append(daemons, d)
+			ready := step.Ready
+			ready.Stdout = evaluateVariables(ready.Stdout, vars)
+			ready.Port = evaluateVariables(ready.Port, vars)
+			timeout := prepared.Timeout
+			if timeout <= 0 {
+				timeout = defaultReadyTimeout
+			}
+			if err := d.waitReady(ready, timeout); err != nil {
+				t.Fatalf("Failed to start %s: %s", name, err)
+			}
+			continue
+		}
+
+		run, ok := runs[i]
+		switch {
+		case ok:
+		case step.Parallel:
+			group := make([]stepRun, 0, len(scheme.Steps)-i)
+			for _, parallel := range scheme.Steps[i:] {
+				if !parallel.Parallel {
+					break
+				}
+				group = append(group, stepRun{program: programOf(parallel), prepared: prepareStep(parallel)})
+			}
+			runGoroutines(t, len(group), func(t testing.TB, j int) {
+				run := &group[j]
+				run.got = executeCommand(t, run.program, run.prepared, cfg.cmdOpts)
+			})
+			for j, parallel := range group {
+				runs[i+j] = parallel
+			}
+			run = group[0]
+		default:
+			prepared := prepareStep(step)
+			run = stepRun{program: program, prepared: prepared, got: executeCommand(t, program, prepared, cfg.cmdOpts)}
+		}
+
+		prepared, got := run.prepared, run.got
+		stepFailed := got.ReturnCode != 0 || got.KilledBy != "" || got.StartError != ""
+		if inGroup := step.Parallel && i > 0 && scheme.Steps[i-1].Parallel; inGroup {
+			// the group fails if any of its steps fails.
+			failed = failed || stepFailed
+		} else {
+			failed = stepFailed
+		}
+		checked := got
+		if i+1 < len(scheme.Steps) && scheme.Steps[i+1].RunIf != "" {
+			// the exit of the step chooses the next one instead of the assertion.
+			checked.ReturnCode, checked.KilledBy = prepared.ReturnCode, prepared.KilledBy
+			prepared.ReturnCodes = nil
+		}
+		if i+1 < len(scheme.Steps) && scheme.Steps[i+1].Pipe {
+			spooled, err := got.withSpooled()
+			if err != nil {
+				t.Fatalf("Failed to read stdout of %s: %s", name, err)
+			}
+			piped = spooled.Stdout
+			if step.Scheme.ExpectedStdout == "" {
+				checked.Stdout, checked.StdoutSpool = "", ""
+			}
+		}
+		if mismatches := checkResult(prepared, checked); len(mismatches) > 0 {
+			reportSchemeName(t, scheme)
+			t.Logf("Failed %s", name)
+			reportMismatches(t, mismatches, got, cfg)
+			return
+		}
+		if len(step.Captures) > 0 {
+			spooled, err := got.withSpooled()
+			if err != nil {
+				t.Fatalf("Failed to read stdout of %s: %s", name, err)
+			}
+			for _, capture := range step.Captures {
+				value, ok := capture.capture(spooled.Stdout)
+				if !ok {
+					t.Errorf("Failed to capture %s: /%s/ doesn't match the stdout of %s", capture.Name, capture.Pattern, name)
+					t.Logf("stdout:\n%s", spooled.Stdout)
+					return
+				}
+				vars.capture(capture.Name, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package exectest_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+// serveMain answers pong to every connection to the port from the argument.
+func serveMain() int {
+	listener, err := net.Listen("tcp", net.JoinHostPort("localhost", os.Args[1]))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return 1
+		}
+		fmt.Fprintln(conn, "pong")
+		conn.Close()
+	}
+}
+
+// pingMain prints the answer of the server on the port from the argument.
+func pingMain() int {
+	conn, err := net.Dial("tcp", net.JoinHostPort("localhost", os.Args[1]))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer conn.Close()
+	answer, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Print(answer)
+	return 0
+}
+
+func TestExecuteSteps(t *testing.T) {
+	exectest.Execute(t, "cat", `
+--file:greeting.txt
+hello
+--run
+--arg:greeting.txt
+--stdout
+hello
+--run: sh
+--arg:-c
+--arg:echo bye >> greeting.txt
+--run
+--arg:greeting.txt
+--stdout
+hello
+bye
+`)
+}
+
+func TestExecuteStepsDaemonPort(t *testing.T) {
+	exectest.Execute(t, exectest.Self("ping"), `
+--daemon: `+exectest.Self("serve")+`
+--arg:{port:server}
+--ready: port {port:server}
+--run
+--arg:{port:server}
+--stdout
+pong
+`)
+}
+
+func TestExecuteStepsDaemonStdout(t *testing.T) {
+	exectest.Execute(t, "cat", `
+--daemon: sh
+--arg:-c
+--arg:echo started > log.txt; echo ready; while :; do sleep 0.01; done
+--ready: stdout ready
+--run
+--arg:log.txt
+--stdout
+started
+`)
+}
+
+func TestExecuteStepsDaemonNotReady(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "cat", `
+--daemon: echo
+--arg:starting
+--ready: stdout ready
+--run
+--arg:missing.txt
+`)
+	})
+
+	assertFailed(t, fake, `Failed to start step 1 (echo): "ready" did not appear on stdout within 10s`)
+}
+
+func TestExecuteStepsStopsAtFailedStep(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--run
+--arg:-c
+--arg:exit 1
+--run
+--arg:-c
+--arg:echo unreachable
+`)
+	})
+
+	assertFailed(t, fake, "Failed to match return code: want 0, got 1")
+	for _, err := range fake.errors {
+		if err != "Failed to match return code: want 0, got 1" {
+			t.Errorf("Unexpected failure: %s", err)
+		}
+	}
+}
+
+func TestExecuteStepsPipe(t *testing.T) {
+	exectest.Execute(t, "sort", `
+--run: printf
+--arg:b\na\nc\n
+--run
+--pipe
+--run: tr
+--pipe
+--arg:a-z
+--arg:A-Z
+--stdout
+A
+B
+C
+`)
+}
+
+func TestExecuteStepsPipeAssertsStep(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "cat", `
+--run: echo
+--arg:hello
+--stdout
+bye
+--run
+--pipe
+--stdout
+hello
+`)
+	})
+
+	assertFailed(t, fake, `"bye\n"`, `"hello\n"`)
+}
+
+func TestExecuteStepsRunIf(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--run
+--arg:-c
+--arg:test -f state.txt
+--run
+--run-if: failed
+--arg:-c
+--arg:echo repaired > state.txt
+--run
+--run-if: failed
+--arg:-c
+--arg:echo unreachable
+--run: cat
+--arg:state.txt
+--stdout
+repaired
+`)
+}
+
+func TestExecuteStepsRunIfSkipsSteps(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--run
+--arg:-c
+--arg:true
+--run
+--run-if: failed
+--arg:-c
+--arg:echo repair
+--run
+--run-if: succeeded
+--arg:-c
+--arg:exit 2
+`)
+	})
+
+	assertFailed(t, fake, "Failed to match return code: want 0, got 2")
+}
+
+func TestExecuteStepsRunParallel(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--run-parallel
+--timeout: 5s
+--arg:-c
+--arg:while [ ! -f ready.txt ]; do sleep 0.01; done; echo watched
+--stdout
+watched
+--run-parallel
+--arg:-c
+--arg:echo written; touch ready.txt
+--stdout
+written
+--run: ls
+--stdout
+ready.txt
+`)
+}
+
+func TestExecuteStepsRunParallelFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--run-parallel
+--arg:-c
+--arg:exit 0
+--run-parallel
+--arg:-c
+--arg:exit 3
+--run
+--run-if: failed
+--arg:-c
+--arg:exit 4
+`)
+	})
+
+	assertFailed(t, fake, "Failed to match return code: want 0, got 4")
+}
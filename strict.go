@@ -0,0 +1,36 @@
+package exectest
+
+import (
+	"errors"
+	"strings"
+)
+
+// WithStrictScheme fails the scheme with an unknown directive, e.g. the
+// `--retrun-code:` typo, instead of ignoring it or treating it as the block
+// content.
+//
+// Block lines starting with `--` followed by a letter can't be used in the
+// strict schemes.
+func WithStrictScheme() Option {
+	return func(c *config) {
+		c.strictScheme = true
+	}
+}
+
+// checkUnknownDirectives returns the error for the first line looking like a
+// directive not interpreted by the parser.
+func checkUnknownDirectives(scheme string) error {
+	var heredoc heredocBody
+	for i, line := range toLines(scheme) {
+		if heredoc.inside(line) || !looksLikeDirective(line) || isDirective(line) {
+			continue
+		}
+		return newParseError(i+1, line, errors.New("unknown directive"))
+	}
+	return nil
+}
+
+func looksLikeDirective(line string) bool {
+	rest, ok := strings.CutPrefix(line, "--")
+	return ok && rest != "" && (rest[0] >= 'a' && rest[0] <= 'z' || rest[0] >= 'A' && rest[0] <= 'Z')
+}
@@ -0,0 +1,66 @@
+package exectest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Stub is the `--stub:<name> [exit=<code>]` block, the fake executable put to
+// the private directory prepended to the PATH of the binary. The block is the
+// script of the stub if it starts with `#!`, the stdout of the stub otherwise.
+// The stubs are shell scripts, so they are for Unix only.
+//
+//	--stub: git exit=128
+//	fatal: not a git repository
+type Stub struct {
+	// Name of the executable.
+	Name string
+	// Content is the script or the stdout of the stub.
+	Content string
+	// ExitCode of the stub printing the Content.
+	ExitCode int
+}
+
+func parseStub(text string) (Stub, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return Stub{}, fmt.Errorf("--stub must have a name")
+	}
+	stub := Stub{Name: fields[0]}
+	if strings.ContainsAny(stub.Name, `/\`) || stub.Name == "." || stub.Name == ".." {
+		return Stub{}, fmt.Errorf("stub name %q must not be a path", stub.Name)
+	}
+	for _, attribute := range fields[1:] {
+		code, ok := strings.CutPrefix(attribute, "exit=")
+		if !ok {
+			return Stub{}, fmt.Errorf("unknown stub attribute %q of %q", attribute, stub.Name)
+		}
+		var err error
+		if stub.ExitCode, err = strconv.Atoi(code); err != nil {
+			return Stub{}, fmt.Errorf("failed to convert exit code of %q to int: %w", stub.Name, err)
+		}
+	}
+	return stub, nil
+}
+
+// script of the stub, the canned stub prints its content exactly.
+func (s Stub) script() string {
+	if strings.HasPrefix(s.Content, "#!") {
+		return s.Content
+	}
+	return fmt.Sprintf("#!/bin/sh\nprintf '%%s' %s\nexit %d\n", shellQuote(s.Content), s.ExitCode)
+}
+
+// writeStubs writes the executables of the stubs to the dir.
+func writeStubs(dir string, stubs []Stub, vars *variables) error {
+	for _, stub := range stubs {
+		stub.Content = evaluateVariables(stub.Content, vars)
+		if err := os.WriteFile(filepath.Join(dir, stub.Name), []byte(stub.script()), 0o755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteStub(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--stub: git exit=128
+fatal: not a git repository
+--arg:-c
+--arg:git status; echo "code $?"
+--stdout
+fatal: not a git repository
+code 128
+`)
+}
+
+func TestExecuteStubScript(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--stub: docker
+#!/bin/sh
+echo "docker $*" >&2
+exit 3
+--arg:-c
+--arg:docker ps -a
+--return-code: 3
+--stderr
+docker ps -a
+`)
+}
@@ -0,0 +1,74 @@
+package exectest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Symlink is the `--expect-symlink:<path> -> <target>` directive, the
+// symbolic link of the scheme directory expected after the execution. The
+// target is compared as written in the link, relative or absolute, with the
+// backslashes taken as the separators.
+//
+//	--expect-symlink: out/current -> releases/v2
+type Symlink struct {
+	// Path of the link relative to the scheme directory.
+	Path string
+	// Target the link points at.
+	Target string
+}
+
+func parseSymlink(text string) (Symlink, error) {
+	path, target, ok := strings.Cut(text, "->")
+	link := Symlink{Path: slashPath(path), Target: strings.TrimSpace(target)}
+	if !ok || link.Path == "" || link.Target == "" {
+		return Symlink{}, fmt.Errorf("malformed --expect-symlink %q, expected <path> -> <target>", strings.TrimSpace(text))
+	}
+	if !filepath.IsLocal(link.Path) {
+		return Symlink{}, fmt.Errorf("file path %q must be local to the scheme directory", link.Path)
+	}
+	return link, nil
+}
+
+// checkSymlinks checks the links of the dir point at the targets.
+func checkSymlinks(dir string, want []Symlink) []mismatch {
+	var mismatches []mismatch
+	for _, link := range want {
+		path := filepath.Join(dir, link.Path)
+		info, err := os.Lstat(path)
+		if err != nil {
+			mismatches = append(mismatches, mismatch{message: fmt.Sprintf("Failed to stat %s: %s", link.Path, err)})
+			continue
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match symlink %s: want -> %s, got %s", link.Path, link.Target, describeMode(info.Mode())),
+			})
+			continue
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			mismatches = append(mismatches, mismatch{message: fmt.Sprintf("Failed to read symlink %s: %s", link.Path, err)})
+			continue
+		}
+		if slashPath(target) != slashPath(link.Target) {
+			mismatches = append(mismatches, mismatch{
+				message: fmt.Sprintf("Failed to match symlink %s: want -> %s, got -> %s", link.Path, link.Target, target),
+			})
+		}
+	}
+	return mismatches
+}
+
+// describeMode tells the kind of the file of the mode.
+func describeMode(mode os.FileMode) string {
+	if mode.IsDir() {
+		return "directory"
+	}
+	if mode.IsRegular() {
+		return "regular file"
+	}
+	return mode.Type().String()
+}
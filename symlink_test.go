@@ -0,0 +1,37 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteExpectSymlink(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--file:releases/v2/tool
+--arg:-c
+--arg:mkdir out && ln -s ../releases/v2 out/current && ln -s {dir}/releases/v2/tool tool
+--expect-symlink: out/current -> ../releases/v2
+--expect-symlink: tool -> {dir}/releases/v2/tool
+`)
+}
+
+func TestExecuteExpectSymlinkFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--file:releases/v1/tool
+--file:regular.txt
+--arg:-c
+--arg:ln -s releases/v1 current
+--expect-symlink: current -> releases/v2
+--expect-symlink: regular.txt -> releases/v2
+--expect-symlink: missing -> releases/v2
+`)
+	})
+
+	assertFailed(t, fake,
+		"Failed to match symlink current: want -> releases/v2, got -> releases/v1",
+		"Failed to match symlink regular.txt: want -> releases/v2, got regular file",
+		"Failed to stat missing",
+	)
+}
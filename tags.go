@@ -0,0 +1,46 @@
+package exectest
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+type tagFilter struct {
+	include []string
+	exclude []string
+}
+
+func parseTagFilter(filter string) tagFilter {
+	var result tagFilter
+	for _, tag := range splitList(filter) {
+		if excluded, ok := strings.CutPrefix(tag, "!"); ok {
+			result.exclude = append(result.exclude, excluded)
+		} else {
+			result.include = append(result.include, tag)
+		}
+	}
+	return result
+}
+
+// envTagFilter is the filter from the EXECTEST_TAGS environment variable.
+func envTagFilter() tagFilter {
+	return parseTagFilter(os.Getenv("EXECTEST_TAGS"))
+}
+
+func (f tagFilter) match(tags []string) bool {
+	for _, tag := range f.exclude {
+		if slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, tag := range f.include {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
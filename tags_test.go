@@ -0,0 +1,49 @@
+package exectest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteDirTagFilter(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "network.txt"), `
+--tags:network
+--stdout
+fails if run
+`)
+	writeFile(t, filepath.Join(dir, "slow.txt"), `
+--tags: slow, local
+--stdin
+slow
+--stdout
+slow
+`)
+	writeFile(t, filepath.Join(dir, "fast.txt"), `
+--stdin
+fast
+--stdout
+fast
+`)
+
+	exectest.ExecuteDir(t, "cat", dir, exectest.WithTagFilter("!network"))
+}
+
+func TestExecuteTagFilterIncludes(t *testing.T) {
+	exectest.Execute(t, "cat", `
+--tags:unit
+--stdout
+fails if run
+`, exectest.WithTagFilter("network,slow"))
+}
+
+func TestExecuteTagFilterFromEnv(t *testing.T) {
+	t.Setenv("EXECTEST_TAGS", "!slow")
+	exectest.Execute(t, "cat", `
+--tags:slow
+--stdout
+fails if run
+`)
+}
@@ -0,0 +1,47 @@
+package exectest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithTAP writes results of the schemes run by [ExecuteDir] to the w in the
+// TAP (Test Anything Protocol) format once all of them are finished.
+func WithTAP(w io.Writer) Option {
+	return func(c *config) {
+		c.reporters = append(c.reporters, &tapReporter{w: w})
+	}
+}
+
+type tapReporter struct {
+	w io.Writer
+}
+
+func (r *tapReporter) report(_ string, results []caseResult) error {
+	var out strings.Builder
+	out.WriteString("TAP version 13\n")
+	fmt.Fprintf(&out, "1..%d\n", len(results))
+	for i, result := range results {
+		switch result.status {
+		case casePassed:
+			fmt.Fprintf(&out, "ok %d - %s\n", i+1, result.name)
+		case caseSkipped:
+			fmt.Fprintf(&out, "ok %d - %s # SKIP %s\n", i+1, result.name, firstLine(strings.Join(result.messages, " ")))
+		case caseFailed:
+			fmt.Fprintf(&out, "not ok %d - %s\n", i+1, result.name)
+			for _, message := range result.messages {
+				for _, line := range toLines(message) {
+					out.WriteString("# " + line)
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(r.w, out.String())
+	return err
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
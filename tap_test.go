@@ -0,0 +1,33 @@
+package exectest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTAPReporterFormatsResults(t *testing.T) {
+	var out strings.Builder
+	reporter := &tapReporter{w: &out}
+
+	err := reporter.report("TestSchemes", []caseResult{
+		{name: "a.txt", status: casePassed},
+		{name: "b.txt", status: caseFailed, messages: []string{"Failed matching stdout:\n-a\n+b"}},
+		{name: "c.txt", status: caseSkipped, messages: []string{"Scheme tags [slow] don't match the filter"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to report: %s", err)
+	}
+
+	want := `TAP version 13
+1..3
+ok 1 - a.txt
+not ok 2 - b.txt
+# Failed matching stdout:
+# -a
+# +b
+ok 3 - c.txt # SKIP Scheme tags [slow] don't match the filter
+`
+	if got := out.String(); got != want {
+		t.Errorf("Unexpected TAP output:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
@@ -0,0 +1,51 @@
+package exectest
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// templateFuncs are the functions of the scheme templates.
+var templateFuncs = template.FuncMap{
+	"quote": strconv.Quote,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// ExecuteTemplate is the same as the [Execute] but the scheme is the
+// [text/template] rendered with the data first. The `quote` function quotes
+// the string as the Go literal and the `json` one encodes the value as JSON,
+// the missing keys of the maps fail the rendering.
+//
+// Example:
+//
+//	exectest.ExecuteTemplate(t, "greet", `
+//	--arg:{{.Name}}
+//	--stdout
+//	{"greeting": {{json .Greeting}}}
+//	`, struct{ Name, Greeting string }{"Ann", "Hello, Ann!"})
+func ExecuteTemplate(t testing.TB, binary, schemeTemplate string, data any, opts ...Option) {
+	t.Helper()
+	scheme, err := renderTemplate(schemeTemplate, data)
+	if err != nil {
+		t.Fatalf("Failed to render scheme template: %s", err)
+	}
+	Execute(t, binary, scheme, opts...)
+}
+
+func renderTemplate(schemeTemplate string, data any) (string, error) {
+	tmpl, err := template.New("scheme").Funcs(templateFuncs).Option("missingkey=error").Parse(schemeTemplate)
+	if err != nil {
+		return "", err
+	}
+	var scheme strings.Builder
+	if err := tmpl.Execute(&scheme, data); err != nil {
+		return "", err
+	}
+	return scheme.String(), nil
+}
@@ -0,0 +1,43 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteTemplate(t *testing.T) {
+	exectest.ExecuteTemplate(t, "sh", `
+{{- range .Files}}
+--file:{{.}}
+{{- end}}
+--arg:-c
+--arg:ls; echo "$0"; echo "$1"
+--arg:{{quote .Message}}
+--arg:{{json .Config}}
+--stdout
+{{- range .Files}}
+{{.}}
+{{- end}}
+"100% \"done\""
+{"debug":true,"level":3}
+`, map[string]any{
+		"Files":   []string{"a.txt", "b.txt"},
+		"Message": `100% "done"`,
+		"Config":  map[string]any{"debug": true, "level": 3},
+	})
+}
+
+func TestExecuteTemplateFailure(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.ExecuteTemplate(tb, "echo", "--arg:{{.Missing}}\n", map[string]string{})
+	})
+
+	assertFailed(t, fake, "Failed to render scheme template: ", `map has no entry for key "Missing"`)
+
+	fake = runFake(t, func(tb testing.TB) {
+		exectest.ExecuteTemplate(tb, "echo", "--arg:{{.Name\n", nil)
+	})
+
+	assertFailed(t, fake, "Failed to render scheme template: ")
+}
@@ -0,0 +1,17 @@
+// Command hello greets its arguments, it is used to test Go binary helpers.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: hello NAME...")
+		os.Exit(2)
+	}
+	for _, name := range os.Args[1:] {
+		fmt.Printf("hello, %s\n", name)
+	}
+}
@@ -0,0 +1,244 @@
+package exectest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ConvertTestscript translates the testscript (rsc.io/script-test,
+// github.com/rogpeppe/go-internal/testscript) txtar script into the exectest
+// scheme in the txtar format, see [ParseTxtar].
+//
+// Every `exec` of the script becomes a `--run` step, and the commands below
+// apply to the previous `exec`:
+//
+//   - `! exec` expects the return code 1;
+//   - `stdout <pattern>` and `stderr <pattern>` expect the literal pattern
+//     somewhere in the output, regular expressions aren't supported;
+//   - `! stdout <pattern>` and `! stderr <pattern>` become the excludes;
+//   - `cmp stdout <file>` and `cmp stderr <file>` expect the content of the
+//     member of the archive.
+//
+// The `env` and `stdin` commands apply to the following steps, the `$WORK`
+// variable becomes the `{dir}` placeholder. The outputs of the steps without
+// assertions are ignored. Other commands and conditions are errors.
+func ConvertTestscript(script string) (string, error) {
+	comment, members := splitTxtar(script)
+	files := make(map[string]string, len(members))
+	for _, member := range members {
+		files[member.name] = member.data
+	}
+
+	var steps []*convertedStep
+	var env []string
+	var stdin string
+	for i, line := range toLines(comment) {
+		text := strings.TrimSpace(line)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		lineError := func(err error) error {
+			return newParseError(i+1, line, err)
+		}
+		words, err := splitTestscriptWords(text)
+		if err != nil {
+			return "", lineError(err)
+		}
+		negated := words[0] == "!"
+		if negated {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			return "", lineError(fmt.Errorf("missing command"))
+		}
+		var last *convertedStep
+		if len(steps) > 0 {
+			last = steps[len(steps)-1]
+		}
+
+		switch command, args := words[0], words[1:]; {
+		case command == "exec" && len(args) > 0:
+			steps = append(steps, &convertedStep{
+				program: args[0],
+				args:    args[1:],
+				env:     env,
+				stdin:   stdin,
+				fails:   negated,
+			})
+			stdin = ""
+		case command == "env" && !negated:
+			// the previous steps keep their environment.
+			env = append([]string(nil), env...)
+			for _, kv := range args {
+				if !strings.Contains(kv, "=") {
+					return "", lineError(fmt.Errorf("env %q isn't supported, expected KEY=VALUE", kv))
+				}
+				env = append(env, kv)
+			}
+		case command == "stdin" && !negated && len(args) == 1:
+			content, ok := files[args[0]]
+			if !ok {
+				return "", lineError(fmt.Errorf("file %s is not in the archive", args[0]))
+			}
+			stdin = content
+		case (command == "stdout" || command == "stderr") && len(args) == 1:
+			if last == nil {
+				return "", lineError(fmt.Errorf("%s must follow exec", command))
+			}
+			if regexp.QuoteMeta(args[0]) != args[0] {
+				return "", lineError(fmt.Errorf("regular expression %q isn't supported", args[0]))
+			}
+			if err := last.expect(command, negated, ellipsisLine+ellipsisToken+args[0]+ellipsisToken+"\n"+ellipsisLine, args[0]); err != nil {
+				return "", lineError(err)
+			}
+		case command == "cmp" && !negated && len(args) == 2 && (args[0] == "stdout" || args[0] == "stderr"):
+			if last == nil {
+				return "", lineError(fmt.Errorf("cmp must follow exec"))
+			}
+			content, ok := files[args[1]]
+			if !ok {
+				return "", lineError(fmt.Errorf("file %s is not in the archive", args[1]))
+			}
+			if err := last.expect(args[0], false, content, ""); err != nil {
+				return "", lineError(err)
+			}
+		default:
+			return "", lineError(fmt.Errorf("unsupported testscript command"))
+		}
+	}
+
+	var result strings.Builder
+	for _, step := range steps {
+		if err := step.format(&result); err != nil {
+			return "", err
+		}
+	}
+	for _, member := range members {
+		result.WriteString(txtarMarker(member.name) + "\n")
+		result.WriteString(member.data)
+	}
+	return result.String(), nil
+}
+
+// convertedStep is the `exec` of the testscript.
+type convertedStep struct {
+	program string
+	args    []string
+	env     []string
+	stdin   string
+	fails   bool
+	// stdout and stderr are the expected blocks, the excludes are the
+	// negated patterns.
+	stdout, stderr                 string
+	stdoutExcludes, stderrExcludes []string
+}
+
+func (s *convertedStep) expect(stream string, negated bool, block, pattern string) error {
+	expected, excludes := &s.stdout, &s.stdoutExcludes
+	if stream == "stderr" {
+		expected, excludes = &s.stderr, &s.stderrExcludes
+	}
+	if negated {
+		*excludes = append(*excludes, pattern)
+		return nil
+	}
+	if *expected != "" {
+		return fmt.Errorf("only one %s assertion per exec is supported", stream)
+	}
+	*expected = block
+	return nil
+}
+
+func (s *convertedStep) format(result *strings.Builder) error {
+	result.WriteString(runHeader + ": " + convertWork(s.program) + "\n")
+	for _, arg := range s.args {
+		result.WriteString(argPrefix + convertWork(arg) + "\n")
+	}
+	for _, kv := range s.env {
+		result.WriteString(envPrefix + convertWork(kv) + "\n")
+	}
+	if s.fails {
+		result.WriteString(formatReturnCode(1))
+	}
+	blocks := []struct {
+		header  string
+		content string
+	}{
+		{stdinPrefix, s.stdin},
+		{stdoutPrefix, withDefault(s.stdout, ellipsisLine)},
+		{stderrPrefix, withDefault(s.stderr, ellipsisLine)},
+	}
+	for _, block := range blocks {
+		if block.content == "" {
+			continue
+		}
+		content, err := formatBlock(convertWork(block.content), "")
+		if err != nil {
+			return err
+		}
+		result.WriteString(block.header + "\n" + content)
+	}
+	for _, excludes := range []struct {
+		header   string
+		patterns []string
+	}{{stdoutExcludesPrefix, s.stdoutExcludes}, {stderrExcludesPrefix, s.stderrExcludes}} {
+		if len(excludes.patterns) == 0 {
+			continue
+		}
+		content, err := formatBlock(convertWork(strings.Join(excludes.patterns, "\n")), "")
+		if err != nil {
+			return err
+		}
+		result.WriteString(excludes.header + "\n" + content)
+	}
+	return nil
+}
+
+func withDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// convertWork replaces the `$WORK` variable of the testscript with the
+// `{dir}` placeholder.
+func convertWork(text string) string {
+	return strings.NewReplacer("${WORK}", "{dir}", "$WORK", "{dir}").Replace(text)
+}
+
+// splitTestscriptWords splits the testscript line into the words, the single
+// quoted text is a word with the doubled quote standing for the quote.
+func splitTestscriptWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var inWord, quoted bool
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quoted && c == '\'' && i+1 < len(line) && line[i+1] == '\'':
+			word.WriteByte('\'')
+			i++
+		case c == '\'':
+			quoted = !quoted
+			inWord = true
+		case !quoted && (c == ' ' || c == '\t'):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
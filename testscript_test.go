@@ -0,0 +1,81 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+	"github.com/google/go-cmp/cmp"
+)
+
+const testscript = `# Greets and copies the input.
+env GREETING=hi
+exec sh -c 'echo $GREETING from ''sh''; echo err >&2'
+stdout 'hi from'
+! stderr boom
+
+exec cat $WORK/input.txt
+cmp stdout input.txt
+! exec false
+-- input.txt --
+content
+`
+
+func TestConvertTestscript(t *testing.T) {
+	got, err := exectest.ConvertTestscript(testscript)
+	if err != nil {
+		t.Fatalf("Failed to convert: %s", err)
+	}
+	want := `--run: sh
+--arg:-c
+--arg:echo $GREETING from 'sh'; echo err >&2
+--env:GREETING=hi
+--stdout
+...
+[...]hi from[...]
+...
+--stderr
+...
+--stderr-excludes
+boom
+--run: cat
+--arg:{dir}/input.txt
+--env:GREETING=hi
+--stdout
+content
+--stderr
+...
+--run: false
+--env:GREETING=hi
+--return-code: 1
+--stdout
+...
+--stderr
+...
+-- input.txt --
+content
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Unexpected scheme (-want +got):\n%s", diff)
+	}
+
+	exectest.ExecuteTxtar(t, "true", got)
+}
+
+func TestConvertTestscriptErrors(t *testing.T) {
+	for name, script := range map[string]string{
+		"unsupported command": "exec true\nexists a.txt\n",
+		"condition":           "[linux] exec true\n",
+		"regexp":              "exec true\nstdout '^ok$'\n",
+		"stdout before exec":  "stdout ok\n",
+		"missing file":        "exec true\ncmp stdout golden.txt\n",
+		"directive content":   "exec true\ncmp stdout golden.txt\n-- golden.txt --\n--stdout\n",
+		"unterminated quote":  "exec echo 'hi\n",
+		"stdout twice":        "exec true\nstdout a\nstdout b\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := exectest.ConvertTestscript(script); err == nil {
+				t.Errorf("Expected error for script %q", script)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package exectest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// WithTrace logs every execution of the binary with the t.Logf: the command
+// line as run, the environment variables set, changed or unset compared with
+// the test process, the working directory, the size of the stdin, the
+// duration and the exit code.
+func WithTrace() Option {
+	return func(c *config) {
+		c.trace = true
+	}
+}
+
+// traceRun logs the finished cmd of the prepared scheme.
+func traceRun(t testing.TB, cmd *exec.Cmd, prepared schemeResult, result executionResult) {
+	t.Helper()
+	exit := fmt.Sprintf("%d", result.ReturnCode)
+	switch {
+	case result.StartError != "":
+		exit = "failed to start: " + result.StartError
+	case result.KilledBy != "":
+		exit += " killed by " + result.KilledBy
+	}
+	t.Logf("Trace of %s:\n%s\n  duration: %s\n  exit code: %s", cmd.Path, describeInvocation(cmd, prepared), result.Duration, exit)
+}
+
+// describeInvocation describes the command line, the working directory, the
+// environment changes and the stdin of the cmd of the prepared scheme.
+func describeInvocation(cmd *exec.Cmd, prepared schemeResult) string {
+	stdin := fmt.Sprintf("%d bytes", len(prepared.Stdin))
+	if prepared.Interaction != nil {
+		stdin = "interactive"
+	}
+	dir := cmd.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "  command: %s\n", shellJoin(append([]string{cmd.Path}, cmd.Args[1:]...)))
+	fmt.Fprintf(&b, "  dir: %s\n", dir)
+	for _, delta := range envDelta(os.Environ(), cmd.Env) {
+		fmt.Fprintf(&b, "  env: %s\n", delta)
+	}
+	fmt.Fprintf(&b, "  stdin: %s", stdin)
+	return b.String()
+}
+
+// envDelta describes the changes of the environment of the cmd compared with
+// the one of the test process, sorted by the names: `+NAME=value` for the set
+// variables, `~NAME=value` for the changed ones and `-NAME` for the unset ones.
+// The nil cmd environment is the inherited one.
+func envDelta(base, env []string) []string {
+	if env == nil {
+		return nil
+	}
+	before, after := envMap(base), envMap(env)
+	var delta []string
+	for name, value := range after {
+		if old, ok := before[name]; !ok {
+			delta = append(delta, "+"+name+"="+value)
+		} else if old != value {
+			delta = append(delta, "~"+name+"="+value)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			delta = append(delta, "-"+name)
+		}
+	}
+	sort.Slice(delta, func(i, j int) bool { return delta[i][1:] < delta[j][1:] })
+	return delta
+}
+
+// envMap returns the variables of the environment, the last value wins as it
+// does for the [exec.Cmd].
+func envMap(env []string) map[string]string {
+	vars := make(map[string]string, len(env))
+	for _, entry := range env {
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			vars[name] = value
+		}
+	}
+	return vars
+}
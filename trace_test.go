@@ -0,0 +1,54 @@
+package exectest_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteWithTrace(t *testing.T) {
+	t.Setenv("EXECTEST_TRACE_CHANGED", "before")
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--env:EXECTEST_TRACE_ADDED=added
+--env:EXECTEST_TRACE_CHANGED=after
+--arg:-c
+--arg:cat; exit 3
+--stdin
+hello
+--stdout
+hello
+--return-code:3
+`, exectest.WithTrace())
+	})
+
+	assertPassed(t, fake)
+	logs := strings.Join(fake.logs, "\n")
+	for _, part := range []string{
+		"Trace of ",
+		"  command: '",
+		"' '-c' 'cat; exit 3'\n",
+		"  dir: " + os.TempDir(),
+		"  env: +EXECTEST_TRACE_ADDED=added\n",
+		"  env: ~EXECTEST_TRACE_CHANGED=after\n",
+		"  stdin: 6 bytes\n",
+		"  duration: ",
+		"  exit code: 3",
+	} {
+		if !strings.Contains(logs, part) {
+			t.Errorf("Expected trace containing %q, got:\n%s", part, logs)
+		}
+	}
+}
+
+func TestExecuteWithoutTrace(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "true", "")
+	})
+
+	if logs := strings.Join(fake.logs, "\n"); strings.Contains(logs, "Trace of") {
+		t.Errorf("Unexpected trace:\n%s", logs)
+	}
+}
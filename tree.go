@@ -0,0 +1,134 @@
+package exectest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TreeEntry is a line of the `--expect-tree` block: the slash separated path
+// relative to the scheme directory and the optional `size=<bytes>` and
+// `mode=<octal>` attributes.
+//
+//	--expect-tree
+//	go.mod size=27
+//	cmd/tool/main.go mode=0644
+//	empty/
+//
+// The directories are implied by the paths under them, only the empty ones
+// are listed with the trailing slash.
+type TreeEntry struct {
+	Path string
+	// Size is checked if set.
+	Size *int64
+	// Mode is the permission bits checked if set.
+	Mode *fs.FileMode
+}
+
+func parseTreeEntry(line string) (TreeEntry, error) {
+	fields := strings.Fields(line)
+	entry := TreeEntry{Path: fields[0]}
+	for _, attribute := range fields[1:] {
+		name, value, _ := strings.Cut(attribute, "=")
+		switch name {
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return TreeEntry{}, fmt.Errorf("failed to convert size of %q to int: %w", entry.Path, err)
+			}
+			entry.Size = &size
+		case "mode":
+			mode, err := strconv.ParseUint(value, 8, 32)
+			if err != nil {
+				return TreeEntry{}, fmt.Errorf("failed to convert mode of %q to octal: %w", entry.Path, err)
+			}
+			perm := fs.FileMode(mode).Perm()
+			entry.Mode = &perm
+		default:
+			return TreeEntry{}, fmt.Errorf("unknown tree attribute %q of %q", attribute, entry.Path)
+		}
+	}
+	return entry, nil
+}
+
+// format the entry with the attributes of the want one.
+func (e TreeEntry) format(want TreeEntry) string {
+	line := e.Path
+	if want.Size != nil && e.Size != nil {
+		line += " size=" + strconv.FormatInt(*e.Size, 10)
+	}
+	if want.Mode != nil && e.Mode != nil {
+		line += fmt.Sprintf(" mode=%04o", uint32(*e.Mode))
+	}
+	return line + "\n"
+}
+
+// walkTree lists the files and the empty directories of the dir, the skip
+// directory is left out if set.
+func walkTree(dir, skip string) ([]TreeEntry, error) {
+	var entries []TreeEntry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if path == skip {
+			return fs.SkipDir
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := TreeEntry{Path: filepath.ToSlash(rel)}
+		mode := info.Mode().Perm()
+		entry.Mode = &mode
+		if d.IsDir() {
+			children, err := os.ReadDir(path)
+			if err != nil || len(children) > 0 {
+				return err
+			}
+			entry.Path += "/"
+		} else {
+			size := info.Size()
+			entry.Size = &size
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// checkTree compares the tree of the directory with the expected one.
+func checkTree(want, got []TreeEntry) (mismatch, bool) {
+	wanted := make(map[string]TreeEntry, len(want))
+	wantLines := make([]string, 0, len(want))
+	for _, entry := range want {
+		wanted[entry.Path] = entry
+		wantLines = append(wantLines, entry.format(entry))
+	}
+	gotLines := make([]string, 0, len(got))
+	for _, entry := range got {
+		gotLines = append(gotLines, entry.format(wanted[entry.Path]))
+	}
+	sort.Strings(wantLines)
+	sort.Strings(gotLines)
+	if diff := cmp.Diff(wantLines, gotLines); diff != "" {
+		return mismatch{
+			message: fmt.Sprintf("Failed matching tree (-missing line, +extra line): \n%s", diff),
+			output:  fmt.Sprintf("tree:\n%s", strings.Join(gotLines, "")),
+		}, false
+	}
+	return mismatch{}, true
+}
@@ -0,0 +1,41 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+const scaffoldScript = `mkdir -p cmd/app empty && printf 'module app\n' > go.mod && touch cmd/app/main.go && chmod 0755 cmd/app/main.go`
+
+func TestExecuteExpectTree(t *testing.T) {
+	exectest.Execute(t, "sh", `
+--arg:-c
+--arg:`+scaffoldScript+`
+--expect-tree
+go.mod size=11
+cmd/app/main.go mode=0755
+empty/
+`)
+}
+
+func TestExecuteExpectTreeMismatch(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "sh", `
+--arg:-c
+--arg:`+scaffoldScript+`
+--expect-tree
+go.mod size=10
+cmd/app/main.go
+README.md
+`)
+	})
+
+	assertFailed(t, fake,
+		"Failed matching tree (-missing line, +extra line)",
+		`"README.md\n"`,
+		`"go.mod size=10\n"`,
+		`"go.mod size=11\n"`,
+		`"empty/\n"`,
+	)
+}
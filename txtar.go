@@ -0,0 +1,161 @@
+package exectest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// txtarExt is the extension of the scheme files in the txtar format.
+const txtarExt = ".txtar"
+
+// txtarMember is a `-- <name> --` file of the txtar archive.
+type txtarMember struct {
+	name string
+	// line is the number of the marker line in the archive.
+	line int
+	data string
+}
+
+// ParseTxtar parses the scheme encoded as a txtar archive, the format of
+// golang.org/x/tools/txtar:
+//
+//	--return-code: 1
+//	-- args --
+//	input.txt
+//	-- input.txt --
+//	content
+//	-- stdout --
+//	content
+//
+// The comment of the archive is parsed as a regular scheme. The `args`
+// member has an argument per line, the `stdin`, `stdout` and `stderr` ones
+// are the input and the expected outputs, other members are the files of the
+// scheme directory. Only the files can be used with the `--run` and
+// `--daemon` steps. Contents of the members aren't interpreted, so they can
+// have lines starting with `--`.
+func ParseTxtar(archive string) (*Scheme, error) {
+	comment, members := splitTxtar(archive)
+	scheme, err := ParseScheme(comment)
+	if err != nil {
+		return nil, err
+	}
+	defined := make(map[string]int)
+	for _, member := range members {
+		if first, ok := defined[member.name]; ok {
+			return nil, newParseError(member.line, txtarMarker(member.name), fmt.Errorf("member %s is already defined at line %d", member.name, first))
+		}
+		defined[member.name] = member.line
+		if isTxtarField(member.name) && len(scheme.Steps) > 0 {
+			return nil, newParseError(member.line, txtarMarker(member.name), fmt.Errorf("member %s can't be used with --run and --daemon steps", member.name))
+		}
+		switch member.name {
+		case "args":
+			for _, arg := range toLines(member.data) {
+				scheme.Args = append(scheme.Args, strings.TrimSuffix(arg, "\n"))
+			}
+		case "stdin":
+			err = setTxtarField(&scheme.Stdin, member)
+		case "stdout":
+			err = setTxtarField(&scheme.ExpectedStdout, member)
+		case "stderr":
+			err = setTxtarField(&scheme.ExpectedStderr, member)
+		default:
+			if !filepath.IsLocal(member.name) {
+				return nil, newParseError(member.line, txtarMarker(member.name), fmt.Errorf("file path %q must be local to the scheme directory", member.name))
+			}
+			for _, file := range scheme.Files {
+				if filepath.Clean(file.Path) == filepath.Clean(member.name) {
+					return nil, newParseError(member.line, txtarMarker(member.name), fmt.Errorf("file %s is already defined with --file", member.name))
+				}
+			}
+			scheme.Files = append(scheme.Files, File{Path: member.name, Content: member.data})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return scheme, nil
+}
+
+// isTxtarField reports whether the member is the args, input or expected
+// output rather than a file.
+func isTxtarField(name string) bool {
+	return name == "args" || name == "stdin" || name == "stdout" || name == "stderr"
+}
+
+// setTxtarField sets the field to the member data unless it's defined by
+// the comment of the archive.
+func setTxtarField(field *string, member txtarMember) error {
+	if *field != "" {
+		return newParseError(member.line, txtarMarker(member.name), fmt.Errorf("member %s is already defined in the comment", member.name))
+	}
+	*field = member.data
+	return nil
+}
+
+func txtarMarker(name string) string {
+	return "-- " + name + " --"
+}
+
+// splitTxtar splits the archive into the comment and the members.
+func splitTxtar(archive string) (string, []txtarMember) {
+	var comment strings.Builder
+	var members []txtarMember
+	var data strings.Builder
+	flush := func() {
+		if len(members) > 0 {
+			members[len(members)-1].data = data.String()
+			data.Reset()
+		}
+	}
+	for i, line := range toLines(archive) {
+		if name, ok := cutTxtarMarker(line); ok {
+			flush()
+			members = append(members, txtarMember{name: name, line: i + 1})
+			continue
+		}
+		if len(members) == 0 {
+			comment.WriteString(line)
+		} else {
+			data.WriteString(line)
+		}
+	}
+	flush()
+	return comment.String(), members
+}
+
+// cutTxtarMarker returns the name of the `-- <name> --` marker line.
+func cutTxtarMarker(line string) (string, bool) {
+	line = strings.TrimSuffix(line, "\n")
+	rest, ok := strings.CutPrefix(line, "-- ")
+	if !ok {
+		return "", false
+	}
+	name, ok := strings.CutSuffix(rest, " --")
+	name = strings.TrimSpace(name)
+	return name, ok && name != ""
+}
+
+func isTxtar(file string) bool {
+	return filepath.Ext(file) == txtarExt
+}
+
+// ExecuteTxtar is the same as the [Execute] but the scheme is a txtar
+// archive, see [ParseTxtar]. The [ExecuteForFile] and [ExecuteDir] run the
+// files with the `.txtar` extension the same way.
+func ExecuteTxtar(t testing.TB, binary, archive string, opts ...Option) {
+	t.Helper()
+	executeTxtar(t, binary, archive, newConfig(opts))
+}
+
+func executeTxtar(t testing.TB, binary, archive string, cfg *config) {
+	t.Helper()
+	logSchemeOnFailure(t, archive)
+	scheme, err := ParseTxtar(archive)
+	if err != nil {
+		t.Fatalf("Failed to parse scheme: %s", err)
+	}
+	executeScheme(t, binary, schemeSource{text: archive}, scheme, cfg)
+}
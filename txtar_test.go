@@ -0,0 +1,76 @@
+package exectest_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/IlyasYOY/exectest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExecuteTxtar(t *testing.T) {
+	exectest.ExecuteTxtar(t, "cat", `Prints the files.
+-- args --
+--
+a.txt
+--b.txt
+-- a.txt --
+--stdout
+-- --b.txt --
+b
+-- stdout --
+--stdout
+b
+`)
+}
+
+func TestExecuteTxtarCommentDirectives(t *testing.T) {
+	exectest.ExecuteTxtar(t, "sh", `--arg:-c
+--arg:cat; exit 3
+--return-code: 3
+-- stdin --
+input
+-- stdout --
+input
+`)
+}
+
+func TestExecuteForFSTxtar(t *testing.T) {
+	fsys := fstest.MapFS{
+		"echo.txtar": {Data: []byte("-- args --\nhello\n-- stdout --\nhello\n")},
+	}
+	exectest.ExecuteForFS(t, "echo", fsys, "echo.txtar")
+}
+
+func TestParseTxtar(t *testing.T) {
+	scheme, err := exectest.ParseTxtar("--env:A=1\n-- args --\n-n\nx\n-- dir/a.txt --\na\n-- stderr --\nerr\n")
+	if err != nil {
+		t.Fatalf("Failed to parse txtar: %s", err)
+	}
+	want := &exectest.Scheme{
+		Args:           []string{"-n", "x"},
+		Env:            []string{"A=1"},
+		Files:          []exectest.File{{Path: "dir/a.txt", Content: "a\n"}},
+		ExpectedStderr: "err\n",
+	}
+	if diff := cmp.Diff(want, scheme); diff != "" {
+		t.Errorf("Unexpected scheme (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseTxtarErrors(t *testing.T) {
+	for name, archive := range map[string]string{
+		"member twice":     "-- a.txt --\n-- a.txt --\n",
+		"stdout twice":     "--stdout\nx\n-- stdout --\ny\n",
+		"file twice":       "--file:a.txt\n-- a.txt --\n",
+		"escaping file":    "-- ../a.txt --\n",
+		"comment":          "--return-code: zero\n",
+		"steps and member": "--run\n-- stdout --\nx\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := exectest.ParseTxtar(archive); err == nil {
+				t.Errorf("Expected error for archive %q", archive)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package exectest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// DiffFormat is the rendering of the output mismatches, see [WithDiffFormat].
+type DiffFormat int
+
+const (
+	// GoCmp renders the mismatches with [cmp.Diff] of the slices of lines,
+	// the default.
+	GoCmp DiffFormat = iota
+	// Unified renders the mismatches as the classic unified diff.
+	Unified
+)
+
+// unifiedContext is the number of the unchanged lines around the changes of
+// the unified diff.
+const unifiedContext = 3
+
+// maxUnifiedCells limits the table of the longest common subsequence of the
+// unified diff, the changed lines are reported as replaced beyond it.
+const maxUnifiedCells = 1 << 22
+
+// WithDiffFormat renders the output mismatches in the format:
+//
+//	exectest.WithDiffFormat(exectest.Unified)
+//
+// renders them as:
+//
+//	--- want stdout
+//	+++ got stdout
+//	@@ -1,2 +1,2 @@
+//	 first
+//	-second
+//	+2nd
+func WithDiffFormat(format DiffFormat) Option {
+	return func(c *config) {
+		c.diffFormat = format
+	}
+}
+
+// diffOp is a line of the edit script.
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns the unified diff of the lines, empty if they're equal.
+func unifiedDiff(name string, want, got []string, opts []cmp.Option) string {
+	ops := diffLines(want, got, opts)
+	var changed bool
+	for _, op := range ops {
+		changed = changed || op.kind != ' '
+	}
+	if !changed {
+		return ""
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "--- want %s\n+++ got %s\n", name, name)
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		// the hunk spans the changes separated by at most 2*context lines.
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*unifiedContext {
+				break
+			}
+		}
+		from := max(start-unifiedContext, 0)
+		to := min(end+unifiedContext, len(ops))
+		writeHunk(&result, ops, from, to)
+		start = to
+	}
+	return result.String()
+}
+
+func writeHunk(result *strings.Builder, ops []diffOp, from, to int) {
+	// line numbers of the hunk start in the want and got.
+	wantLine, gotLine := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			wantLine++
+		}
+		if op.kind != '-' {
+			gotLine++
+		}
+	}
+	var wantCount, gotCount int
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			wantCount++
+		}
+		if op.kind != '-' {
+			gotCount++
+		}
+	}
+	fmt.Fprintf(result, "@@ -%s +%s @@\n", hunkRange(wantLine, wantCount), hunkRange(gotLine, gotCount))
+	for _, op := range ops[from:to] {
+		result.WriteByte(op.kind)
+		result.WriteString(strings.TrimSuffix(op.line, "\n"))
+		result.WriteByte('\n')
+	}
+}
+
+// hunkRange is the `start,count` range of the hunk header, the start is the
+// line before the hunk if it's empty.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// diffLines computes the edit script turning the want lines into the got
+// ones with the longest common subsequence.
+func diffLines(want, got []string, opts []cmp.Option) []diffOp {
+	var prefix int
+	for prefix < len(want) && prefix < len(got) && equalLines(want[prefix], got[prefix], opts) {
+		prefix++
+	}
+	var suffix int
+	for suffix < len(want)-prefix && suffix < len(got)-prefix &&
+		equalLines(want[len(want)-1-suffix], got[len(got)-1-suffix], opts) {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(want)+len(got))
+	for _, line := range got[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(want[prefix:len(want)-suffix], got[prefix:len(got)-suffix], opts)...)
+	for _, line := range got[len(got)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func diffMiddle(want, got []string, opts []cmp.Option) []diffOp {
+	var ops []diffOp
+	if (len(want)+1)*(len(got)+1) > maxUnifiedCells {
+		for _, line := range want {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range got {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// common[i][j] is the length of the longest common subsequence of the
+	// want[i:] and got[j:].
+	common := make([][]int, len(want)+1)
+	for i := range common {
+		common[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if equalLines(want[i], got[j], opts) {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && equalLines(want[i], got[j], opts):
+			ops = append(ops, diffOp{' ', got[j]})
+			i++
+			j++
+		case j < len(got) && (i == len(want) || common[i][j+1] > common[i+1][j]):
+			ops = append(ops, diffOp{'+', got[j]})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', want[i]})
+			i++
+		}
+	}
+	return ops
+}
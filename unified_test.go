@@ -0,0 +1,73 @@
+package exectest_test
+
+import (
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteUnifiedDiff(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "seq", `
+--arg:20
+--stdout
+1
+2
+3
+4
+5
+six
+7
+8
+9
+10
+11
+12
+13
+14
+15
+16
+17
+18
+19
+`, exectest.WithDiffFormat(exectest.Unified))
+	})
+
+	assertFailed(t, fake, `Failed matching stdout:
+--- want stdout
++++ got stdout
+@@ -3,7 +3,7 @@
+ 3
+ 4
+ 5
+-six
++6
+ 7
+ 8
+ 9
+@@ -17,3 +17,4 @@
+ 17
+ 18
+ 19
++20
+`)
+}
+
+func TestExecuteUnifiedDiffMissingLines(t *testing.T) {
+	fake := runFake(t, func(tb testing.TB) {
+		exectest.Execute(tb, "true", `
+--stdout
+missing
+`, exectest.WithDiffFormat(exectest.Unified))
+	})
+
+	assertFailed(t, fake, "@@ -1,1 +0,0 @@\n-missing\n")
+}
+
+func TestExecuteUnifiedDiffWithDiffOptions(t *testing.T) {
+	exectest.Execute(t, "echo", `
+--arg:Hello
+--stdout: ignore-case
+hello
+`, exectest.WithDiffFormat(exectest.Unified))
+}
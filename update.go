@@ -0,0 +1,231 @@
+package exectest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("exectest.update", false, "rewrite expected blocks of scheme files with the actual results")
+
+// updateMode reports whether scheme files should be rewritten instead of
+// asserted. It is enabled with the -exectest.update flag or EXECTEST_UPDATE=1.
+func updateMode() bool {
+	return *update || os.Getenv("EXECTEST_UPDATE") == "1"
+}
+
+// updateSchemeFile rewrites the `--stdout`, `--stderr`, `--output` and
+// `--return-code:` sections of the scheme file if they don't match the actual
+// results. Only the case of the source is rewritten if the scheme has cases.
+func updateSchemeFile(t testing.TB, source schemeSource, want schemeResult, got executionResult) {
+	t.Helper()
+	if len(checkResult(want, got)) == 0 {
+		return
+	}
+	file := source.file
+
+	got, err := got.withSpooled()
+	if err != nil {
+		t.Fatalf("Failed to update scheme file %s: %s", file, err)
+	}
+	updated, err := updateScheme(source.text, got, want.Dir)
+	if err != nil {
+		t.Fatalf("Failed to update scheme file %s: %s", file, err)
+	}
+	if source.caseName != "" {
+		// the other cases might be updated already, so the file is read again.
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read scheme file %s: %s", file, err)
+		}
+		if updated, err = replaceCase(string(content), source.caseName, updated); err != nil {
+			t.Fatalf("Failed to update scheme file %s: %s", file, err)
+		}
+	}
+	if err := os.WriteFile(file, []byte(updated), 0o644); err != nil {
+		t.Fatalf("Failed to write scheme file %s: %s", file, err)
+	}
+	t.Logf("Updated scheme file %s", file)
+}
+
+// updateScheme replaces expected blocks of the scheme with the actual results.
+// Missing blocks are appended to the end of the scheme.
+func updateScheme(scheme string, got executionResult, dir string) (string, error) {
+	stdout, err := formatBlock(got.Stdout, dir)
+	if err != nil {
+		return "", err
+	}
+	stderr, err := formatBlock(got.Stderr, dir)
+	if err != nil {
+		return "", err
+	}
+	output, err := formatBlock(got.Output, dir)
+	if err != nil {
+		return "", err
+	}
+
+	platform, err := selectPlatform(scheme, 1)
+	if err != nil {
+		return "", err
+	}
+	var result strings.Builder
+	var skipContent bool
+	var hasStdout, hasStderr, hasOutput, hasTermination bool
+	// the outputs of the line counts are not appended.
+	var hasStdoutLines, hasStderrLines bool
+	var heredoc heredocBody
+	for _, line := range toLines(scheme) {
+		if heredoc.inside(line) {
+			result.WriteString(line)
+			continue
+		}
+		directive, selected := platform.selected(line)
+		if !selected {
+			// the directives of the other platforms are kept as is.
+			result.WriteString(line)
+			if startsBlock(directive) {
+				skipContent = false
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(directive, filePrefix), strings.HasPrefix(directive, stubPrefix),
+			strings.HasPrefix(directive, stdinPrefix),
+			strings.HasPrefix(directive, interactPrefix), strings.HasPrefix(directive, stdoutExcludesPrefix),
+			strings.HasPrefix(directive, stderrExcludesPrefix), strings.HasPrefix(directive, expectTreePrefix),
+			strings.HasPrefix(directive, expectChangesPrefix):
+			result.WriteString(line)
+			skipContent = false
+		case strings.HasPrefix(directive, stdoutEmptyPrefix), strings.HasPrefix(directive, stdoutAnyPrefix),
+			strings.HasPrefix(directive, stdoutInOrderPrefix), strings.HasPrefix(directive, stdoutPrefixPrefix),
+			strings.HasPrefix(directive, stdoutSuffixPrefix):
+			result.WriteString(line)
+			hasStdout = true
+			skipContent = false
+		case strings.HasPrefix(directive, stderrEmptyPrefix), strings.HasPrefix(directive, stderrAnyPrefix),
+			strings.HasPrefix(directive, stderrInOrderPrefix), strings.HasPrefix(directive, stderrPrefixPrefix),
+			strings.HasPrefix(directive, stderrSuffixPrefix):
+			result.WriteString(line)
+			hasStderr = true
+			skipContent = false
+		case strings.HasPrefix(directive, stdoutLinesPrefix):
+			result.WriteString(line)
+			hasStdoutLines = true
+		case strings.HasPrefix(directive, stderrLinesPrefix):
+			result.WriteString(line)
+			hasStderrLines = true
+		case strings.HasPrefix(directive, stderrPrefix):
+			result.WriteString(line)
+			block := stderr
+			if strings.HasPrefix(directive, stderrBytesPrefix) {
+				if block, err = formatBytesBlock(got.Stderr, dir); err != nil {
+					return "", err
+				}
+			}
+			if strings.HasPrefix(directive, stderrHexPrefix) {
+				block = formatHexBlock(got.Stderr)
+			}
+			if !hasStderr {
+				result.WriteString(block)
+			}
+			hasStderr = true
+			skipContent = true
+		case strings.HasPrefix(directive, stdoutPrefix):
+			result.WriteString(line)
+			block := stdout
+			if strings.HasPrefix(directive, stdoutBytesPrefix) {
+				if block, err = formatBytesBlock(got.Stdout, dir); err != nil {
+					return "", err
+				}
+			}
+			if strings.HasPrefix(directive, stdoutHexPrefix) {
+				block = formatHexBlock(got.Stdout)
+			}
+			if !hasStdout {
+				result.WriteString(block)
+			}
+			hasStdout = true
+			skipContent = true
+		case strings.HasPrefix(directive, outputPrefix):
+			result.WriteString(line)
+			if !hasOutput {
+				result.WriteString(output)
+			}
+			hasOutput = true
+			skipContent = true
+		case strings.HasPrefix(directive, returnCodeAnyPrefix):
+			// the matching return code is kept as any of the listed ones.
+			codes, err := parseReturnCodes(strings.TrimPrefix(directive, returnCodeAnyPrefix))
+			switch {
+			case hasTermination:
+			case err == nil && matchesReturnCodes(codes, got):
+				result.WriteString(line)
+			default:
+				result.WriteString(qualifyLike(formatTermination(got), line))
+			}
+			hasTermination = true
+		case strings.HasPrefix(directive, returnCodePrefix), strings.HasPrefix(directive, killedByPrefix):
+			if !hasTermination {
+				result.WriteString(qualifyLike(formatTermination(got), line))
+			}
+			hasTermination = true
+		case isDirective(line), !skipContent:
+			result.WriteString(line)
+		}
+	}
+
+	if !hasTermination && (got.ReturnCode != 0 || got.KilledBy != "") {
+		result.WriteString(formatTermination(got))
+	}
+	if !hasStdout && !hasStdoutLines && stdout != "" {
+		result.WriteString(stdoutPrefix + "\n" + stdout)
+	}
+	if !hasStderr && !hasStderrLines && stderr != "" {
+		result.WriteString(stderrPrefix + "\n" + stderr)
+	}
+	return result.String(), nil
+}
+
+// formatTermination is the `--killed-by` directive for the process killed by
+// a signal and the `--return-code` one otherwise.
+func formatTermination(got executionResult) string {
+	if got.KilledBy != "" {
+		return killedByPrefix + " " + got.KilledBy + "\n"
+	}
+	return formatReturnCode(got.ReturnCode)
+}
+
+func formatReturnCode(code int) string {
+	return returnCodePrefix + " " + strconv.Itoa(code) + "\n"
+}
+
+// formatBlock turns output into a scheme block content with the scheme
+// directory replaced back with the `{dir}` placeholder.
+func formatBlock(output, dir string) (string, error) {
+	var block strings.Builder
+	for _, line := range toLines(output) {
+		if isDirective(line) {
+			return "", fmt.Errorf("output line %q can't be represented in a scheme", strings.TrimSuffix(line, "\n"))
+		}
+		if dir != "" {
+			line = strings.ReplaceAll(line, dir, "{dir}")
+		}
+		block.WriteString(line)
+	}
+	return block.String(), nil
+}
+
+// isDirective reports whether the line is interpreted by the scheme parser,
+// the platform qualified lines are the directives too.
+func isDirective(line string) bool {
+	line, _, _ = cutPlatforms(line)
+	for _, prefix := range directivePrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteForFileUpdateRewritesExpectedBlocks(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `Prints to both streams and fails.
+--arg:-c
+--arg:echo out; echo err >&2; echo "$PWD"; exit 3
+--stdout
+stale
+--stderr
+--return-code: 1
+`)
+
+	exectest.ExecuteForFile(t, "sh", file)
+
+	assertFileContent(t, file, `Prints to both streams and fails.
+--arg:-c
+--arg:echo out; echo err >&2; echo "$PWD"; exit 3
+--stdout
+out
+{dir}
+--stderr
+err
+--return-code: 3
+`)
+}
+
+func TestExecuteForFileUpdateAppendsMissingBlocks(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `--arg:-c
+--arg:echo out; exit 2
+`)
+
+	exectest.ExecuteForFile(t, "sh", file)
+
+	assertFileContent(t, file, `--arg:-c
+--arg:echo out; exit 2
+--return-code: 2
+--stdout
+out
+`)
+}
+
+func TestExecuteForFileUpdateKeepsMatchingScheme(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	scheme := `--stdin
+hello
+--stdout
+hello`
+	writeFile(t, file, scheme)
+
+	exectest.ExecuteForFile(t, "cat", file)
+
+	assertFileContent(t, file, scheme)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %s", path, err)
+	}
+}
+
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %s", path, err)
+	}
+	if string(got) != want {
+		t.Errorf("Unexpected content of %s:\nwant:\n%s\ngot:\n%s", path, want, got)
+	}
+}
+
+func TestExecuteForFileUpdateRewritesCombinedOutput(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `--arg:-c
+--arg:echo out; echo err >&2
+--output
+stale
+`)
+
+	exectest.ExecuteForFile(t, "sh", file)
+
+	assertFileContent(t, file, `--arg:-c
+--arg:echo out; echo err >&2
+--output
+out
+err
+`)
+}
+
+func TestExecuteForFileUpdateKeepsExcludes(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `--arg:out
+--stdout-excludes
+secret
+--stdout
+stale
+`)
+
+	exectest.ExecuteForFile(t, "echo", file)
+
+	assertFileContent(t, file, `--arg:out
+--stdout-excludes
+secret
+--stdout
+out
+`)
+}
+
+func TestExecuteForFileUpdateKeepsLineCounts(t *testing.T) {
+	t.Setenv("EXECTEST_UPDATE", "1")
+	file := filepath.Join(t.TempDir(), "scheme.txt")
+	writeFile(t, file, `--arg:-c
+--arg:date; exit 1
+--stdout-lines: 1
+`)
+
+	exectest.ExecuteForFile(t, "sh", file)
+
+	assertFileContent(t, file, `--arg:-c
+--arg:date; exit 1
+--stdout-lines: 1
+--return-code: 1
+`)
+}
@@ -0,0 +1,56 @@
+package exectest
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// WASIRunner is the [Runner] executing `.wasm` binaries with the `wazero`
+// command line WASI runtime, the command has to be on the PATH as there is no
+// runtime in the process. The scheme directory is preopened as the root of
+// the guest filesystem and as its own path, so relative paths and the `{dir}`
+// placeholder resolve to the same files as in the native runs. The own path
+// is not mounted if it has a drive letter, the `{dir}` of such a path can't
+// be a guest path. A relative binary is resolved in the working directory of
+// the test.
+type WASIRunner struct {
+	// Options are passed to `wazero run` before the module, e.g. -cachedir.
+	Options []string
+	// Program is the wazero command, "wazero" by default.
+	Program string
+}
+
+// Command returns the wazero cmd running the module.
+func (r WASIRunner) Command(_ testing.TB, invocation Invocation) (*exec.Cmd, func() error, error) {
+	module, err := filepath.Abs(invocation.Binary)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve module %s: %w", invocation.Binary, err)
+	}
+	program := r.Program
+	if program == "" {
+		program = "wazero"
+	}
+	dir := invocation.Dir
+	args := append(append([]string{"run"}, wasiMounts(dir)...), r.Options...)
+	for _, env := range invocation.Env {
+		args = append(args, "-env="+env)
+	}
+	args = append(args, module)
+	args = append(args, invocation.Args...)
+	cmd := exec.Command(program, args...)
+	cmd.Dir = dir
+	// the files are written to the mounted directory directly.
+	return cmd, func() error { return nil }, nil
+}
+
+// wasiMounts returns the mounts of the dir as the root and as its own path
+// unless the path has a drive letter, which wazero splits at the wrong colon.
+func wasiMounts(dir string) []string {
+	mounts := []string{"-mount=" + dir + ":/"}
+	if guest := filepath.ToSlash(dir); len(guest) < 2 || guest[1] != ':' {
+		mounts = append(mounts, "-mount="+dir+":"+guest)
+	}
+	return mounts
+}
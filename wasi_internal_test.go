@@ -0,0 +1,18 @@
+package exectest
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWASIMounts(t *testing.T) {
+	for dir, want := range map[string][]string{
+		"/tmp/x": {"-mount=/tmp/x:/", "-mount=/tmp/x:/tmp/x"},
+		`C:\x`:   {`-mount=C:\x:/`},
+		"C:/x/y": {"-mount=C:/x/y:/"},
+	} {
+		if got := wasiMounts(dir); !slices.Equal(got, want) {
+			t.Errorf("Expected mounts %q of %s, got %q", want, dir, got)
+		}
+	}
+}
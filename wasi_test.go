@@ -0,0 +1,36 @@
+package exectest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IlyasYOY/exectest"
+)
+
+func TestExecuteWASIRunner(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "wazero")
+	if err := os.WriteFile(program, []byte("#!/bin/sh\nprintf '%s\\n' \"$@\"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake wazero: %s", err)
+	}
+	module, err := filepath.Abs("app.wasm")
+	if err != nil {
+		t.Fatalf("Failed to resolve module: %s", err)
+	}
+
+	exectest.Execute(t, "app.wasm", `
+--arg:--in
+--arg:{dir}/in.txt
+--env:LEVEL=debug
+--stdout
+run
+-mount={dir}:/
+-mount={dir}:{dir}
+-cachedir=cache
+-env=LEVEL=debug
+{module}
+--in
+{dir}/in.txt
+`, exectest.WithRunner(exectest.WASIRunner{Program: program, Options: []string{"-cachedir=cache"}}),
+		exectest.WithVariable("module", func(string) string { return module }))
+}